@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/client"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/throttle"
+)
+
+// Exit codes of client subcommands
+const (
+	exitOK          = 0
+	exitCheckFailed = 1 // the check returned a non-OK status: the app should not write
+	exitUsage       = 2
+	exitError       = 3 // freno could not be reached, or the request failed
+)
+
+const defaultEndpoint = "http://127.0.0.1:8087"
+const cliAppName = "freno-cli"
+
+// cliCommand describes a client subcommand, which talks to a running freno service via HTTP
+type cliCommand struct {
+	name        string
+	usage       string
+	description string
+	run         func(cli *cliContext, args []string) int
+}
+
+var cliCommands = []*cliCommand{
+	{"check", "check [--read-threshold=<n>] [--if-exists] [--low-priority] <app> <store-type> <store-name>", "check whether app may write to a store; exits with 1 when it may not", runCheck},
+	{"throttle-app", "throttle-app [--ttl=<duration>] [--ratio=<ratio>] <app>", "throttle an app", runThrottleApp},
+	{"unthrottle-app", "unthrottle-app <app>", "remove throttling from an app", runUnthrottleApp},
+	{"throttled-apps", "throttled-apps", "list throttled apps", runThrottledApps},
+	{"app-thresholds", "app-thresholds", "list app thresholds overriding store thresholds", runAppThresholds},
+	{"throttle-schedules", "throttle-schedules", "list throttle schedules and their current or next windows", runThrottleSchedules},
+	{"throttle-audit", "throttle-audit [--app=<app>] [--since=<duration>]", "list audited throttle and unthrottle operations", runThrottleAudit},
+	{"recent-apps", "recent-apps [--last=<duration>]", "list apps which recently checked", runRecentApps},
+	{"metrics", "metrics", "list aggregated metrics", runMetrics},
+	{"hosts", "hosts <store-type> <store-name>", "list a store's hosts and their metrics", runHosts},
+	{"consensus", "consensus status", "show consensus status", runConsensus},
+}
+
+func getCliCommand(name string) *cliCommand {
+	for _, command := range cliCommands {
+		if command.name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+// cliContext holds the flags common to all client subcommands
+type cliContext struct {
+	flags     *flag.FlagSet
+	endpoints *string
+	token     *string
+	format    *string
+	timeout   *time.Duration
+	out       io.Writer
+	errOut    io.Writer
+}
+
+func newCliContext(command *cliCommand, out io.Writer, errOut io.Writer) *cliContext {
+	endpoint := os.Getenv("FRENO_ENDPOINT")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	flags := flag.NewFlagSet(command.name, flag.ContinueOnError)
+	flags.SetOutput(out)
+	cli := &cliContext{
+		flags:     flags,
+		endpoints: flags.String("endpoint", endpoint, "comma separated freno endpoints; defaults to $FRENO_ENDPOINT"),
+		token:     flags.String("token", os.Getenv("FRENO_TOKEN"), "bearer token for admin commands; defaults to $FRENO_TOKEN"),
+		format:    flags.String("format", "table", "output format: table|json"),
+		timeout:   flags.Duration("timeout", 5*time.Second, "request timeout"),
+		out:       out,
+		errOut:    errOut,
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(out, "Usage: freno %s\n  %s\n\nOptions:\n", command.usage, command.description)
+		flags.PrintDefaults()
+	}
+	return cli
+}
+
+// parse parses flags, which may be interleaved with positional arguments, and returns the positional arguments
+func (cli *cliContext) parse(args []string, numArgs int) (positional []string, ok bool) {
+	for {
+		if err := cli.flags.Parse(args); err != nil {
+			return positional, false
+		}
+		args = cli.flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != numArgs {
+		cli.flags.Usage()
+		return positional, false
+	}
+	if *cli.format != "table" && *cli.format != "json" {
+		fmt.Fprintf(cli.out, "unknown format: %s\n", *cli.format)
+		return positional, false
+	}
+	return positional, true
+}
+
+func (cli *cliContext) client(appName string) (*client.Client, error) {
+	return client.New(client.Config{
+		Endpoints: strings.Split(*cli.endpoints, ","),
+		App:       appName,
+		Timeout:   *cli.timeout,
+		Token:     *cli.token,
+	})
+}
+
+func (cli *cliContext) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), *cli.timeout)
+}
+
+// printJSON prints v as indented JSON when so requested, and otherwise returns false
+func (cli *cliContext) printJSON(v interface{}) bool {
+	if *cli.format != "json" {
+		return false
+	}
+	encoder := json.NewEncoder(cli.out)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+	return true
+}
+
+// printTable prints rows as an aligned table
+func (cli *cliContext) printTable(header []string, rows [][]string) {
+	w := tabwriter.NewWriter(cli.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+func (cli *cliContext) fail(err error) int {
+	fmt.Fprintf(cli.errOut, "%+v\n", err)
+	return exitError
+}
+
+// getJSON reads given API path into v
+func (cli *cliContext) getJSON(path string, v interface{}) error {
+	frenoClient, err := cli.client(cliAppName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cli.context()
+	defer cancel()
+	return frenoClient.GetJSON(ctx, path, v)
+}
+
+// runCliCommand runs a client subcommand and returns its exit code. Errors are written to errOut.
+func runCliCommand(name string, args []string, out io.Writer, errOut io.Writer) int {
+	command := getCliCommand(name)
+	if command == nil {
+		fmt.Fprintf(out, "unknown command: %s\n", name)
+		return exitUsage
+	}
+	return command.run(newCliContext(command, out, errOut), args)
+}
+
+func runCheck(cli *cliContext, args []string) int {
+	readThreshold := cli.flags.Float64("read-threshold", 0, "check against this threshold rather than the configured one")
+	ifExists := cli.flags.Bool("if-exists", false, "report OK when the metric is unknown")
+	lowPriority := cli.flags.Bool("low-priority", false, "make a low priority check")
+	positional, ok := cli.parse(args, 3)
+	if !ok {
+		return exitUsage
+	}
+	frenoClient, err := cli.client(positional[0])
+	if err != nil {
+		return cli.fail(err)
+	}
+	opts := []client.CheckOption{}
+	if *readThreshold > 0 {
+		opts = append(opts, client.ReadThreshold(*readThreshold))
+	}
+	if *ifExists {
+		opts = append(opts, client.IfExists())
+	}
+	if *lowPriority {
+		opts = append(opts, client.LowPriority())
+	}
+	ctx, cancel := cli.context()
+	defer cancel()
+	checkResult, err := frenoClient.Check(ctx, positional[1], positional[2], opts...)
+	if err != nil {
+		return cli.fail(err)
+	}
+	if !cli.printJSON(checkResult) {
+		cli.printTable([]string{"STATUS", "VALUE", "THRESHOLD", "MESSAGE"}, [][]string{
+			{fmt.Sprintf("%d", checkResult.StatusCode), fmt.Sprintf("%f", checkResult.Value), fmt.Sprintf("%f", checkResult.Threshold), checkResult.Message},
+		})
+	}
+	if !checkResult.OK() {
+		return exitCheckFailed
+	}
+	return exitOK
+}
+
+func runThrottleApp(cli *cliContext, args []string) int {
+	ttl := cli.flags.Duration("ttl", 0, "throttle duration, e.g. 30m; rounded to minutes. Default: freno's default")
+	ratio := cli.flags.Float64("ratio", -1, "throttle ratio in [0..1]. Default: freno's default")
+	positional, ok := cli.parse(args, 1)
+	if !ok {
+		return exitUsage
+	}
+	if err := base.ValidateAppName(positional[0]); err != nil {
+		return cli.fail(err)
+	}
+	frenoClient, err := cli.client(cliAppName)
+	if err != nil {
+		return cli.fail(err)
+	}
+	ctx, cancel := cli.context()
+	defer cancel()
+	if err := frenoClient.ThrottleApp(ctx, positional[0], *ttl, *ratio); err != nil {
+		return cli.fail(err)
+	}
+	return exitOK
+}
+
+func runUnthrottleApp(cli *cliContext, args []string) int {
+	positional, ok := cli.parse(args, 1)
+	if !ok {
+		return exitUsage
+	}
+	if err := base.ValidateAppName(positional[0]); err != nil {
+		return cli.fail(err)
+	}
+	frenoClient, err := cli.client(cliAppName)
+	if err != nil {
+		return cli.fail(err)
+	}
+	ctx, cancel := cli.context()
+	defer cancel()
+	if err := frenoClient.UnthrottleApp(ctx, positional[0]); err != nil {
+		return cli.fail(err)
+	}
+	return exitOK
+}
+
+func sortedKeys(m map[string]bool) (keys []string) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func runThrottledApps(cli *cliContext, args []string) int {
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	throttledApps := map[string]*base.AppThrottle{}
+	if err := cli.getJSON("/throttled-apps", &throttledApps); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(throttledApps) {
+		return exitOK
+	}
+	appNames := map[string]bool{}
+	for appName := range throttledApps {
+		appNames[appName] = true
+	}
+	rows := [][]string{}
+	for _, key := range sortedKeys(appNames) {
+		appThrottle := throttledApps[key]
+		appName := base.AppThrottleKeyApp(key, appThrottle.AppThrottleStore)
+		store := appThrottle.AppThrottleStore.String()
+		if store == "" {
+			store = "*"
+		}
+		origin := appThrottle.Origin
+		if origin == "" {
+			origin = "-"
+		}
+		match := "exact"
+		if rule, _ := base.ParseAppRule(appName); rule != nil {
+			match = rule.Kind
+		}
+		rows = append(rows, []string{appName, match, store, appThrottle.ExpireAt.Format(time.RFC3339), fmt.Sprintf("%.2f", appThrottle.Ratio), origin})
+	}
+	cli.printTable([]string{"APP", "MATCH", "STORE", "EXPIRE-AT", "RATIO", "ORIGIN"}, rows)
+	return exitOK
+}
+
+func runAppThresholds(cli *cliContext, args []string) int {
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	appThresholds := []base.AppThreshold{}
+	if err := cli.getJSON("/api/v1/app-thresholds", &appThresholds); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(appThresholds) {
+		return exitOK
+	}
+	rows := [][]string{}
+	for _, appThreshold := range appThresholds {
+		match := "exact"
+		if rule, _ := base.ParseAppRule(appThreshold.App); rule != nil {
+			match = rule.Kind
+		}
+		source := "runtime"
+		if appThreshold.Configured {
+			source = "config"
+		}
+		rows = append(rows, []string{appThreshold.App, match, appThreshold.AppThrottleStore.String(), fmt.Sprintf("%g", appThreshold.Threshold), source})
+	}
+	cli.printTable([]string{"APP", "MATCH", "STORE", "THRESHOLD", "SOURCE"}, rows)
+	return exitOK
+}
+
+func runThrottleSchedules(cli *cliContext, args []string) int {
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	schedules := []group.ThrottleScheduleStatus{}
+	if err := cli.getJSON("/api/v1/throttle-schedules", &schedules); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(schedules) {
+		return exitOK
+	}
+	rows := [][]string{}
+	for _, schedule := range schedules {
+		window := "next"
+		if schedule.Active {
+			window = "active"
+		}
+		rows = append(rows, []string{schedule.Name, schedule.Cron, schedule.Duration, strings.Join(schedule.Apps, ","), fmt.Sprintf("%.2f", schedule.Ratio), window, schedule.WindowStart.Format(time.RFC3339), schedule.WindowEnd.Format(time.RFC3339)})
+	}
+	cli.printTable([]string{"NAME", "CRON", "DURATION", "APPS", "RATIO", "WINDOW", "START", "END"}, rows)
+	return exitOK
+}
+
+func runThrottleAudit(cli *cliContext, args []string) int {
+	app := cli.flags.String("app", "", "only list operations on this app")
+	since := cli.flags.Duration("since", 0, "only list operations within this duration, e.g. 24h")
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	query := url.Values{}
+	if *app != "" {
+		query.Set("app", *app)
+	}
+	if *since > 0 {
+		query.Set("since", since.String())
+	}
+	entries := []base.ThrottleAuditEntry{}
+	if err := cli.getJSON("/throttle-audit?"+query.Encode(), &entries); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(entries) {
+		return exitOK
+	}
+	rows := [][]string{}
+	for _, entry := range entries {
+		ttl, ratio := "-", "-"
+		if entry.TTLMinutes > 0 {
+			ttl = (time.Duration(entry.TTLMinutes) * time.Minute).String()
+		}
+		if entry.Operation == "throttle" && entry.Ratio >= 0 {
+			ratio = fmt.Sprintf("%.2f", entry.Ratio)
+		}
+		rows = append(rows, []string{entry.Time.Format(time.RFC3339), entry.Operation, entry.App, ttl, ratio, entry.Requester, entry.RemoteAddr, entry.Reason})
+	}
+	cli.printTable([]string{"TIME", "OPERATION", "APP", "TTL", "RATIO", "REQUESTER", "REMOTE-ADDR", "REASON"}, rows)
+	return exitOK
+}
+
+func runRecentApps(cli *cliContext, args []string) int {
+	last := cli.flags.Duration("last", 0, "only list apps which checked within this duration, e.g. 10m; rounded up to minutes")
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	path := "/recent-apps"
+	if *last > 0 {
+		path = fmt.Sprintf("/recent-apps/%d", int64((*last+time.Minute-1)/time.Minute))
+	}
+	recentApps := map[string]*base.RecentApp{}
+	if err := cli.getJSON(path, &recentApps); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(recentApps) {
+		return exitOK
+	}
+	keys := map[string]bool{}
+	for key := range recentApps {
+		keys[key] = true
+	}
+	rows := [][]string{}
+	for _, key := range sortedKeys(keys) {
+		recentApp := recentApps[key]
+		rows = append(rows, []string{key, time.Unix(recentApp.CheckedAtEpoch, 0).Format(time.RFC3339), fmt.Sprintf("%d", recentApp.MinutesSinceChecked)})
+	}
+	cli.printTable([]string{"APP/HOST", "CHECKED-AT", "MINUTES-AGO"}, rows)
+	return exitOK
+}
+
+func runMetrics(cli *cliContext, args []string) int {
+	if _, ok := cli.parse(args, 0); !ok {
+		return exitUsage
+	}
+	aggregatedMetrics := map[string]string{}
+	if err := cli.getJSON("/aggregated-metrics", &aggregatedMetrics); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(aggregatedMetrics) {
+		return exitOK
+	}
+	metricNames := map[string]bool{}
+	for metricName := range aggregatedMetrics {
+		metricNames[metricName] = true
+	}
+	rows := [][]string{}
+	for _, metricName := range sortedKeys(metricNames) {
+		rows = append(rows, []string{metricName, aggregatedMetrics[metricName]})
+	}
+	cli.printTable([]string{"METRIC", "VALUE"}, rows)
+	return exitOK
+}
+
+func runHosts(cli *cliContext, args []string) int {
+	positional, ok := cli.parse(args, 2)
+	if !ok {
+		return exitUsage
+	}
+	hostMetrics := []*throttle.HostMetric{}
+	if err := cli.getJSON(fmt.Sprintf("/metrics/%s/%s/hosts", positional[0], positional[1]), &hostMetrics); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(hostMetrics) {
+		return exitOK
+	}
+	rows := [][]string{}
+	for _, hostMetric := range hostMetrics {
+		value := fmt.Sprintf("%f", hostMetric.Value)
+		if hostMetric.Error != "" {
+			value = "error: " + hostMetric.Error
+		}
+		part := "included"
+		if hostMetric.Excluded {
+			part = "excluded"
+		} else if hostMetric.Ignored {
+			part = "ignored"
+		}
+		lastProbeTime := ""
+		if !hostMetric.LastProbeTime.IsZero() {
+			lastProbeTime = hostMetric.LastProbeTime.Format(time.RFC3339)
+		}
+		httpCheckStatus := ""
+		if hostMetric.HttpCheckStatus != 0 {
+			httpCheckStatus = fmt.Sprintf("%d", hostMetric.HttpCheckStatus)
+		}
+		rows = append(rows, []string{hostMetric.Host, value, lastProbeTime, httpCheckStatus, part, hostMetric.Reason})
+	}
+	cli.printTable([]string{"HOST", "VALUE", "LAST-PROBE", "HTTP-CHECK", "AGGREGATION", "REASON"}, rows)
+	return exitOK
+}
+
+func runConsensus(cli *cliContext, args []string) int {
+	positional, ok := cli.parse(args, 1)
+	if !ok {
+		return exitUsage
+	}
+	if positional[0] != "status" {
+		cli.flags.Usage()
+		return exitUsage
+	}
+	status := &group.ConsensusServiceStatus{}
+	if err := cli.getJSON("/consensus/status", status); err != nil {
+		return cli.fail(err)
+	}
+	if cli.printJSON(status) {
+		return exitOK
+	}
+	cli.printTable([]string{"SERVICE-ID", "STATE", "LEADER", "HEALTHY", "DOMAIN", "SHARE-DOMAIN"}, [][]string{
+		{status.ServiceID, status.State, status.Leader, fmt.Sprintf("%t", status.Healthy), status.Domain, status.ShareDomain},
+	})
+	return exitOK
+}
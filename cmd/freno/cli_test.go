@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/internal/raft"
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/http"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/outbrain/golib/log"
+	test "github.com/outbrain/golib/tests"
+)
+
+func init() {
+	log.SetLevel(log.ERROR)
+}
+
+// leaderConsensusService applies requests directly onto the throttler, as a single leader node would.
+// Other consensus operations are not expected.
+type leaderConsensusService struct {
+	group.ConsensusService
+	throttler *throttle.Throttler
+}
+
+func (service *leaderConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	return "localhost", nil
+}
+func (service *leaderConsensusService) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (string, error) {
+	service.throttler.UnthrottleAppOnStore(appName, store)
+	return "localhost", nil
+}
+func (service *leaderConsensusService) ThrottledAppsMap() map[string](*base.AppThrottle) {
+	return service.throttler.ThrottledAppsMap()
+}
+func (service *leaderConsensusService) AppThresholds() []base.AppThreshold {
+	return service.throttler.AppThresholds()
+}
+func (service *leaderConsensusService) IsLeader() bool { return true }
+
+func TestCliCommands(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	api := http.NewAPIImpl(throttle.NewThrottlerCheck(throttler), &leaderConsensusService{throttler: throttler})
+	server := httptest.NewServer(http.ConfigureRoutes(api))
+	defer server.Close()
+
+	throttler.ThrottleApp("archiver", time.Now().Add(time.Hour), 0.8)
+	throttler.SetAppThreshold("prefix:gh-ost", base.AppThrottleStore{StoreType: "mysql", StoreName: "main7"}, 1.5)
+
+	isThrottled := func(key string) func() bool {
+		return func() bool {
+			_, found := throttler.ThrottledAppsMap()[key]
+			return found
+		}
+	}
+	isNotThrottled := func(key string) func() bool {
+		return func() bool { return !isThrottled(key)() }
+	}
+	cases := []struct {
+		name     string
+		command  string
+		args     []string
+		exitCode int
+		output   []string    // expected in the output
+		errors   []string    // expected in the error output
+		verify   func() bool // verifies freno's resulting state
+	}{
+		{name: "throttle-app", command: "throttle-app", args: []string{"--ttl=30m", "--ratio=0.5", "gh-ost"}, exitCode: exitOK, verify: func() bool {
+			appThrottle, found := throttler.ThrottledAppsMap()["gh-ost"]
+			return found && appThrottle.Ratio == 0.5 && appThrottle.ExpireAt.Before(time.Now().Add(31*time.Minute))
+		}},
+		{name: "throttle-app flags after app", command: "throttle-app", args: []string{"prefix:archiver/", "--ratio=0.3"}, exitCode: exitOK, verify: isThrottled("prefix:archiver/")},
+		{name: "throttle-app no app", command: "throttle-app", args: []string{}, exitCode: exitUsage, output: []string{"Usage: freno throttle-app"}},
+		{name: "throttle-app two apps", command: "throttle-app", args: []string{"gh-ost", "archiver"}, exitCode: exitUsage},
+		{name: "throttle-app invalid ttl", command: "throttle-app", args: []string{"--ttl=soon", "gh-ost"}, exitCode: exitUsage},
+		{name: "throttle-app unknown flag", command: "throttle-app", args: []string{"--store=mysql", "gh-ost"}, exitCode: exitUsage},
+		{name: "throttle-app invalid format", command: "throttle-app", args: []string{"--format=yaml", "gh-ost"}, exitCode: exitUsage, output: []string{"unknown format: yaml"}},
+		{name: "throttle-app invalid app", command: "throttle-app", args: []string{"gh-ost@mysql"}, exitCode: exitError, errors: []string{`may not contain "@"`}, verify: isNotThrottled("gh-ost@mysql")},
+		{name: "throttle-app invalid ratio", command: "throttle-app", args: []string{"--ratio=1.5", "migration"}, exitCode: exitError, errors: []string{"400 ratio must be in [0..1] range"}, verify: isNotThrottled("migration")},
+		{name: "throttled-apps", command: "throttled-apps", args: []string{}, exitCode: exitOK, output: []string{"APP", "archiver", "exact", "0.80", "gh-ost", "prefix:archiver/", "prefix", "0.30"}},
+		{name: "throttled-apps json", command: "throttled-apps", args: []string{"--format=json"}, exitCode: exitOK, output: []string{`"prefix:archiver/": {`, `"Ratio": 0.5`}},
+		{name: "throttled-apps extra argument", command: "throttled-apps", args: []string{"gh-ost"}, exitCode: exitUsage},
+		{name: "unthrottle-app", command: "unthrottle-app", args: []string{"gh-ost"}, exitCode: exitOK, verify: isNotThrottled("gh-ost")},
+		{name: "unthrottle-app rule", command: "unthrottle-app", args: []string{"prefix:archiver/"}, exitCode: exitOK, verify: func() bool {
+			return isNotThrottled("prefix:archiver/")() && isThrottled("archiver")()
+		}},
+		{name: "unthrottle-app no app", command: "unthrottle-app", args: []string{}, exitCode: exitUsage},
+		{name: "unthrottle-app invalid app", command: "unthrottle-app", args: []string{"gh-ost@mysql"}, exitCode: exitError},
+		{name: "app-thresholds", command: "app-thresholds", args: []string{}, exitCode: exitOK, output: []string{"THRESHOLD", "prefix:gh-ost", "prefix", "mysql/main7", "1.5", "runtime"}},
+		{name: "app-thresholds json", command: "app-thresholds", args: []string{"--format=json"}, exitCode: exitOK, output: []string{`"App": "prefix:gh-ost"`, `"Threshold": 1.5`}},
+		{name: "app-thresholds extra argument", command: "app-thresholds", args: []string{"mysql"}, exitCode: exitUsage},
+		{name: "unknown command", command: "throttle-everything", args: []string{}, exitCode: exitUsage, output: []string{"unknown command"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			args := append([]string{"--endpoint=" + server.URL}, c.args...)
+			test.S(t).ExpectEquals(runCliCommand(c.command, args, &out, &errOut), c.exitCode)
+			for _, expected := range c.output {
+				test.S(t).ExpectTrue(strings.Contains(out.String(), expected))
+			}
+			for _, expected := range c.errors {
+				test.S(t).ExpectTrue(strings.Contains(errOut.String(), expected))
+			}
+			if c.verify != nil {
+				test.S(t).ExpectTrue(c.verify())
+			}
+		})
+	}
+}
+
+func TestCliCommandsAdminAuthorization(t *testing.T) {
+	settings := config.Settings()
+	settings.AdminTokens = []string{"s3cret"}
+	defer func() { settings.AdminTokens = nil }()
+
+	throttler := throttle.NewThrottler()
+	api := http.NewAPIImpl(throttle.NewThrottlerCheck(throttler), &leaderConsensusService{throttler: throttler})
+	server := httptest.NewServer(http.ConfigureRoutes(api))
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		args     []string
+		exitCode int
+		errors   []string
+	}{
+		{name: "no token", args: []string{"gh-ost"}, exitCode: exitError, errors: []string{"401"}},
+		{name: "wrong token", args: []string{"--token=guess", "gh-ost"}, exitCode: exitError, errors: []string{"403"}},
+		{name: "token", args: []string{"--token=s3cret", "gh-ost"}, exitCode: exitOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var errOut bytes.Buffer
+			args := append([]string{"--endpoint=" + server.URL}, c.args...)
+			test.S(t).ExpectEquals(runCliCommand("throttle-app", args, ioutil.Discard, &errOut), c.exitCode)
+			for _, expected := range c.errors {
+				test.S(t).ExpectTrue(strings.Contains(errOut.String(), expected))
+			}
+		})
+	}
+	_, found := throttler.ThrottledAppsMap()["gh-ost"]
+	test.S(t).ExpectTrue(found)
+}
+
+func TestCliCommandsUnreachable(t *testing.T) {
+	for _, command := range []string{"throttle-app", "unthrottle-app"} {
+		t.Run(command, func(t *testing.T) {
+			test.S(t).ExpectEquals(runCliCommand(command, []string{"--endpoint=http://127.0.0.1:1", "--timeout=1s", "gh-ost"}, ioutil.Discard, ioutil.Discard), exitError)
+		})
+	}
+	for _, command := range []string{"throttled-apps", "app-thresholds"} {
+		t.Run(command, func(t *testing.T) {
+			test.S(t).ExpectEquals(runCliCommand(command, []string{"--endpoint=http://127.0.0.1:1", "--timeout=1s"}, ioutil.Discard, ioutil.Discard), exitError)
+		})
+	}
+}
+
+func TestSnapshotCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-snapshot")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	snapshots, err := raft.NewFileSnapshotStore(dir, 1, ioutil.Discard)
+	test.S(t).ExpectNil(err)
+	sink, err := snapshots.Create(1, 1, nil)
+	test.S(t).ExpectNil(err)
+	_, err = sink.Write([]byte(`{"version":1,"throttledApps":{"archiver":{"ExpireAt":"2030-01-01T00:00:00Z","Ratio":0.8}}}`))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNil(sink.Close())
+
+	cases := []struct {
+		name     string
+		args     []string
+		exitCode int
+		output   []string
+		errors   []string
+	}{
+		{name: "inspect", args: []string{"inspect", dir}, exitCode: exitOK, output: []string{`"Index": 1`, `"archiver": {`, `"Ratio": 0.8`}},
+		{name: "no subcommand", args: []string{}, exitCode: exitUsage, errors: []string{"Usage: freno snapshot inspect <dir>"}},
+		{name: "unknown subcommand", args: []string{"restore", dir}, exitCode: exitUsage, errors: []string{"unknown subcommand restore"}},
+		{name: "inspect no directory", args: []string{"inspect"}, exitCode: exitUsage},
+		{name: "inspect two directories", args: []string{"inspect", dir, dir}, exitCode: exitUsage},
+		{name: "inspect missing directory", args: []string{"inspect", dir + "/nosuchdir"}, exitCode: exitError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			test.S(t).ExpectEquals(runSnapshotCommand(c.args, &out, &errOut), c.exitCode)
+			for _, expected := range c.output {
+				test.S(t).ExpectTrue(strings.Contains(out.String(), expected))
+			}
+			for _, expected := range c.errors {
+				test.S(t).ExpectTrue(strings.Contains(errOut.String(), expected))
+			}
+		})
+	}
+}
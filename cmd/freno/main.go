@@ -3,13 +3,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net"
 	gohttp "net/http"
+	"os"
 	"strings"
 
 	"github.com/github/freno/pkg/config"
 	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/grpc"
 	"github.com/github/freno/pkg/http"
 	"github.com/github/freno/pkg/throttle"
+	"github.com/github/freno/pkg/tlsutil"
 	"github.com/outbrain/golib/log"
 )
 
@@ -27,6 +31,7 @@ func main() {
 	// The next group of variables override configuration file settings. This allows for easy local testing.
 	// In the general deployment case they will not be used.
 	httpPort := flag.Int("http-port", 0, "HTTP listen port; overrides config's ListenPort")
+	grpcPort := flag.Int("grpc-port", 0, "gRPC listen port; overrides config's GRPCListenPort")
 	raftDataDir := flag.String("raft-datadir", "", "Data directory for raft backend db; overrides config's RaftDataDir")
 	raftBind := flag.String("raft-bind", "", "Raft bind address (example: '127.0.0.1:10008'). Overrides config's RaftBind")
 	raftNodes := flag.String("raft-nodes", "", "Comma separated (e.g. 'host:port[,host:port]') list of raft nodes. Overrides config's RaftNodes")
@@ -61,6 +66,16 @@ func main() {
 		// Override!!
 		log.SetLevel(log.ERROR)
 	}
+
+	if flag.Arg(0) == "snapshot" {
+		// offline tooling; does not require a configuration file
+		os.Exit(runSnapshotCommand(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+	if getCliCommand(flag.Arg(0)) != nil {
+		// client tooling; talks to a running freno service
+		os.Exit(runCliCommand(flag.Arg(0), flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
 	log.Infof("starting freno %s", AppVersion)
 
 	loadConfiguration(*configFile)
@@ -78,6 +93,9 @@ func main() {
 	if *httpPort > 0 {
 		config.Settings().ListenPort = *httpPort
 	}
+	if *grpcPort > 0 {
+		config.Settings().GRPCListenPort = *grpcPort
+	}
 
 	switch {
 	case *http:
@@ -115,19 +133,83 @@ func httpServe() error {
 	throttler.SetSharedDomainServicesFunc(consensusServiceProvider.GetConsensusService().GetSharedDomainServices)
 
 	go consensusServiceProvider.Monitor()
+	go group.NewThrottleScheduler(consensusServiceProvider.GetConsensusService()).Run()
 	go throttler.Operate()
 
 	throttlerCheck := throttle.NewThrottlerCheck(throttler)
 	throttlerCheck.SelfChecks()
+	if grpcPort := config.Settings().GRPCListenPort; grpcPort > 0 {
+		go func() {
+			log.Errore(grpcServe(throttlerCheck, consensusServiceProvider.GetConsensusService(), grpcPort))
+		}()
+	}
 	api := http.NewAPIImpl(throttlerCheck, consensusServiceProvider.GetConsensusService())
 	router := http.ConfigureRoutes(api)
 	port := config.Settings().ListenPort
+	if certFile := config.Settings().HTTPTLSCertFile; certFile != "" {
+		reloader, err := tlsutil.NewReloader(tlsutil.Files{
+			CAFile:   config.Settings().HTTPTLSCAFile,
+			CertFile: certFile,
+			KeyFile:  config.Settings().HTTPTLSKeyFile,
+		})
+		if err != nil {
+			return err
+		}
+		server := &gohttp.Server{
+			Addr:      fmt.Sprintf(":%d", port),
+			Handler:   router,
+			TLSConfig: tlsutil.ServerConfig(reloader, config.Settings().HTTPTLSRequireClient),
+		}
+		log.Infof("Starting TLS server in port %d", port)
+		return server.ListenAndServeTLS("", "")
+	}
 	log.Infof("Starting server in port %d", port)
 	return gohttp.ListenAndServe(fmt.Sprintf(":%d", port), router)
 }
 
+func grpcServe(throttlerCheck *throttle.ThrottlerCheck, consensusService group.ConsensusService, port int) error {
+	opts, err := grpc.ServerOptions()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(grpc.NewServerImpl(throttlerCheck, consensusService), opts...)
+	if config.Settings().HTTPTLSCertFile != "" {
+		log.Infof("Starting gRPC TLS server in port %d", port)
+	} else {
+		log.Infof("Starting gRPC server in port %d", port)
+	}
+	return server.Serve(listener)
+}
+
 func printHelp() {
-	panic("not yet implemented")
+	fmt.Println(`Usage: freno [OPTIONS] [COMMAND [ARGS]]
+
+Run the freno service with: freno --http [OPTIONS]
+
+Options:`)
+	flag.CommandLine.SetOutput(os.Stdout)
+	flag.PrintDefaults()
+
+	fmt.Println(`
+Commands:
+	snapshot inspect <dir>
+	  inspect the raft snapshots found in a raft data directory`)
+	for _, command := range cliCommands {
+		fmt.Printf("\t%s\n\t  %s\n", command.usage, command.description)
+	}
+	fmt.Printf(`
+Commands other than snapshot talk to a running freno service via HTTP. They accept:
+	--endpoint   comma separated freno endpoints (default: $FRENO_ENDPOINT, or %s)
+	--token      bearer token for admin commands (default: $FRENO_TOKEN)
+	--format     table|json (default: table)
+	--timeout    request timeout (default: 5s)
+
+Exit codes: %d success; %d check not OK; %d usage error; %d freno unreachable or request failed
+`, defaultEndpoint, exitOK, exitCheckFailed, exitUsage, exitError)
 }
 
 func printUsage() {
@@ -135,6 +217,14 @@ func printUsage() {
 	To run the freno service, execute:
 		freno --http
 
+	To inspect the raft snapshots found in a raft data directory, execute:
+		freno snapshot inspect <dir>
+
+	To query or administer a running freno service, execute e.g.:
+		freno check <app> <store-type> <store-name>
+		freno throttle-app --ttl=30m --ratio=0.5 <app>
+		freno consensus status
+
 	For more help options use: freno -help.
 
 	freno is a free and open source software.
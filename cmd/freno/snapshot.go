@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/github/freno/pkg/group"
+)
+
+const snapshotUsage = "Usage: freno snapshot inspect <dir>"
+
+// runSnapshotCommand handles `freno snapshot <subcommand>`, which operates offline on raft snapshot files and
+// does not require a running freno service. It returns an exit code, same as client subcommands do, and writes
+// errors to errOut.
+func runSnapshotCommand(args []string, out io.Writer, errOut io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(errOut, "snapshot: expected a subcommand. %s\n", snapshotUsage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "inspect":
+		if len(args) != 2 {
+			fmt.Fprintf(errOut, "snapshot inspect: expected exactly one directory. %s\n", snapshotUsage)
+			return exitUsage
+		}
+		inspections, err := group.InspectSnapshots(args[1])
+		if err != nil {
+			fmt.Fprintf(errOut, "%+v\n", err)
+			return exitError
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(inspections)
+		return exitOK
+	}
+	fmt.Fprintf(errOut, "snapshot: unknown subcommand %s. %s\n", args[0], snapshotUsage)
+	return exitUsage
+}
@@ -7,11 +7,16 @@ require (
 	github.com/boltdb/bolt v1.3.1
 	github.com/bradfitz/gomemcache v0.0.0-20190913173617-a41fca850d0b
 	github.com/go-sql-driver/mysql v1.5.0 // indirect
+	github.com/golang/protobuf v1.3.5
 	github.com/hashicorp/go-msgpack v0.5.5
 	github.com/julienschmidt/httprouter v1.3.0
+	github.com/lib/pq v1.10.9
 	github.com/outbrain/golib v0.0.0-20180830062331-ab954725f502
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/rcrowley/go-metrics v0.0.0-20190826022208-cac0b30c2563
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/segmentio/kafka-go v0.4.17
 	golang.org/x/sys v0.0.0-20190602015325-4c4f7f33c9ed // indirect
+	google.golang.org/grpc v1.29.1
 	vitess.io/vitess v2.1.1+incompatible
 )
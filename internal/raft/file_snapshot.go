@@ -112,6 +112,23 @@ func NewFileSnapshotStore(base string, retain int, logOutput io.Writer) (*FileSn
 	return NewFileSnapshotStoreWithLogger(base, retain, log.New(logOutput, "", log.LstdFlags))
 }
 
+// OpenFileSnapshotStore opens the existing snapshots of a base directory for reading. Unlike
+// NewFileSnapshotStore, it neither creates the snapshot path nor writes to it to test permissions.
+func OpenFileSnapshotStore(base string, logOutput io.Writer) (*FileSnapshotStore, error) {
+	if logOutput == nil {
+		logOutput = os.Stderr
+	}
+	path := filepath.Join(base, snapPath)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("snapshot path not accessible: %v", err)
+	}
+	return &FileSnapshotStore{
+		path:   path,
+		retain: 1,
+		logger: log.New(logOutput, "", log.LstdFlags),
+	}, nil
+}
+
 // testPermissions tries to touch a file in our path to see if it works.
 func (f *FileSnapshotStore) testPermissions() error {
 	path := filepath.Join(f.path, testPath)
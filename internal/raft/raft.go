@@ -109,6 +109,10 @@ type Raft struct {
 	// leaderState used only while state is leader
 	leaderState leaderState
 
+	// replStateLock protects changes to leaderState.replState, which are made by the
+	// main thread, against concurrent readers such as PeerLastContact
+	replStateLock sync.RWMutex
+
 	// Stores our local addr
 	localAddr string
 
@@ -468,6 +472,25 @@ func (r *Raft) LastContact() time.Time {
 	return last
 }
 
+// PeerLastContact returns the time of last contact with each follower.
+// This only makes sense if we are currently the leader; otherwise the
+// result is empty.
+func (r *Raft) PeerLastContact() map[string]time.Time {
+	r.replStateLock.RLock()
+	defer r.replStateLock.RUnlock()
+
+	contacts := make(map[string]time.Time, len(r.leaderState.replState))
+	for peer, repl := range r.leaderState.replState {
+		contacts[peer] = repl.LastContact()
+	}
+	return contacts
+}
+
+// Peers returns the known set of peers, including ourself
+func (r *Raft) Peers() ([]string, error) {
+	return r.peerStore.Peers()
+}
+
 // Stats is used to return a map of various internal stats. This
 // should only be used for informative purposes or debugging.
 //
@@ -781,7 +804,9 @@ func (r *Raft) runLeader() {
 	// Setup leader state
 	r.leaderState.commitCh = make(chan struct{}, 1)
 	r.leaderState.inflight = newInflight(r.leaderState.commitCh)
+	r.replStateLock.Lock()
 	r.leaderState.replState = make(map[string]*followerReplication)
+	r.replStateLock.Unlock()
 	r.leaderState.notify = make(map[*verifyFuture]struct{})
 	r.leaderState.stepDown = make(chan struct{}, 1)
 
@@ -810,7 +835,9 @@ func (r *Raft) runLeader() {
 		// Clear all the state
 		r.leaderState.commitCh = nil
 		r.leaderState.inflight = nil
+		r.replStateLock.Lock()
 		r.leaderState.replState = nil
+		r.replStateLock.Unlock()
 		r.leaderState.notify = nil
 		r.leaderState.stepDown = nil
 
@@ -885,7 +912,9 @@ func (r *Raft) startReplication(peer string) {
 		notifyCh:    make(chan struct{}, 1),
 		stepDown:    r.leaderState.stepDown,
 	}
+	r.replStateLock.Lock()
 	r.leaderState.replState[peer] = s
+	r.replStateLock.Unlock()
 	r.goFunc(func() { r.replicate(s) })
 	asyncNotifyCh(s.triggerCh)
 }
@@ -1266,9 +1295,11 @@ func (r *Raft) processLog(l *Log, future *logFuture, precommit bool) (stepDown b
 					toDelete = append(toDelete, repl.peer)
 				}
 			}
+			r.replStateLock.Lock()
 			for _, name := range toDelete {
 				delete(r.leaderState.replState, name)
 			}
+			r.replStateLock.Unlock()
 		}
 
 		// Handle removing ourself
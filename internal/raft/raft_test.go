@@ -1042,6 +1042,32 @@ func TestRaft_RemoveFollower(t *testing.T) {
 	}
 }
 
+func TestRaft_PeerLastContact(t *testing.T) {
+	// Make a cluster
+	c := MakeCluster(3, t, nil)
+	defer c.Close()
+
+	// Get the leader
+	leader := c.Leader()
+	c.WaitForReplication(0)
+
+	// The leader tracks contact with all followers
+	contacts := leader.PeerLastContact()
+	if len(contacts) != 2 {
+		c.FailNowf("[ERR] expected two followers: %v", contacts)
+	}
+	for _, follower := range c.Followers() {
+		last, ok := contacts[follower.localAddr]
+		if !ok || last.IsZero() {
+			c.FailNowf("[ERR] no contact with %v", follower.localAddr)
+		}
+		// Followers do not track peers
+		if len(follower.PeerLastContact()) != 0 {
+			c.FailNowf("[ERR] follower should not track peer contact")
+		}
+	}
+}
+
 func TestRaft_RemoveLeader(t *testing.T) {
 	// Make a cluster
 	c := MakeCluster(3, t, nil)
@@ -52,6 +52,18 @@ func newTCPTransport(bindAddr string,
 	maxPool int,
 	timeout time.Duration,
 	transportCreator func(stream StreamLayer) *NetworkTransport) (*NetworkTransport, error) {
+	stream, err := newTCPStreamLayer(bindAddr, advertise)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the network transport
+	trans := transportCreator(stream)
+	return trans, nil
+}
+
+// newTCPStreamLayer binds to given address and verifies the advertised address is usable
+func newTCPStreamLayer(bindAddr string, advertise net.Addr) (*TCPStreamLayer, error) {
 	// Try to bind
 	list, err := net.Listen("tcp", bindAddr)
 	if err != nil {
@@ -74,10 +86,7 @@ func newTCPTransport(bindAddr string,
 		list.Close()
 		return nil, errNotAdvertisable
 	}
-
-	// Create the network transport
-	trans := transportCreator(stream)
-	return trans, nil
+	return stream, nil
 }
 
 // Dial implements the StreamLayer interface.
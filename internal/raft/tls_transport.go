@@ -0,0 +1,82 @@
+package raft
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+var errNoTLSConfig = errors.New("no TLS configuration given")
+
+// TLSStreamLayer implements StreamLayer interface for TLS over TCP. The same TLS configuration
+// serves both incoming and outgoing connections; for mutual authentication it is expected to
+// require and verify client certificates, as well as to present a certificate when dialing.
+type TLSStreamLayer struct {
+	*TCPStreamLayer
+	config *tls.Config
+}
+
+// NewTLSTransport returns a NetworkTransport that is built on top of
+// a TLS streaming transport layer.
+func NewTLSTransport(
+	bindAddr string,
+	advertise net.Addr,
+	tlsConfig *tls.Config,
+	maxPool int,
+	timeout time.Duration,
+	logOutput io.Writer,
+) (*NetworkTransport, error) {
+	stream, err := NewTLSStreamLayer(bindAddr, advertise, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewNetworkTransport(stream, maxPool, timeout, logOutput), nil
+}
+
+// NewTLSTransportWithLogger returns a NetworkTransport that is built on top of
+// a TLS streaming transport layer, with log output going to the supplied Logger
+func NewTLSTransportWithLogger(
+	bindAddr string,
+	advertise net.Addr,
+	tlsConfig *tls.Config,
+	maxPool int,
+	timeout time.Duration,
+	logger *log.Logger,
+) (*NetworkTransport, error) {
+	stream, err := NewTLSStreamLayer(bindAddr, advertise, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewNetworkTransportWithLogger(stream, maxPool, timeout, logger), nil
+}
+
+// NewTLSStreamLayer binds to given address and returns a TLS stream layer
+func NewTLSStreamLayer(bindAddr string, advertise net.Addr, tlsConfig *tls.Config) (*TLSStreamLayer, error) {
+	if tlsConfig == nil {
+		return nil, errNoTLSConfig
+	}
+	stream, err := newTCPStreamLayer(bindAddr, advertise)
+	if err != nil {
+		return nil, err
+	}
+	return &TLSStreamLayer{TCPStreamLayer: stream, config: tlsConfig}, nil
+}
+
+// Dial implements the StreamLayer interface.
+func (t *TLSStreamLayer) Dial(address string, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	return tls.DialWithDialer(dialer, "tcp", address, t.config)
+}
+
+// Accept implements the net.Listener interface. The TLS handshake takes place upon first read or write,
+// so that a slow or misbehaving peer does not block accepting other connections.
+func (t *TLSStreamLayer) Accept() (c net.Conn, err error) {
+	conn, err := t.TCPStreamLayer.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return tls.Server(conn, t.config), nil
+}
@@ -0,0 +1,154 @@
+package raft
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// makeTLSConfig returns a mutual TLS config based on a fresh self-signed certificate, trusting only itself
+func makeTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "raft"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	config := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ServerName:   "127.0.0.1",
+	}
+	return config
+}
+
+func TestTLSTransport_NoConfig(t *testing.T) {
+	_, err := NewTLSTransportWithLogger("127.0.0.1:0", nil, nil, 1, 0, newTestLogger(t))
+	if err != errNoTLSConfig {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestTLSTransport_AppendEntries(t *testing.T) {
+	config := makeTLSConfig(t)
+
+	// Transport 1 is consumer
+	trans1, err := NewTLSTransportWithLogger("127.0.0.1:0", nil, config, 2, time.Second, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans1.Close()
+	rpcCh := trans1.Consumer()
+
+	args := AppendEntriesRequest{
+		Term:              10,
+		Leader:            []byte("cartman"),
+		PrevLogEntry:      100,
+		PrevLogTerm:       4,
+		Entries:           []*Log{{Index: 101, Term: 4, Type: LogNoop}},
+		LeaderCommitIndex: 90,
+	}
+	resp := AppendEntriesResponse{
+		Term:    4,
+		LastLog: 90,
+		Success: true,
+	}
+
+	// Listen for a request
+	errCh := make(chan error, 1)
+	go func() {
+		select {
+		case rpc := <-rpcCh:
+			req := rpc.Command.(*AppendEntriesRequest)
+			if !reflect.DeepEqual(req, &args) {
+				rpc.Respond(nil, errNotTCP)
+				errCh <- errNotTCP
+				return
+			}
+			rpc.Respond(&resp, nil)
+			errCh <- nil
+		case <-time.After(time.Second):
+			errCh <- errNoTLSConfig
+		}
+	}()
+
+	// Transport 2 makes outbound request
+	trans2, err := NewTLSTransportWithLogger("127.0.0.1:0", nil, config, 2, time.Second, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans2.Close()
+
+	var out AppendEntriesResponse
+	if err := trans2.AppendEntries(trans1.LocalAddr(), &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("command mismatch or timeout: %v", err)
+	}
+	if !reflect.DeepEqual(resp, out) {
+		t.Fatalf("command mismatch: %#v %#v", resp, out)
+	}
+}
+
+func TestTLSTransport_RejectsUntrusted(t *testing.T) {
+	config := makeTLSConfig(t)
+	trans1, err := NewTLSTransportWithLogger("127.0.0.1:0", nil, config, 2, time.Second, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans1.Close()
+
+	// A peer with an untrusted certificate, trusting trans1 nonetheless
+	untrusted := makeTLSConfig(t)
+	untrusted.RootCAs = config.RootCAs
+	trans2, err := NewTLSTransportWithLogger("127.0.0.1:0", nil, untrusted, 2, time.Second, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans2.Close()
+
+	var out AppendEntriesResponse
+	if err := trans2.AppendEntries(trans1.LocalAddr(), &AppendEntriesRequest{Term: 1}, &out); err == nil {
+		t.Fatalf("expected untrusted peer to be rejected")
+	}
+
+	// A plaintext peer
+	trans3, err := NewTCPTransportWithLogger("127.0.0.1:0", nil, 2, time.Second, newTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans3.Close()
+	if err := trans3.AppendEntries(trans1.LocalAddr(), &AppendEntriesRequest{Term: 1}, &out); err == nil {
+		t.Fatalf("expected plaintext peer to be rejected")
+	}
+}
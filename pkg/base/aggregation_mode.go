@@ -0,0 +1,74 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type AggregationType string
+
+const (
+	AggregationMax        AggregationType = "max"
+	AggregationMin        AggregationType = "min"
+	AggregationAvg        AggregationType = "avg"
+	AggregationMedian     AggregationType = "median"
+	AggregationPercentile AggregationType = "percentile"
+	AggregationQuorum     AggregationType = "quorum"
+)
+
+// AggregationMode indicates how a cluster's per-host values are reduced into a single value.
+// The zero value is AggregationMax, which reports the worst (highest) value.
+type AggregationMode struct {
+	Type  AggregationType
+	Value float64 // percentile or quorum percentage, (0, 100]
+}
+
+// ParseAggregationMode parses a mode such as "max", "median", "percentile:90" or "quorum:75%".
+// An empty string is parsed as "max".
+func ParseAggregationMode(mode string) (aggregationMode AggregationMode, err error) {
+	mode = strings.TrimSpace(mode)
+	tokens := strings.SplitN(mode, ":", 2)
+	aggregationMode.Type = AggregationType(strings.ToLower(tokens[0]))
+	switch aggregationMode.Type {
+	case "":
+		aggregationMode.Type = AggregationMax
+		return aggregationMode, nil
+	case AggregationMax, AggregationMin, AggregationAvg, AggregationMedian:
+		if len(tokens) > 1 {
+			return aggregationMode, fmt.Errorf("Aggregation mode %s takes no argument: %s", aggregationMode.Type, mode)
+		}
+		return aggregationMode, nil
+	case AggregationPercentile, AggregationQuorum:
+		if len(tokens) < 2 {
+			return aggregationMode, fmt.Errorf("Aggregation mode %s requires an argument, e.g. %s:90", aggregationMode.Type, aggregationMode.Type)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(tokens[1]), "%"), 64)
+		if err != nil {
+			return aggregationMode, fmt.Errorf("Invalid aggregation mode argument: %s", mode)
+		}
+		if value <= 0 || value > 100 {
+			return aggregationMode, fmt.Errorf("Aggregation mode argument must be in (0, 100]: %s", mode)
+		}
+		aggregationMode.Value = value
+		return aggregationMode, nil
+	}
+	return aggregationMode, fmt.Errorf("Unknown aggregation mode: %s", mode)
+}
+
+func (mode AggregationMode) String() string {
+	switch mode.Type {
+	case "":
+		return string(AggregationMax)
+	case AggregationPercentile:
+		return fmt.Sprintf("%s:%s", mode.Type, strconv.FormatFloat(mode.Value, 'f', -1, 64))
+	case AggregationQuorum:
+		return fmt.Sprintf("%s:%s%%", mode.Type, strconv.FormatFloat(mode.Value, 'f', -1, 64))
+	}
+	return string(mode.Type)
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestParseAggregationMode(t *testing.T) {
+	{
+		mode, err := ParseAggregationMode("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(mode.Type, AggregationMax)
+		test.S(t).ExpectEquals(mode.String(), "max")
+	}
+	{
+		mode, err := ParseAggregationMode("Median")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(mode.Type, AggregationMedian)
+	}
+	{
+		mode, err := ParseAggregationMode("percentile:90")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(mode.Type, AggregationPercentile)
+		test.S(t).ExpectEquals(mode.Value, 90.0)
+		test.S(t).ExpectEquals(mode.String(), "percentile:90")
+	}
+	{
+		mode, err := ParseAggregationMode("quorum:75%")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(mode.Type, AggregationQuorum)
+		test.S(t).ExpectEquals(mode.Value, 75.0)
+		test.S(t).ExpectEquals(mode.String(), "quorum:75%")
+	}
+	{
+		mode, err := ParseAggregationMode("quorum:66.6")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(mode.Value, 66.6)
+	}
+	test.S(t).ExpectEquals(AggregationMode{}.String(), "max")
+
+	for _, invalid := range []string{"worst", "max:1", "percentile", "percentile:0", "percentile:101", "quorum:abc%"} {
+		_, err := ParseAggregationMode(invalid)
+		test.S(t).ExpectNotNil(err)
+	}
+}
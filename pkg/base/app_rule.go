@@ -0,0 +1,195 @@
+package base
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// An app throttle whose app name carries one of these prefixes is a rule, applying to all apps it matches:
+// - prefix:archiver/ matches apps whose names begin with "archiver/"
+// - glob:gh-ost:* matches apps by shell pattern, as per path.Match; `*` does not match `/`
+// - regex:^gh-ost:(main|ci)[0-9]+$ matches apps by regular expression
+const (
+	AppRulePrefix = "prefix"
+	AppRuleGlob   = "glob"
+	AppRuleRegex  = "regex"
+)
+
+// appRuleKinds lists the rule kinds; on equal specificity, earlier kinds take precedence
+var appRuleKinds = []string{AppRulePrefix, AppRuleGlob, AppRuleRegex}
+
+// AppRule is a parsed app throttle rule
+type AppRule struct {
+	Name        string // the throttled app name, e.g. "glob:gh-ost:*"
+	Kind        string
+	Pattern     string
+	Specificity int // the number of literal characters in the pattern
+	regexp      *regexp.Regexp
+}
+
+// IsAppRule checks whether an app name is a rule, rather than a plain app name
+func IsAppRule(appName string) bool {
+	return appRuleKind(appName) != ""
+}
+
+func appRuleKind(appName string) string {
+	for _, kind := range appRuleKinds {
+		if strings.HasPrefix(appName, kind+":") {
+			return kind
+		}
+	}
+	return ""
+}
+
+// ParseAppRule parses an app name into a rule. It returns nil for a plain app name.
+func ParseAppRule(appName string) (*AppRule, error) {
+	kind := appRuleKind(appName)
+	if kind == "" {
+		return nil, nil
+	}
+	rule := &AppRule{Name: appName, Kind: kind, Pattern: strings.TrimPrefix(appName, kind+":")}
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("empty %s rule", kind)
+	}
+	switch kind {
+	case AppRulePrefix:
+		rule.Specificity = len(rule.Pattern)
+	case AppRuleGlob:
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %s: %+v", rule.Pattern, err)
+		}
+		rule.Specificity = globLiteralLength(rule.Pattern)
+	case AppRuleRegex:
+		parsed, err := syntax.Parse(rule.Pattern, syntax.Perl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %+v", rule.Pattern, err)
+		}
+		// The whole app name must match
+		if rule.regexp, err = regexp.Compile(`^(?:` + rule.Pattern + `)$`); err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %+v", rule.Pattern, err)
+		}
+		rule.Specificity = regexLiteralLength(parsed.Simplify())
+	}
+	return rule, nil
+}
+
+// globLiteralLength counts the characters of a glob which match literally
+func globLiteralLength(pattern string) (length int) {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+		case '[':
+			// a character class counts as no literal; path.Match has validated it is terminated
+			for i++; i < len(pattern) && pattern[i] != ']'; i++ {
+				if pattern[i] == '\\' {
+					i++
+				}
+			}
+		case '\\':
+			i++
+			length++
+		default:
+			length++
+		}
+	}
+	return length
+}
+
+// regexLiteralLength counts the literal characters a regex requires
+func regexLiteralLength(re *syntax.Regexp) (length int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpConcat, syntax.OpCapture:
+		for _, sub := range re.Sub {
+			length += regexLiteralLength(sub)
+		}
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return re.Min * regexLiteralLength(re.Sub[0])
+		}
+	case syntax.OpPlus:
+		return regexLiteralLength(re.Sub[0])
+	}
+	return length
+}
+
+// Matches checks whether the rule applies to given app
+func (rule *AppRule) Matches(appName string) bool {
+	switch rule.Kind {
+	case AppRulePrefix:
+		return strings.HasPrefix(appName, rule.Pattern)
+	case AppRuleGlob:
+		matched, _ := path.Match(rule.Pattern, appName)
+		return matched
+	case AppRuleRegex:
+		return rule.regexp.MatchString(appName)
+	}
+	return false
+}
+
+// AppRules is a set of rules, sorted by precedence: the most specific rule first
+type AppRules []*AppRule
+
+// NewAppRules parses the rules among given app names, skipping plain app names, invalid rules and duplicates
+func NewAppRules(appNames []string) AppRules {
+	rules := AppRules{}
+	parsed := map[string]bool{}
+	for _, appName := range appNames {
+		if parsed[appName] {
+			continue
+		}
+		parsed[appName] = true
+		if rule, err := ParseAppRule(appName); err == nil && rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	kindOrder := map[string]int{}
+	for i, kind := range appRuleKinds {
+		kindOrder[kind] = i
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Specificity != rules[j].Specificity {
+			return rules[i].Specificity > rules[j].Specificity
+		}
+		if rules[i].Kind != rules[j].Kind {
+			return kindOrder[rules[i].Kind] < kindOrder[rules[j].Kind]
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+// ResolveAppThrottle returns the throttle in effect for given app on given store: its own throttle, or else that
+// of the most specific matching rule. For either, a throttle scoped to the store beats one scoped to the store
+// type, which beats an unscoped throttle. activeThrottle returns the unexpired throttle of a key (see
+// AppThrottleKey), or nil. ruleName is empty when the app is throttled by name.
+func ResolveAppThrottle(appName string, store AppThrottleStore, rules AppRules, activeThrottle func(key string) *AppThrottle) (appThrottle *AppThrottle, ruleName string) {
+	scopes := store.broader()
+	scopedThrottle := func(name string) *AppThrottle {
+		for _, scope := range scopes {
+			if appThrottle := activeThrottle(AppThrottleKey(name, scope)); appThrottle != nil {
+				return appThrottle
+			}
+		}
+		return nil
+	}
+	if HasAppThrottleKeys(appName) {
+		if appThrottle = scopedThrottle(appName); appThrottle != nil {
+			return appThrottle, ""
+		}
+	}
+	for _, rule := range rules {
+		if !rule.Matches(appName) {
+			continue
+		}
+		if appThrottle = scopedThrottle(rule.Name); appThrottle != nil {
+			return appThrottle, rule.Name
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,134 @@
+package base
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestParseAppRule(t *testing.T) {
+	{
+		rule, err := ParseAppRule("gh-ost:main1")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(rule == nil)
+	}
+	{
+		rule, err := ParseAppRule("prefix:archiver/")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(rule.Kind, AppRulePrefix)
+		test.S(t).ExpectEquals(rule.Specificity, 9)
+		test.S(t).ExpectTrue(rule.Matches("archiver/daily"))
+		test.S(t).ExpectFalse(rule.Matches("archiver"))
+	}
+	{
+		rule, err := ParseAppRule("glob:gh-ost:*")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(rule.Specificity, 7)
+		test.S(t).ExpectTrue(rule.Matches("gh-ost:main1"))
+		test.S(t).ExpectFalse(rule.Matches("gh-ost:main1/x"))
+	}
+	{
+		rule, err := ParseAppRule("regex:gh-ost:(main|ci)[0-9]+")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(rule.Specificity, 7)
+		test.S(t).ExpectTrue(rule.Matches("gh-ost:main12"))
+		// the whole name must match
+		test.S(t).ExpectFalse(rule.Matches("x-gh-ost:main12"))
+	}
+	for _, appName := range []string{"prefix:", "glob:gh-ost:[", "regex:gh-ost:("} {
+		_, err := ParseAppRule(appName)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestResolveAppThrottle(t *testing.T) {
+	now := time.Now()
+	throttledApps := map[string]*AppThrottle{
+		"gh-ost:main1":         NewAppThrottle(now.Add(time.Hour), 0),
+		"glob:gh-ost:*":        NewAppThrottle(now.Add(time.Hour), 1),
+		"prefix:gh-ost:main":   NewAppThrottle(now.Add(time.Hour), 0.5),
+		"prefix:gh-ost:ci":     NewAppThrottle(now.Add(-time.Hour), 0.3),
+		"regex:gh-ost:ci[0-9]": NewAppThrottle(now.Add(time.Hour), 0.2),
+	}
+	appNames := []string{}
+	for appName := range throttledApps {
+		appNames = append(appNames, appName)
+	}
+	rules := NewAppRules(appNames)
+	activeThrottle := func(name string) *AppThrottle {
+		if appThrottle, ok := throttledApps[name]; ok && appThrottle.ExpireAt.After(now) {
+			return appThrottle
+		}
+		return nil
+	}
+
+	expectations := []struct {
+		appName  string
+		ruleName string
+		ratio    float64
+	}{
+		{"gh-ost:main1", "", 0}, // exact beats patterns
+		{"gh-ost:main2", "prefix:gh-ost:main", 0.5},
+		{"gh-ost:ci1", "regex:gh-ost:ci[0-9]", 0.2}, // the more specific prefix rule has expired
+		{"gh-ost:other", "glob:gh-ost:*", 1},
+	}
+	for _, expectation := range expectations {
+		appThrottle, ruleName := ResolveAppThrottle(expectation.appName, AppThrottleStore{}, rules, activeThrottle)
+		test.S(t).ExpectEquals(ruleName, expectation.ruleName)
+		test.S(t).ExpectEquals(appThrottle.Ratio, expectation.ratio)
+	}
+	appThrottle, _ := ResolveAppThrottle("archiver", AppThrottleStore{}, rules, activeThrottle)
+	test.S(t).ExpectTrue(appThrottle == nil)
+
+	// store scoped throttles apply to checks on their stores only, and the narrowest scope wins
+	main7 := AppThrottleStore{StoreType: "mysql", StoreName: "main7"}
+	throttledApps[AppThrottleKey("archiver", main7)] = &AppThrottle{ExpireAt: now.Add(time.Hour), Ratio: 0.7, AppThrottleStore: main7}
+	throttledApps[AppThrottleKey("gh-ost:main1", AppThrottleStore{StoreType: "mysql"})] = NewAppThrottle(now.Add(time.Hour), 0.8)
+	throttledApps[AppThrottleKey("gh-ost:main1", main7)] = NewAppThrottle(now.Add(time.Hour), 0.9)
+
+	appThrottle, _ = ResolveAppThrottle("archiver", AppThrottleStore{StoreType: "mysql", StoreName: "main1"}, rules, activeThrottle)
+	test.S(t).ExpectTrue(appThrottle == nil)
+	appThrottle, _ = ResolveAppThrottle("archiver", main7, rules, activeThrottle)
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.7)
+	appThrottle, _ = ResolveAppThrottle("gh-ost:main1", main7, rules, activeThrottle)
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.9)
+	appThrottle, _ = ResolveAppThrottle("gh-ost:main1", AppThrottleStore{StoreType: "mysql", StoreName: "main1"}, rules, activeThrottle)
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.8)
+}
+
+func TestAppThrottleKey(t *testing.T) {
+	stores := []AppThrottleStore{{}, {StoreType: "mysql"}, {StoreType: "mysql", StoreName: "main7"}}
+	keys := []string{"app", "app@mysql", "app@mysql/main7"}
+	for i, store := range stores {
+		test.S(t).ExpectEquals(AppThrottleKey("app", store), keys[i])
+		test.S(t).ExpectEquals(AppThrottleKeyApp(keys[i], store), "app")
+	}
+	test.S(t).ExpectNotNil(AppThrottleStore{StoreName: "main7"}.Validate())
+}
+
+func TestValidateAppName(t *testing.T) {
+	for _, appName := range []string{"archiver", "gh-ost:main1", "prefix:archiver/", "glob:gh-ost:*", "regex:^gh-ost:(main|ci)[0-9]+$"} {
+		test.S(t).ExpectNil(ValidateAppName(appName))
+	}
+	// "@" would be ambiguous with a store scoped throttle's key
+	for _, appName := range []string{"app@mysql", "app@x", "prefix:app@", "regex:a@b", "glob:[", "regex:("} {
+		test.S(t).ExpectNotNil(ValidateAppName(appName))
+	}
+}
+
+func TestResolveAppThrottleAtSign(t *testing.T) {
+	throttles := map[string]*AppThrottle{
+		"app@mysql":  {Ratio: 1},
+		"prefix:app": {Ratio: 0.5},
+	}
+	activeThrottle := func(key string) *AppThrottle { return throttles[key] }
+	rules := NewAppRules([]string{"prefix:app"})
+
+	// "app@mysql" is checked as a plain app, rather than read as the key of app's throttle on mysql
+	appThrottle, ruleName := ResolveAppThrottle("app@mysql", AppThrottleStore{StoreType: "mysql", StoreName: "main7"}, rules, activeThrottle)
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.5)
+	test.S(t).ExpectEquals(ruleName, "prefix:app")
+	appThrottle, _ = ResolveAppThrottle("app@mysql", AppThrottleStore{}, nil, activeThrottle)
+	test.S(t).ExpectTrue(appThrottle == nil)
+}
@@ -0,0 +1,70 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AppThreshold overrides the throttle threshold of a single store for an app, or for the apps an app rule
+// matches. Thresholds are either configured, or set at runtime; a runtime threshold beats a configured one.
+type AppThreshold struct {
+	App       string
+	Threshold float64
+	AppThrottleStore
+	Configured bool `json:",omitempty"` // read from the AppThresholds configuration, rather than set at runtime
+}
+
+// Validate checks the threshold applies to a single store and is positive, and that its app is a valid app name or rule
+func (appThreshold *AppThreshold) Validate() error {
+	if appThreshold.App == "" {
+		return fmt.Errorf("app must be given")
+	}
+	if err := ValidateAppName(appThreshold.App); err != nil {
+		return err
+	}
+	if appThreshold.StoreType == "" || appThreshold.StoreName == "" {
+		return fmt.Errorf("app threshold of %s requires both store type and store name", appThreshold.App)
+	}
+	if appThreshold.Threshold <= 0 {
+		return fmt.Errorf("app threshold of %s must be positive; got %+v", appThreshold.App, appThreshold.Threshold)
+	}
+	return nil
+}
+
+// Key is the name under which the threshold is kept, as with app throttles; see AppThrottleKey
+func (appThreshold *AppThreshold) Key() string {
+	return AppThrottleKey(appThreshold.App, appThreshold.AppThrottleStore)
+}
+
+// SortAppThresholds sorts thresholds by store, then app
+func SortAppThresholds(appThresholds []AppThreshold) {
+	sort.Slice(appThresholds, func(i, j int) bool {
+		if appThresholds[i].AppThrottleStore != appThresholds[j].AppThrottleStore {
+			return appThresholds[i].AppThrottleStore.String() < appThresholds[j].AppThrottleStore.String()
+		}
+		if appThresholds[i].App != appThresholds[j].App {
+			return appThresholds[i].App < appThresholds[j].App
+		}
+		return !appThresholds[i].Configured
+	})
+}
+
+// ResolveAppThreshold returns the threshold override in effect for given app on given store: its own, or else that
+// of the most specific matching rule, in which case the result's App names the rule. storeThreshold returns the
+// threshold of a key (see AppThrottleKey), or nil.
+func ResolveAppThreshold(appName string, store AppThrottleStore, rules AppRules, storeThreshold func(key string) *AppThreshold) *AppThreshold {
+	if HasAppThrottleKeys(appName) {
+		if appThreshold := storeThreshold(AppThrottleKey(appName, store)); appThreshold != nil {
+			return appThreshold
+		}
+	}
+	for _, rule := range rules {
+		if !rule.Matches(appName) {
+			continue
+		}
+		if appThreshold := storeThreshold(AppThrottleKey(rule.Name, store)); appThreshold != nil {
+			return appThreshold
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package base
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestAppThresholdValidate(t *testing.T) {
+	main7 := AppThrottleStore{StoreType: "mysql", StoreName: "main7"}
+	test.S(t).ExpectNil((&AppThreshold{App: "glob:archiver*", Threshold: 0.5, AppThrottleStore: main7}).Validate())
+	invalid := []AppThreshold{
+		{Threshold: 0.5, AppThrottleStore: main7},
+		{App: "glob:archiver[", Threshold: 0.5, AppThrottleStore: main7},
+		{App: "archiver", Threshold: 0.5, AppThrottleStore: AppThrottleStore{StoreType: "mysql"}},
+		{App: "archiver", Threshold: -1, AppThrottleStore: main7},
+	}
+	for _, appThreshold := range invalid {
+		test.S(t).ExpectNotNil(appThreshold.Validate())
+	}
+}
+
+func TestSortAppThresholds(t *testing.T) {
+	main1 := AppThrottleStore{StoreType: "mysql", StoreName: "main1"}
+	main7 := AppThrottleStore{StoreType: "mysql", StoreName: "main7"}
+	appThresholds := []AppThreshold{
+		{App: "gh-ost", AppThrottleStore: main7},
+		{App: "archiver", AppThrottleStore: main7, Configured: true},
+		{App: "archiver", AppThrottleStore: main7},
+		{App: "gh-ost", AppThrottleStore: main1},
+	}
+	SortAppThresholds(appThresholds)
+	test.S(t).ExpectEquals(appThresholds[0].AppThrottleStore, main1)
+	test.S(t).ExpectEquals(appThresholds[1].App, "archiver")
+	test.S(t).ExpectFalse(appThresholds[1].Configured)
+	test.S(t).ExpectTrue(appThresholds[2].Configured)
+	test.S(t).ExpectEquals(appThresholds[3].App, "gh-ost")
+}
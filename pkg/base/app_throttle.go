@@ -1,14 +1,97 @@
 package base
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
+// AppThrottleStore scopes an app throttle to the stores of a type, or to a single store. An empty scope applies
+// to all stores; StoreName requires StoreType.
+type AppThrottleStore struct {
+	StoreType string `json:",omitempty"`
+	StoreName string `json:",omitempty"`
+}
+
+// Validate checks the scope is either empty, a store type, or a store type and name
+func (store AppThrottleStore) Validate() error {
+	if store.StoreName != "" && store.StoreType == "" {
+		return fmt.Errorf("store name %s given without store type", store.StoreName)
+	}
+	return nil
+}
+
+// String returns "<storeType>" or "<storeType>/<storeName>", or empty for an unscoped throttle
+func (store AppThrottleStore) String() string {
+	if store.StoreName != "" {
+		return fmt.Sprintf("%s/%s", store.StoreType, store.StoreName)
+	}
+	return store.StoreType
+}
+
+// broader returns the scopes which apply to checks on this store, narrowest first
+func (store AppThrottleStore) broader() []AppThrottleStore {
+	scopes := []AppThrottleStore{}
+	if store.StoreName != "" {
+		scopes = append(scopes, store)
+	}
+	if store.StoreType != "" {
+		scopes = append(scopes, AppThrottleStore{StoreType: store.StoreType})
+	}
+	return append(scopes, AppThrottleStore{})
+}
+
+// ValidateAppName checks an app name, or app rule, may be throttled, or have a threshold or schedule. App names
+// may not contain "@", which separates an app from its store in throttle keys; see AppThrottleKey. Such names
+// may still be checked, see HasAppThrottleKeys.
+func ValidateAppName(appName string) error {
+	if strings.Contains(appName, "@") {
+		return fmt.Errorf("invalid app %s: app names may not contain \"@\"", appName)
+	}
+	_, err := ParseAppRule(appName)
+	return err
+}
+
+// HasAppThrottleKeys tells whether a checked app may have throttles or thresholds of its own. A name containing
+// "@" never does, and its keys would read as those of another app's store scoped throttle; it is checked as a plain
+// app, subject to matching rules only.
+func HasAppThrottleKeys(appName string) bool {
+	return !strings.Contains(appName, "@")
+}
+
+// AppThrottleKey is the name under which an app's throttle is kept: the app name for an unscoped throttle,
+// or else "<app>@<storeType>" or "<app>@<storeType>/<storeName>". It is unambiguous as app names may not
+// contain "@"; see ValidateAppName.
+func AppThrottleKey(appName string, store AppThrottleStore) string {
+	if store.StoreType == "" {
+		return appName
+	}
+	return fmt.Sprintf("%s@%s", appName, store)
+}
+
+// AppThrottleKeyApp returns the app name of a throttle's key
+func AppThrottleKeyApp(key string, store AppThrottleStore) string {
+	if store.StoreType == "" {
+		return key
+	}
+	return strings.TrimSuffix(key, "@"+store.String())
+}
+
+// AppThrottleMetadata describes who throttled an app, and why
+// - Origin: what applied the throttle, e.g. `schedule:<name>`; empty when requested via API
+type AppThrottleMetadata struct {
+	Reason string `json:",omitempty"`
+	Owner  string `json:",omitempty"`
+	Origin string `json:",omitempty"`
+}
+
 // AppThrottle is the definition for an app throtting instruction
 // - Ratio: [0..1], 0 == no throttle, 1 == fully throttle
 type AppThrottle struct {
 	ExpireAt time.Time
 	Ratio    float64
+	AppThrottleStore
+	AppThrottleMetadata
 }
 
 func NewAppThrottle(expireAt time.Time, ratio float64) *AppThrottle {
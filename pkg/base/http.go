@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -25,3 +26,21 @@ func SetupHttpClient(httpTimeout time.Duration) *http.Client {
 
 	return httpClient
 }
+
+var sharedHttpClients = make(map[time.Duration]*http.Client)
+var sharedHttpClientsMutex sync.Mutex
+
+// SharedHttpClient returns a client per timeout, shared by all callers, such that connections are reused across
+// probes. The timeout bounds the entire request.
+func SharedHttpClient(timeout time.Duration) *http.Client {
+	sharedHttpClientsMutex.Lock()
+	defer sharedHttpClientsMutex.Unlock()
+
+	if httpClient, ok := sharedHttpClients[timeout]; ok {
+		return httpClient
+	}
+	httpClient := SetupHttpClient(timeout)
+	httpClient.Timeout = timeout
+	sharedHttpClients[timeout] = httpClient
+	return httpClient
+}
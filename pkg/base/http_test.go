@@ -0,0 +1,15 @@
+package base
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestSharedHttpClient(t *testing.T) {
+	httpClient := SharedHttpClient(250 * time.Millisecond)
+	test.S(t).ExpectEquals(httpClient.Timeout, 250*time.Millisecond)
+	test.S(t).ExpectTrue(SharedHttpClient(250*time.Millisecond) == httpClient)
+	test.S(t).ExpectTrue(SharedHttpClient(time.Second) != httpClient)
+}
@@ -0,0 +1,27 @@
+package base
+
+import (
+	"time"
+)
+
+// AuditInfo describes who requested a throttle or unthrottle operation, and why
+type AuditInfo struct {
+	Requester    string `json:",omitempty"` // the authenticated identity of the requester, if any
+	RemoteAddr   string `json:",omitempty"` // the address of the connecting peer
+	ForwardedFor string `json:",omitempty"` // the X-Forwarded-For header as sent by the client; unverified
+	Reason       string `json:",omitempty"`
+}
+
+// ThrottleAuditEntry records a single throttle or unthrottle operation
+// - AppThrottleStore: the store scope of the operation; empty when unscoped
+// - TTLMinutes: 0 when the operation did not set a TTL
+// - Ratio: negative when the operation did not set a ratio
+type ThrottleAuditEntry struct {
+	Time      time.Time
+	Operation string
+	App       string
+	AppThrottleStore
+	TTLMinutes int64 `json:",omitempty"`
+	Ratio      float64
+	AuditInfo
+}
@@ -0,0 +1,23 @@
+package base
+
+import (
+	"time"
+)
+
+// ThrottleSchedule throttles apps during recurring windows: a window starts at each Cron activation, and lasts Duration
+//   - Cron: a standard cron expression (minute, hour, day of month, month, day of week), or a descriptor such as `@daily`
+//   - Duration: a duration such as `2h`
+//   - Apps: app names, app rules, or glob patterns such as `gh-ost:*`, which are throttled as `glob:` rules
+//   - AppliedWindows: maps the apps the scheduler throttled onto the start of the window each was throttled for. It is
+//     kept by the scheduler via the consensus service, and reset when the schedule is replaced.
+type ThrottleSchedule struct {
+	Name           string
+	Cron           string
+	Duration       string
+	Apps           []string
+	Ratio          float64
+	Reason         string `json:",omitempty"`
+	Owner          string `json:",omitempty"`
+	CreatedAt      time.Time
+	AppliedWindows map[string]time.Time `json:",omitempty"`
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"math/rand"
+	"time"
+)
+
+const defaultBackoffInitial = 100 * time.Millisecond
+const defaultBackoffMax = 5 * time.Second
+
+// Backoff configures the delays between checks in WaitUntilOK. Delays grow exponentially from Initial up to Max,
+// and are jittered: each actual delay is randomly picked between half the delay and the full delay.
+type Backoff struct {
+	Initial time.Duration // Default: 100ms
+	Max     time.Duration // Default: 5s
+}
+
+func (backoff Backoff) withDefaults() Backoff {
+	if backoff.Initial <= 0 {
+		backoff.Initial = defaultBackoffInitial
+	}
+	if backoff.Max <= 0 {
+		backoff.Max = defaultBackoffMax
+	}
+	if backoff.Max < backoff.Initial {
+		backoff.Max = backoff.Initial
+	}
+	return backoff
+}
+
+// delay returns the jittered delay following given (0-based) attempt
+func (backoff Backoff) delay(attempt int) time.Duration {
+	delay := backoff.Initial
+	for i := 0; i < attempt && delay < backoff.Max; i++ {
+		delay *= 2
+	}
+	if delay > backoff.Max {
+		delay = backoff.Max
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
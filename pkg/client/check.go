@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CheckResult is freno's response to a check
+type CheckResult struct {
+	StatusCode int
+	Value      float64
+	Threshold  float64
+	Message    string
+}
+
+// OK returns true when the app may go ahead and write
+func (checkResult *CheckResult) OK() bool {
+	return checkResult.StatusCode == http.StatusOK
+}
+
+type checkOptions struct {
+	ifExists      bool
+	lowPriority   bool
+	readThreshold float64
+}
+
+// CheckOption modifies a check
+type CheckOption func(*checkOptions)
+
+// IfExists reports OK when freno does not know the metric, as with /check-if-exists
+func IfExists() CheckOption {
+	return func(options *checkOptions) { options.ifExists = true }
+}
+
+// LowPriority makes a low priority check, which is denied while normal priority apps are being throttled
+func LowPriority() CheckOption {
+	return func(options *checkOptions) { options.lowPriority = true }
+}
+
+// ReadThreshold checks against given threshold rather than the configured one, as with /check-read
+func ReadThreshold(threshold float64) CheckOption {
+	return func(options *checkOptions) { options.readThreshold = threshold }
+}
+
+func (options *checkOptions) path(appName string, storeType string, storeName string) string {
+	appName, storeType, storeName = url.PathEscape(appName), url.PathEscape(storeType), url.PathEscape(storeName)
+	var path string
+	switch {
+	case options.readThreshold > 0 && options.ifExists:
+		path = fmt.Sprintf("/check-read-if-exists/%s/%s/%s/%f", appName, storeType, storeName, options.readThreshold)
+	case options.readThreshold > 0:
+		path = fmt.Sprintf("/check-read/%s/%s/%s/%f", appName, storeType, storeName, options.readThreshold)
+	case options.ifExists:
+		path = fmt.Sprintf("/check-if-exists/%s/%s/%s", appName, storeType, storeName)
+	default:
+		path = fmt.Sprintf("/check/%s/%s/%s", appName, storeType, storeName)
+	}
+	if options.lowPriority {
+		path = path + "?p=low"
+	}
+	return path
+}
+
+// Check checks whether the client's app may write to given store. An error is returned when freno could
+// not be reached; a throttled app gets a non-OK CheckResult and no error.
+func (client *Client) Check(ctx context.Context, storeType string, storeName string, opts ...CheckOption) (*CheckResult, error) {
+	options := &checkOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.readThreshold > 0 && !options.lowPriority {
+		if checkResult, ok := client.memcacheCheck(storeType, storeName, options.readThreshold); ok {
+			return checkResult, nil
+		}
+	}
+	path := options.path(client.config.App, storeType, storeName)
+	if client.cache != nil {
+		if cached, found := client.cache.Get(path); found {
+			return cached.(*CheckResult), nil
+		}
+	}
+	resp, err := client.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	checkResult := &CheckResult{}
+	if err := json.Unmarshal(body, checkResult); err != nil {
+		return nil, fmt.Errorf("freno %s: unexpected response: %d %s", path, resp.StatusCode, body)
+	}
+	checkResult.StatusCode = resp.StatusCode
+	// server errors are not cached; the next check should ask again
+	if client.cache != nil && checkResult.StatusCode < http.StatusInternalServerError {
+		client.cache.SetDefault(path, checkResult)
+	}
+	return checkResult, nil
+}
+
+// CheckRead checks whether given store's metric is below given threshold, e.g. whether replication lag
+// is low enough to read from replicas. When memcache is configured, the metric is read from memcache.
+func (client *Client) CheckRead(ctx context.Context, storeType string, storeName string, threshold float64) (*CheckResult, error) {
+	return client.Check(ctx, storeType, storeName, ReadThreshold(threshold))
+}
+
+// CheckIfExists is like Check, but reports OK when freno does not know the metric
+func (client *Client) CheckIfExists(ctx context.Context, storeType string, storeName string) (*CheckResult, error) {
+	return client.Check(ctx, storeType, storeName, IfExists())
+}
+
+// WaitUntilOK checks repeatedly, backing off between checks, until the check is OK or until ctx is done
+func (client *Client) WaitUntilOK(ctx context.Context, storeType string, storeName string, opts ...CheckOption) error {
+	backoff := client.config.Backoff.withDefaults()
+	for attempt := 0; ; attempt++ {
+		checkResult, err := client.Check(ctx, storeType, storeName, opts...)
+		if err == nil && checkResult.OK() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			// a request cut short by the deadline has nothing to add to it
+			if err != nil && !errors.Is(err, ctx.Err()) {
+				return fmt.Errorf("%v; last error: %v", ctx.Err(), err)
+			}
+			return ctx.Err()
+		case <-time.After(backoff.delay(attempt)):
+		}
+	}
+}
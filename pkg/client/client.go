@@ -0,0 +1,280 @@
+// Package client is a Go client for freno's HTTP API.
+//
+// A client may be configured with several freno endpoints. Requests are directed at the leader, which is
+// discovered via /leader-check and rediscovered on failure. Checks may be cached client side, and read checks
+// may be served directly from memcache. See doc/clients.md.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const defaultTimeout = time.Second
+
+// Config configures a Client
+type Config struct {
+	Endpoints  []string      // freno base URLs, e.g. "http://my.freno.com:9777". At least one is required
+	App        string        // the app name on whose behalf checks are made. Required
+	Timeout    time.Duration // per request. Default: 1s
+	CacheTTL   time.Duration // when > 0, check results are cached for this long
+	Backoff    Backoff       // WaitUntilOK backoff
+	Memcache   *MemcacheConfig
+	HTTPClient *http.Client // e.g. for TLS client certificates
+	Token      string       // bearer token, sent with all requests, for freno's admin routes
+}
+
+// Client is a freno client. It is safe for concurrent use.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	cache      *cache.Cache
+	memcache   *memcacheReader
+
+	leaderMutex sync.Mutex
+	leader      string // the endpoint requests are currently directed at; empty until first discovered
+}
+
+// New creates a client
+func New(config Config) (*Client, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("freno client: no endpoints given")
+	}
+	if config.App == "" {
+		return nil, fmt.Errorf("freno client: no app given")
+	}
+	endpoints := make([]string, len(config.Endpoints))
+	for i, endpoint := range config.Endpoints {
+		endpoints[i] = strings.TrimSuffix(endpoint, "/")
+	}
+	config.Endpoints = endpoints
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	client := &Client{
+		config:     config,
+		httpClient: config.HTTPClient,
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{}
+	}
+	if config.CacheTTL > 0 {
+		client.cache = cache.New(config.CacheTTL, 10*config.CacheTTL)
+	}
+	if config.Memcache != nil {
+		client.memcache = newMemcacheReader(config.Memcache)
+	}
+	return client, nil
+}
+
+// currentLeader returns the endpoint requests are currently directed at
+func (client *Client) currentLeader() string {
+	client.leaderMutex.Lock()
+	defer client.leaderMutex.Unlock()
+	return client.leader
+}
+
+func (client *Client) setLeader(endpoint string) {
+	client.leaderMutex.Lock()
+	defer client.leaderMutex.Unlock()
+	client.leader = endpoint
+}
+
+// request issues a single request against a single endpoint. A non-nil body is sent as JSON.
+func (client *Client) request(ctx context.Context, method string, endpoint string, path string, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.config.Timeout)
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, endpoint+path, bodyReader)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.config.Token)
+	}
+	resp, err := client.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
+
+// isLeader checks whether given endpoint claims leadership
+func (client *Client) isLeader(ctx context.Context, endpoint string) bool {
+	resp, err := client.request(ctx, http.MethodHead, endpoint, "/leader-check", nil)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// discoverLeader asks all endpoints which of them is the leader. It returns false if none claims leadership.
+func (client *Client) discoverLeader(ctx context.Context) (leader string, found bool) {
+	for _, endpoint := range client.config.Endpoints {
+		if client.isLeader(ctx, endpoint) {
+			return endpoint, true
+		}
+	}
+	return "", false
+}
+
+// do issues a request against the leader. Upon failure to reach the leader, or upon a server error from a node
+// which no longer claims leadership, it rediscovers the leader and retries once. A server error from the leader
+// itself, e.g. a failing metric, is freno's answer and is returned as is.
+func (client *Client) do(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	leader := client.currentLeader()
+	if leader == "" {
+		// Followers do not collect metrics, hence checks must be directed at the leader from the very start
+		var found bool
+		if leader, found = client.discoverLeader(ctx); !found {
+			leader = client.config.Endpoints[0]
+		}
+		client.setLeader(leader)
+	}
+	resp, err := client.request(ctx, method, leader, path, body)
+	if err == nil && (resp.StatusCode < http.StatusInternalServerError || client.isLeader(ctx, leader)) {
+		return resp, nil
+	}
+	newLeader, found := client.discoverLeader(ctx)
+	if !found {
+		// no one claims leadership. Move on to the next endpoint, for the next request's sake
+		newLeader = client.nextEndpoint(leader)
+		client.setLeader(newLeader)
+		return resp, err
+	}
+	client.setLeader(newLeader)
+	if newLeader == leader {
+		return resp, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return client.request(ctx, method, newLeader, path, body)
+}
+
+func (client *Client) nextEndpoint(endpoint string) string {
+	for i, e := range client.config.Endpoints {
+		if e == endpoint {
+			return client.config.Endpoints[(i+1)%len(client.config.Endpoints)]
+		}
+	}
+	return client.config.Endpoints[0]
+}
+
+// generalResponse is freno's response to admin requests
+type generalResponse struct {
+	StatusCode int
+	Message    string
+}
+
+// admin issues an admin request, expecting an OK response
+func (client *Client) admin(ctx context.Context, method string, path string, body []byte) error {
+	resp, err := client.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	responseBody, _ := ioutil.ReadAll(resp.Body)
+	return responseError(path, resp.StatusCode, responseBody)
+}
+
+// responseError describes a non-OK response, using the message in freno's general response if present
+func responseError(path string, statusCode int, body []byte) error {
+	response := &generalResponse{}
+	if err := json.Unmarshal(body, response); err == nil && response.Message != "" {
+		return fmt.Errorf("freno %s: %d %s", path, statusCode, response.Message)
+	}
+	return fmt.Errorf("freno %s: %d", path, statusCode)
+}
+
+// throttleAppRequest is the body of a POST /api/v1/throttled-apps request
+type throttleAppRequest struct {
+	App   string   `json:"app"`
+	TTL   string   `json:"ttl,omitempty"`
+	Ratio *float64 `json:"ratio,omitempty"`
+}
+
+// ThrottleApp throttles given app, or app rule. A zero ttl, or a negative ratio, applies freno's default.
+// Requests are made via the /api/v1/throttled-apps routes, which take app names as is, such that rules
+// containing slashes, e.g. "prefix:archiver/", are throttled by their own name.
+func (client *Client) ThrottleApp(ctx context.Context, appName string, ttl time.Duration, ratio float64) error {
+	request := &throttleAppRequest{App: appName}
+	if ttl > 0 {
+		request.TTL = ttl.String()
+	}
+	if ratio >= 0 {
+		request.Ratio = &ratio
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return client.admin(ctx, http.MethodPost, "/api/v1/throttled-apps", body)
+}
+
+// UnthrottleApp removes any throttling imposed on given app, or removes given app rule
+func (client *Client) UnthrottleApp(ctx context.Context, appName string) error {
+	return client.admin(ctx, http.MethodDelete, "/api/v1/throttled-apps/"+escapePathSegments(appName), nil)
+}
+
+// escapePathSegments escapes each slash separated segment of an app name, keeping the slashes themselves,
+// which the catch-all app route parameter takes as part of the app name
+func escapePathSegments(appName string) string {
+	segments := strings.Split(appName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// GetJSON issues a GET request for any given API path against the leader, decoding the JSON response into v.
+// This serves API requests not otherwise covered by this client, e.g. "/throttled-apps".
+func (client *Client) GetJSON(ctx context.Context, path string, v interface{}) error {
+	resp, err := client.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
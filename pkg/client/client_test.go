@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+// fakeFreno serves a minimal subset of freno's HTTP API
+type fakeFreno struct {
+	sync.Mutex
+	isLeader   bool
+	statusCode int
+	requests   []string
+}
+
+func (freno *fakeFreno) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	freno.Lock()
+	defer freno.Unlock()
+	freno.requests = append(freno.requests, r.URL.RequestURI())
+	switch {
+	case r.URL.Path == "/leader-check":
+		if freno.isLeader {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case strings.HasPrefix(r.URL.Path, "/check"):
+		if !freno.isLeader {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"StatusCode":404,"Value":0,"Threshold":0,"Message":"No such metric"}`)
+			return
+		}
+		w.WriteHeader(freno.statusCode)
+		json.NewEncoder(w).Encode(&CheckResult{StatusCode: freno.statusCode, Value: 0.5, Threshold: 1})
+	case strings.HasPrefix(r.URL.Path, "/api/v1/throttled-apps"):
+		if !freno.isLeader {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"StatusCode":500,"Message":"node is not the leader"}`)
+			return
+		}
+		fmt.Fprint(w, `{"StatusCode":200,"Message":"OK"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (freno *fakeFreno) setStatusCode(statusCode int) {
+	freno.Lock()
+	defer freno.Unlock()
+	freno.statusCode = statusCode
+}
+
+func (freno *fakeFreno) requestsCount() int {
+	freno.Lock()
+	defer freno.Unlock()
+	return len(freno.requests)
+}
+
+func newFakeFrenos(leaderIndex int, count int) (frenos []*fakeFreno, endpoints []string, cleanup func()) {
+	servers := []*httptest.Server{}
+	for i := 0; i < count; i++ {
+		freno := &fakeFreno{isLeader: i == leaderIndex, statusCode: http.StatusOK}
+		server := httptest.NewServer(freno)
+		frenos = append(frenos, freno)
+		servers = append(servers, server)
+		endpoints = append(endpoints, server.URL)
+	}
+	return frenos, endpoints, func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{App: "test"})
+	test.S(t).ExpectNotNil(err)
+	_, err = New(Config{Endpoints: []string{"http://localhost:9777"}})
+	test.S(t).ExpectNotNil(err)
+	_, err = New(Config{Endpoints: []string{"http://localhost:9777"}, App: "test"})
+	test.S(t).ExpectNil(err)
+}
+
+func TestCheckDirectedAtLeader(t *testing.T) {
+	frenos, endpoints, cleanup := newFakeFrenos(1, 3)
+	defer cleanup()
+	client, err := New(Config{Endpoints: endpoints, App: "test"})
+	test.S(t).ExpectNil(err)
+
+	checkResult, err := client.Check(context.Background(), "mysql", "main1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(checkResult.OK())
+	test.S(t).ExpectEquals(checkResult.Value, 0.5)
+	test.S(t).ExpectEquals(client.currentLeader(), endpoints[1])
+
+	frenos[1].setStatusCode(http.StatusTooManyRequests)
+	checkResult, err = client.Check(context.Background(), "mysql", "main1", LowPriority())
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(checkResult.OK())
+	test.S(t).ExpectEquals(frenos[1].requests[len(frenos[1].requests)-1], "/check/test/mysql/main1?p=low")
+
+	_, err = client.CheckRead(context.Background(), "mysql", "main1", 2.5)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(frenos[1].requests[len(frenos[1].requests)-1], "/check-read/test/mysql/main1/2.500000")
+
+	_, err = client.CheckIfExists(context.Background(), "mysql", "main1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(frenos[1].requests[len(frenos[1].requests)-1], "/check-if-exists/test/mysql/main1")
+}
+
+func TestFailover(t *testing.T) {
+	frenos, endpoints, cleanup := newFakeFrenos(0, 2)
+	defer cleanup()
+	client, err := New(Config{Endpoints: endpoints, App: "test"})
+	test.S(t).ExpectNil(err)
+
+	err = client.ThrottleApp(context.Background(), "other", time.Hour, 0.5)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(frenos[0].requests[len(frenos[0].requests)-1], "/api/v1/throttled-apps")
+
+	// leadership moves
+	frenos[0].Lock()
+	frenos[0].isLeader = false
+	frenos[0].Unlock()
+	frenos[1].Lock()
+	frenos[1].isLeader = true
+	frenos[1].Unlock()
+
+	err = client.UnthrottleApp(context.Background(), "other")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(client.currentLeader(), endpoints[1])
+	test.S(t).ExpectEquals(frenos[1].requests[len(frenos[1].requests)-1], "/api/v1/throttled-apps/other")
+}
+
+func TestCheckCache(t *testing.T) {
+	frenos, endpoints, cleanup := newFakeFrenos(0, 1)
+	defer cleanup()
+	client, err := New(Config{Endpoints: endpoints, App: "test", CacheTTL: time.Hour})
+	test.S(t).ExpectNil(err)
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Check(context.Background(), "mysql", "main1")
+		test.S(t).ExpectNil(err)
+	}
+	// one leader discovery, one check
+	test.S(t).ExpectEquals(frenos[0].requestsCount(), 2)
+}
+
+func TestServerErrorFromLeader(t *testing.T) {
+	frenos, endpoints, cleanup := newFakeFrenos(0, 2)
+	defer cleanup()
+	client, err := New(Config{Endpoints: endpoints, App: "test", CacheTTL: time.Hour})
+	test.S(t).ExpectNil(err)
+
+	frenos[0].setStatusCode(http.StatusInternalServerError)
+	for i := 0; i < 3; i++ {
+		checkResult, err := client.Check(context.Background(), "mysql", "main1")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(checkResult.StatusCode, http.StatusInternalServerError)
+	}
+	// the leader still leads: no rediscovery, hence the follower is never asked.
+	// Error results are not cached: every check reaches the leader, followed by a /leader-check
+	test.S(t).ExpectEquals(client.currentLeader(), endpoints[0])
+	test.S(t).ExpectEquals(frenos[1].requestsCount(), 0)
+	test.S(t).ExpectEquals(frenos[0].requestsCount(), 1+3*2)
+
+	frenos[0].setStatusCode(http.StatusOK)
+	checkResult, err := client.Check(context.Background(), "mysql", "main1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(checkResult.OK())
+}
+
+func TestWaitUntilOK(t *testing.T) {
+	frenos, endpoints, cleanup := newFakeFrenos(0, 1)
+	defer cleanup()
+	client, err := New(Config{Endpoints: endpoints, App: "test", Backoff: Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}})
+	test.S(t).ExpectNil(err)
+
+	frenos[0].setStatusCode(http.StatusTooManyRequests)
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := client.WaitUntilOK(ctx, "mysql", "main1")
+		test.S(t).ExpectEquals(err, context.DeadlineExceeded)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		frenos[0].setStatusCode(http.StatusOK)
+	}()
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := client.WaitUntilOK(ctx, "mysql", "main1")
+		test.S(t).ExpectNil(err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}.withDefaults()
+	for attempt, maxDelay := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := backoff.delay(attempt)
+		test.S(t).ExpectTrue(delay >= maxDelay/2)
+		test.S(t).ExpectTrue(delay <= maxDelay)
+	}
+}
+
+func TestParseMemcacheValue(t *testing.T) {
+	value, timestamp, err := parseMemcacheValue("1497418678836:0.540000")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(value, 0.54)
+	test.S(t).ExpectEquals(timestamp.UnixNano()/int64(time.Millisecond), int64(1497418678836))
+
+	_, _, err = parseMemcacheValue("0.54")
+	test.S(t).ExpectNotNil(err)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const defaultMemcachePath = "freno"
+const defaultMemcacheMaxAge = time.Second
+
+// MemcacheConfig configures reading aggregated metrics directly from memcache, where freno publishes them.
+// See doc/memcache.md. Servers and Path should match freno's MemcacheServers and MemcachePath, as listed by /config/memcache.
+type MemcacheConfig struct {
+	Servers []string
+	Path    string        // Default: "freno"
+	MaxAge  time.Duration // entries older than this are disregarded. Default: 1s
+}
+
+type memcacheReader struct {
+	client *memcache.Client
+	path   string
+	maxAge time.Duration
+}
+
+func newMemcacheReader(config *MemcacheConfig) *memcacheReader {
+	reader := &memcacheReader{
+		client: memcache.New(config.Servers...),
+		path:   config.Path,
+		maxAge: config.MaxAge,
+	}
+	if reader.path == "" {
+		reader.path = defaultMemcachePath
+	}
+	if reader.maxAge <= 0 {
+		reader.maxAge = defaultMemcacheMaxAge
+	}
+	return reader
+}
+
+// parseMemcacheValue parses a `<epochmillis>:<aggregated-value>` memcache entry
+func parseMemcacheValue(entry string) (value float64, timestamp time.Time, err error) {
+	tokens := strings.SplitN(entry, ":", 2)
+	if len(tokens) != 2 {
+		return value, timestamp, fmt.Errorf("unexpected memcache entry: %s", entry)
+	}
+	epochMillis, err := strconv.ParseInt(tokens[0], 10, 64)
+	if err != nil {
+		return value, timestamp, err
+	}
+	if value, err = strconv.ParseFloat(tokens[1], 64); err != nil {
+		return value, timestamp, err
+	}
+	return value, time.Unix(0, epochMillis*int64(time.Millisecond)), nil
+}
+
+// read returns the aggregated value of given metric, provided it is fresh
+func (reader *memcacheReader) read(storeType string, storeName string) (value float64, err error) {
+	key := fmt.Sprintf("%s/%s/%s", reader.path, storeType, storeName)
+	item, err := reader.client.Get(key)
+	if err != nil {
+		return value, err
+	}
+	value, timestamp, err := parseMemcacheValue(string(item.Value))
+	if err != nil {
+		return value, err
+	}
+	if age := time.Since(timestamp); age > reader.maxAge {
+		return value, fmt.Errorf("stale memcache entry %s: %v old", key, age)
+	}
+	return value, nil
+}
+
+// memcacheCheck makes a read check based on memcache, if configured. It returns false when memcache is not
+// configured or has no fresh value, in which case the caller should fall back to asking freno.
+// Note that memcache only holds metrics: explicit app throttling does not apply to memcache checks.
+func (client *Client) memcacheCheck(storeType string, storeName string, threshold float64) (checkResult *CheckResult, ok bool) {
+	if client.memcache == nil {
+		return nil, false
+	}
+	value, err := client.memcache.read(storeType, storeName)
+	if err != nil {
+		return nil, false
+	}
+	checkResult = &CheckResult{StatusCode: http.StatusOK, Value: value, Threshold: threshold}
+	if value > threshold {
+		checkResult.StatusCode = http.StatusTooManyRequests
+		checkResult.Message = "Threshold exceeded"
+	}
+	return checkResult, true
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/group"
+	frenohttp "github.com/github/freno/pkg/http"
+	"github.com/github/freno/pkg/throttle"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+// leaderConsensusService applies throttle requests directly onto the throttler, as a single leader node would.
+// Other consensus operations are not expected.
+type leaderConsensusService struct {
+	group.ConsensusService
+	throttler *throttle.Throttler
+}
+
+func (service *leaderConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	return "localhost", nil
+}
+func (service *leaderConsensusService) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (string, error) {
+	service.throttler.UnthrottleAppOnStore(appName, store)
+	return "localhost", nil
+}
+func (service *leaderConsensusService) ThrottledAppsMap() map[string](*base.AppThrottle) {
+	return service.throttler.ThrottledAppsMap()
+}
+func (service *leaderConsensusService) IsLeader() bool { return true }
+
+// newFrenoServer serves freno's actual HTTP routes, on behalf of a single leader
+func newFrenoServer() (throttler *throttle.Throttler, endpoint string, cleanup func()) {
+	throttler = throttle.NewThrottler()
+	api := frenohttp.NewAPIImpl(throttle.NewThrottlerCheck(throttler), &leaderConsensusService{throttler: throttler})
+	server := httptest.NewServer(frenohttp.ConfigureRoutes(api))
+	return throttler, server.URL, server.Close
+}
+
+func TestThrottleAppRuleWithSlash(t *testing.T) {
+	throttler, endpoint, cleanup := newFrenoServer()
+	defer cleanup()
+	client, err := New(Config{Endpoints: []string{endpoint}, App: "test"})
+	test.S(t).ExpectNil(err)
+	ctx := context.Background()
+
+	err = client.ThrottleApp(ctx, "prefix:archiver/", 0, -1)
+	test.S(t).ExpectNil(err)
+	err = client.ThrottleApp(ctx, "prefix:archiver", 0, 0.5)
+	test.S(t).ExpectNil(err)
+	{
+		throttledApps := throttler.ThrottledAppsMap()
+		test.S(t).ExpectEquals(len(throttledApps), 3) // including the built-in "abusing-app"
+		test.S(t).ExpectEquals(throttledApps["prefix:archiver/"].Ratio, throttle.DefaultThrottleRatio)
+		test.S(t).ExpectEquals(throttledApps["prefix:archiver"].Ratio, 0.5)
+	}
+	err = client.ThrottleApp(ctx, "prefix:archiver/", 30*time.Minute, 0.3)
+	test.S(t).ExpectNil(err)
+	{
+		appThrottle := throttler.ThrottledAppsMap()["prefix:archiver/"]
+		test.S(t).ExpectEquals(appThrottle.Ratio, 0.3)
+		test.S(t).ExpectTrue(appThrottle.ExpireAt.Before(time.Now().Add(31 * time.Minute)))
+	}
+
+	err = client.UnthrottleApp(ctx, "prefix:archiver/")
+	test.S(t).ExpectNil(err)
+	{
+		throttledApps := throttler.ThrottledAppsMap()
+		test.S(t).ExpectEquals(len(throttledApps), 2)
+		_, found := throttledApps["prefix:archiver"]
+		test.S(t).ExpectTrue(found)
+	}
+
+	err = client.ThrottleApp(ctx, "app@mysql", 0, -1)
+	test.S(t).ExpectNotNil(err)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// AdminAuthConfigured tells whether admin operations require credentials: any of AdminTokens or AdminIdentities
+func (settings *ConfigurationSettings) AdminAuthConfigured() bool {
+	return len(settings.AdminTokens) > 0 || len(settings.AdminIdentities) > 0
+}
+
+// isAdminToken tells whether given bearer token is one of AdminTokens
+func (settings *ConfigurationSettings) isAdminToken(token string) bool {
+	for _, adminToken := range settings.AdminTokens {
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// isAdminIdentity tells whether given client certificate identity is one of AdminIdentities
+func (settings *ConfigurationSettings) isAdminIdentity(identity string) bool {
+	for _, adminIdentity := range settings.AdminIdentities {
+		if identity == adminIdentity {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeAdmin checks whether a requester may use admin operations: either by a configured bearer token, or by
+// a verified client certificate presenting a configured identity. When neither tokens nor identities are
+// configured, admin operations are open to all.
+// `token` and `identities` are the requester's bearer token and verified certificate identities, either possibly empty.
+// `credentials` indicates whether the requester presented any credentials at all.
+func (settings *ConfigurationSettings) AuthorizeAdmin(token string, identities []string) (authorized bool, credentials bool) {
+	if !settings.AdminAuthConfigured() {
+		return true, false
+	}
+	if token != "" {
+		credentials = true
+		if settings.isAdminToken(token) {
+			return true, credentials
+		}
+	}
+	for _, identity := range identities {
+		credentials = true
+		if settings.isAdminIdentity(identity) {
+			return true, credentials
+		}
+	}
+	return false, credentials
+}
+
+// AdminRequester identifies a requester for auditing: by a verified client certificate identity, preferring
+// admin identities, or else by a fingerprint of a valid admin token. Tokens themselves are never recorded.
+func (settings *ConfigurationSettings) AdminRequester(token string, identities []string) string {
+	for _, identity := range identities {
+		if settings.isAdminIdentity(identity) {
+			return identity
+		}
+	}
+	if len(identities) > 0 {
+		return identities[0]
+	}
+	if token != "" && settings.isAdminToken(token) {
+		fingerprint := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(fingerprint[:4])
+	}
+	return ""
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 
+	"github.com/github/freno/pkg/base"
 	"github.com/outbrain/golib/log"
 )
 
@@ -93,6 +95,13 @@ func (config *Configuration) Reload() error {
 // (like database credentials) are strictly expected from user.
 type ConfigurationSettings struct {
 	ListenPort           int
+	GRPCListenPort       int    // when > 0, the gRPC API is served on this port. Default: 0 (disabled)
+	HTTPTLSCertFile      string // when given, along with HTTPTLSKeyFile, the HTTP API is served over TLS
+	HTTPTLSKeyFile       string
+	HTTPTLSCAFile        string   // when given, clients may authenticate via certificates signed by this CA
+	HTTPTLSRequireClient bool     // require all clients to present a certificate signed by HTTPTLSCAFile
+	AdminIdentities      []string // client certificate identities allowed to use admin routes (throttle, unthrottle, raft membership)
+	AdminTokens          []string // bearer tokens allowed to use admin routes. If neither AdminIdentities nor AdminTokens are given, admin routes are open
 	DataCenter           string
 	Environment          string
 	Domain               string
@@ -101,6 +110,12 @@ type ConfigurationSettings struct {
 	RaftDataDir          string
 	DefaultRaftPort      int      // if a RaftNodes entry does not specify port, use this one
 	RaftNodes            []string // Raft nodes to make initial connection with
+	RaftHTTPPort         int      // HTTP API port of raft nodes, to which followers forward consensus writes. Default: 0, meaning same as ListenPort
+	RaftTLSCAFile        string   // when RaftTLSCertFile is given, raft nodes communicate over mutually authenticated TLS. Peers must be signed by this CA
+	RaftTLSCertFile      string
+	RaftTLSKeyFile       string
+	RaftTLSAllowedPeers  []string // if non empty, peer certificates must present one of these identities (common name, DNS, IP or URI SAN)
+	AuditLogMaxEntries   int      // raft consensus: number of throttle audit entries kept in memory and in snapshots. Default: 10000
 	BackendMySQLHost     string
 	BackendMySQLPort     int
 	BackendMySQLSchema   string
@@ -109,6 +124,7 @@ type ConfigurationSettings struct {
 	MemcacheServers      []string // if given, freno will report to aggregated values to given memcache
 	MemcachePath         string   // use as prefix to metric path in memcache key, e.g. if `MemcachePath` is "myprefix" the key would be "myprefix/mysql/maincluster". Default: "freno"
 	Stores               StoresSettings
+	AppThresholds        map[string](map[string]float64) // "<store-type>/<store-name>" -> app name or app rule -> threshold, overriding the store's ThrottleThreshold for that app
 }
 
 func newConfigurationSettings() *ConfigurationSettings {
@@ -123,6 +139,7 @@ func newConfigurationSettings() *ConfigurationSettings {
 		BackendMySQLPort:   3306,
 		MemcacheServers:    []string{},
 		MemcachePath:       "freno",
+		AuditLogMaxEntries: 10000,
 		//Debug:                                        false,
 		//ListenSocket:                                 "",
 		//AnExampleListOfStrings:                       []string{"*"},
@@ -147,6 +164,31 @@ func (settings *ConfigurationSettings) postReadAdjustments() error {
 	if settings.RaftDataDir == "" && settings.BackendMySQLHost == "" {
 		return fmt.Errorf("Either RaftDataDir or BackendMySQLHost must be set")
 	}
+	if (settings.HTTPTLSCertFile == "") != (settings.HTTPTLSKeyFile == "") {
+		return fmt.Errorf("HTTPTLSCertFile and HTTPTLSKeyFile must be set together")
+	}
+	if settings.HTTPTLSCAFile != "" && settings.HTTPTLSCertFile == "" {
+		return fmt.Errorf("HTTPTLSCAFile requires HTTPTLSCertFile and HTTPTLSKeyFile")
+	}
+	if settings.HTTPTLSRequireClient && settings.HTTPTLSCAFile == "" {
+		return fmt.Errorf("HTTPTLSRequireClient requires HTTPTLSCAFile")
+	}
+	if len(settings.AdminIdentities) > 0 && settings.HTTPTLSCAFile == "" {
+		return fmt.Errorf("AdminIdentities requires HTTPTLSCAFile, so as to verify client certificates")
+	}
+	if len(settings.AdminTokens) > 0 && settings.HTTPTLSCertFile == "" {
+		return fmt.Errorf("AdminTokens requires HTTPTLSCertFile and HTTPTLSKeyFile, so that bearer tokens do not travel in plaintext")
+	}
+	for i, token := range settings.AdminTokens {
+		if submatch := envVariableRegexp.FindStringSubmatch(token); len(submatch) > 1 {
+			settings.AdminTokens[i] = os.Getenv(submatch[1])
+		}
+	}
+	if settings.RaftTLSCAFile != "" || settings.RaftTLSCertFile != "" || settings.RaftTLSKeyFile != "" {
+		if settings.RaftTLSCAFile == "" || settings.RaftTLSCertFile == "" || settings.RaftTLSKeyFile == "" {
+			return fmt.Errorf("RaftTLSCAFile, RaftTLSCertFile and RaftTLSKeyFile must all be set to enable raft TLS")
+		}
+	}
 	if settings.BackendMySQLHost != "" {
 		if settings.BackendMySQLSchema == "" {
 			return fmt.Errorf("BackendMySQLSchema must be set when BackendMySQLHost is specified")
@@ -155,5 +197,33 @@ func (settings *ConfigurationSettings) postReadAdjustments() error {
 	if err := settings.Stores.postReadAdjustments(); err != nil {
 		return err
 	}
+	if _, err := settings.ConfiguredAppThresholds(); err != nil {
+		return err
+	}
 	return nil
 }
+
+// ConfiguredAppThresholds returns the app thresholds of the AppThresholds setting, sorted by store then app
+func (settings *ConfigurationSettings) ConfiguredAppThresholds() (appThresholds []base.AppThreshold, err error) {
+	appThresholds = []base.AppThreshold{}
+	for metricName, thresholds := range settings.AppThresholds {
+		tokens := strings.Split(metricName, "/")
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("AppThresholds: expected <store-type>/<store-name>, got %s", metricName)
+		}
+		for appName, threshold := range thresholds {
+			appThreshold := base.AppThreshold{
+				App:              appName,
+				Threshold:        threshold,
+				AppThrottleStore: base.AppThrottleStore{StoreType: tokens[0], StoreName: tokens[1]},
+				Configured:       true,
+			}
+			if err := appThreshold.Validate(); err != nil {
+				return nil, fmt.Errorf("AppThresholds: %+v", err)
+			}
+			appThresholds = append(appThresholds, appThreshold)
+		}
+	}
+	base.SortAppThresholds(appThresholds)
+	return appThresholds, nil
+}
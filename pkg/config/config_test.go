@@ -85,8 +85,62 @@ func TestReload(t *testing.T) {
 	}
 }
 
+func TestAdminTokensRequireTLS(t *testing.T) {
+	var config = createConfiguration()
+	config.settings.AdminTokens = []string{"s3cret"}
+	dump("/tmp/TestAdminTokensRequireTLSFixture.json", config.settings)
+
+	config = createConfiguration()
+	if err := config.Read("/tmp/TestAdminTokensRequireTLSFixture.json"); err == nil {
+		t.Errorf("Expected AdminTokens without HTTPTLSCertFile to be refused")
+	}
+
+	config = createConfiguration()
+	config.settings.AdminTokens = []string{"s3cret"}
+	config.settings.HTTPTLSCertFile = "/etc/freno/tls/freno.pem"
+	config.settings.HTTPTLSKeyFile = "/etc/freno/tls/freno-key.pem"
+	dump("/tmp/TestAdminTokensRequireTLSFixture.json", config.settings)
+
+	config = createConfiguration()
+	if err := config.Read("/tmp/TestAdminTokensRequireTLSFixture.json"); err != nil {
+		t.Errorf("Expected AdminTokens with HTTPTLSCertFile to be accepted, got %+v", err)
+	}
+}
+
 func dump(path string, contents *ConfigurationSettings) error {
 	json, _ := json.Marshal(contents)
 	err := ioutil.WriteFile(path, json, 0644)
 	return err
 }
+
+func TestConfiguredAppThresholds(t *testing.T) {
+	settings := newConfigurationSettings()
+	settings.AppThresholds = map[string](map[string]float64){
+		"mysql/main7": {"prefix:archiver": 0.5, "gh-ost": 1.5},
+		"mysql/main1": {"archiver": 0.8},
+	}
+	appThresholds, err := settings.ConfiguredAppThresholds()
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	if len(appThresholds) != 3 {
+		t.Fatalf("Expected 3 app thresholds, got %d", len(appThresholds))
+	}
+	if first := appThresholds[0]; first.App != "archiver" || first.StoreName != "main1" || first.Threshold != 0.8 || !first.Configured {
+		t.Errorf("Unexpected first app threshold: %+v", first)
+	}
+	if last := appThresholds[2]; last.App != "prefix:archiver" || last.StoreType != "mysql" || last.StoreName != "main7" {
+		t.Errorf("Unexpected last app threshold: %+v", last)
+	}
+
+	for _, invalid := range []map[string](map[string]float64){
+		{"mysql": {"archiver": 0.5}},
+		{"mysql/main7": {"archiver": 0}},
+		{"mysql/main7": {"regex:(": 0.5}},
+	} {
+		settings.AppThresholds = invalid
+		if _, err := settings.ConfiguredAppThresholds(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+//
+// HTTP-store specific configuration
+//
+
+import (
+	"github.com/github/freno/pkg/base"
+)
+
+const DefaultHTTPTimeoutMillis = 1000
+
+type HTTPClusterConfigurationSettings struct {
+	URLs                 []string // List of URLs to probe. Each URL is considered as a host in this cluster
+	JSONPath             string   // override HTTPConfigurationSettings's, or leave empty to inherit those settings
+	TimeoutMillis        int      // override HTTPConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis          int      // override HTTPConfigurationSettings's, or leave empty to inherit those settings
+	ThrottleThreshold    float64  // override HTTPConfigurationSettings's, or leave empty to inherit those settings
+	IgnoreHostsCount     int      // Number of URLs that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // override HTTPConfigurationSettings's, or leave empty to inherit those settings
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *HTTPClusterConfigurationSettings) postReadAdjustments() error {
+	return nil
+}
+
+type HTTPConfigurationSettings struct {
+	JSONPath             string // Path of numeric value within a JSON response, e.g. `$.replication.lag`. If empty, response body is expected to be a plain-text number
+	TimeoutMillis        int    // HTTP request timeout. Default: 1000
+	CacheMillis          int    // optional, if defined then probe result will be cached, and future probes may use cached value
+	ThrottleThreshold    float64
+	IgnoreDialTcpErrors  bool    // Skip URLs where a metric cannot be retrieved due to TCP dial errors
+	IgnoreHostsCount     int     // Number of URLs that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64 // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string  // How hosts' values are aggregated: max (default), min, avg, median, percentile:N, quorum:N%
+
+	Clusters map[string](*HTTPClusterConfigurationSettings) // cluster name -> cluster config
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *HTTPConfigurationSettings) postReadAdjustments() error {
+	if settings.TimeoutMillis == 0 {
+		settings.TimeoutMillis = DefaultHTTPTimeoutMillis
+	}
+	for _, clusterSettings := range settings.Clusters {
+		if err := clusterSettings.postReadAdjustments(); err != nil {
+			return err
+		}
+		if clusterSettings.JSONPath == "" {
+			clusterSettings.JSONPath = settings.JSONPath
+		}
+		if clusterSettings.TimeoutMillis == 0 {
+			clusterSettings.TimeoutMillis = settings.TimeoutMillis
+		}
+		if clusterSettings.CacheMillis == 0 {
+			clusterSettings.CacheMillis = settings.CacheMillis
+		}
+		if clusterSettings.ThrottleThreshold == 0 {
+			clusterSettings.ThrottleThreshold = settings.ThrottleThreshold
+		}
+		if clusterSettings.IgnoreHostsCount == 0 {
+			clusterSettings.IgnoreHostsCount = settings.IgnoreHostsCount
+		}
+		if clusterSettings.IgnoreHostsThreshold == 0 {
+			clusterSettings.IgnoreHostsThreshold = settings.IgnoreHostsThreshold
+		}
+		if clusterSettings.AggregationMode == "" {
+			clusterSettings.AggregationMode = settings.AggregationMode
+		}
+		if _, err := base.ParseAggregationMode(clusterSettings.AggregationMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestHTTPConfigurationSettingsInheritance(t *testing.T) {
+	settings := &HTTPConfigurationSettings{
+		JSONPath:          "$.lag",
+		ThrottleThreshold: 2,
+		CacheMillis:       100,
+		Clusters: map[string](*HTTPClusterConfigurationSettings){
+			"queue1": {URLs: []string{"http://10.0.0.1/lag"}},
+			"queue2": {URLs: []string{"http://10.0.0.2/lag"}, JSONPath: "$.depth", TimeoutMillis: 300, ThrottleThreshold: 100},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectEquals(settings.TimeoutMillis, DefaultHTTPTimeoutMillis)
+
+	queue1 := settings.Clusters["queue1"]
+	test.S(t).ExpectEquals(queue1.JSONPath, "$.lag")
+	test.S(t).ExpectEquals(queue1.TimeoutMillis, DefaultHTTPTimeoutMillis)
+	test.S(t).ExpectEquals(queue1.CacheMillis, 100)
+	test.S(t).ExpectEquals(queue1.ThrottleThreshold, 2.0)
+
+	queue2 := settings.Clusters["queue2"]
+	test.S(t).ExpectEquals(queue2.JSONPath, "$.depth")
+	test.S(t).ExpectEquals(queue2.TimeoutMillis, 300)
+	test.S(t).ExpectEquals(queue2.ThrottleThreshold, 100.0)
+}
+
+func TestHTTPConfigurationSettingsAggregationMode(t *testing.T) {
+	{
+		settings := &HTTPConfigurationSettings{
+			AggregationMode: "percentile:90",
+			Clusters: map[string](*HTTPClusterConfigurationSettings){
+				"queue1": {},
+				"queue2": {AggregationMode: "median"},
+			},
+		}
+		err := settings.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(settings.Clusters["queue1"].AggregationMode, "percentile:90")
+		test.S(t).ExpectEquals(settings.Clusters["queue2"].AggregationMode, "median")
+	}
+	{
+		settings := &HTTPConfigurationSettings{
+			Clusters: map[string](*HTTPClusterConfigurationSettings){
+				"queue1": {AggregationMode: "p90"},
+			},
+		}
+		err := settings.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
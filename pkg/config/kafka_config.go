@@ -0,0 +1,82 @@
+package config
+
+//
+// Kafka-specific configuration
+//
+
+import (
+	"fmt"
+)
+
+const DefaultKafkaTimeoutMillis = 1000
+const DefaultKafkaAggregation = "max"
+
+var knownKafkaAggregations = map[string]bool{"max": true, "sum": true}
+
+type KafkaGroupConfigurationSettings struct {
+	GroupID           string   // Consumer group ID. Default: the group's name in this config
+	Topics            []string // Topics consumed by the group, for which lag is computed
+	Brokers           []string // override KafkaConfigurationSettings's, or leave empty to inherit those settings
+	Aggregation       string   // override KafkaConfigurationSettings's, or leave empty to inherit those settings
+	TimeoutMillis     int      // override KafkaConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis       int      // override KafkaConfigurationSettings's, or leave empty to inherit those settings
+	ThrottleThreshold float64  // override KafkaConfigurationSettings's, or leave empty to inherit those settings
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *KafkaGroupConfigurationSettings) postReadAdjustments() error {
+	if len(settings.Topics) == 0 {
+		return fmt.Errorf("No Topics configured for Kafka group %s", settings.GroupID)
+	}
+	return nil
+}
+
+type KafkaConfigurationSettings struct {
+	Brokers           []string // Bootstrap brokers, "hostname:port"
+	Aggregation       string   // How partitions' lag is aggregated: "max" (default) or "sum"
+	TimeoutMillis     int      // Timeout for reading a group's offsets. Default: 1000
+	CacheMillis       int      // optional, if defined then probe result will be cached, and future probes may use cached value
+	ThrottleThreshold float64
+
+	Groups map[string](*KafkaGroupConfigurationSettings) // group name -> group config
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *KafkaConfigurationSettings) postReadAdjustments() error {
+	if settings.TimeoutMillis == 0 {
+		settings.TimeoutMillis = DefaultKafkaTimeoutMillis
+	}
+	if settings.Aggregation == "" {
+		settings.Aggregation = DefaultKafkaAggregation
+	}
+	if !knownKafkaAggregations[settings.Aggregation] {
+		return fmt.Errorf("Unknown Kafka Aggregation: %s", settings.Aggregation)
+	}
+	for groupName, groupSettings := range settings.Groups {
+		if groupSettings.GroupID == "" {
+			groupSettings.GroupID = groupName
+		}
+		if err := groupSettings.postReadAdjustments(); err != nil {
+			return err
+		}
+		if len(groupSettings.Brokers) == 0 {
+			groupSettings.Brokers = settings.Brokers
+		}
+		if groupSettings.Aggregation == "" {
+			groupSettings.Aggregation = settings.Aggregation
+		}
+		if !knownKafkaAggregations[groupSettings.Aggregation] {
+			return fmt.Errorf("Unknown Kafka Aggregation for group %s: %s", groupName, groupSettings.Aggregation)
+		}
+		if groupSettings.TimeoutMillis == 0 {
+			groupSettings.TimeoutMillis = settings.TimeoutMillis
+		}
+		if groupSettings.CacheMillis == 0 {
+			groupSettings.CacheMillis = settings.CacheMillis
+		}
+		if groupSettings.ThrottleThreshold == 0 {
+			groupSettings.ThrottleThreshold = settings.ThrottleThreshold
+		}
+	}
+	return nil
+}
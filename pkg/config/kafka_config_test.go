@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestKafkaConfigurationSettingsInheritance(t *testing.T) {
+	settings := &KafkaConfigurationSettings{
+		Brokers:           []string{"kafka1:9092", "kafka2:9092"},
+		ThrottleThreshold: 10000,
+		CacheMillis:       500,
+		Groups: map[string](*KafkaGroupConfigurationSettings){
+			"indexer":   {Topics: []string{"events"}},
+			"mirroring": {GroupID: "mirror-maker", Topics: []string{"events", "audit"}, Aggregation: "sum", Brokers: []string{"kafka3:9092"}, ThrottleThreshold: 50000},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNil(err)
+
+	indexer := settings.Groups["indexer"]
+	test.S(t).ExpectEquals(indexer.GroupID, "indexer")
+	test.S(t).ExpectEquals(len(indexer.Brokers), 2)
+	test.S(t).ExpectEquals(indexer.Aggregation, DefaultKafkaAggregation)
+	test.S(t).ExpectEquals(indexer.TimeoutMillis, DefaultKafkaTimeoutMillis)
+	test.S(t).ExpectEquals(indexer.CacheMillis, 500)
+	test.S(t).ExpectEquals(indexer.ThrottleThreshold, 10000.0)
+
+	mirroring := settings.Groups["mirroring"]
+	test.S(t).ExpectEquals(mirroring.GroupID, "mirror-maker")
+	test.S(t).ExpectEquals(mirroring.Brokers[0], "kafka3:9092")
+	test.S(t).ExpectEquals(mirroring.Aggregation, "sum")
+	test.S(t).ExpectEquals(mirroring.ThrottleThreshold, 50000.0)
+}
+
+func TestKafkaConfigurationSettingsValidation(t *testing.T) {
+	{
+		settings := &KafkaConfigurationSettings{
+			Groups: map[string](*KafkaGroupConfigurationSettings){"indexer": {}},
+		}
+		test.S(t).ExpectNotNil(settings.postReadAdjustments())
+	}
+	{
+		settings := &KafkaConfigurationSettings{
+			Groups: map[string](*KafkaGroupConfigurationSettings){"indexer": {Topics: []string{"events"}, Aggregation: "avg"}},
+		}
+		test.S(t).ExpectNotNil(settings.postReadAdjustments())
+	}
+}
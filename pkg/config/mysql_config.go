@@ -6,6 +6,8 @@ package config
 
 import (
 	"os"
+
+	"github.com/github/freno/pkg/base"
 )
 
 const DefaultMySQLPort = 3306
@@ -19,6 +21,7 @@ type MySQLClusterConfigurationSettings struct {
 	Port                 int      // Specify if different than 3306 or if different than specified by MySQLConfigurationSettings
 	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
 	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // override MySQLConfigurationSettings's, or leave empty to inherit those settings
 	HttpCheckPort        int      // Specify if different than specified by MySQLConfigurationSettings. -1 to disable HTTP check
 	HttpCheckPath        string   // Specify if different than specified by MySQLConfigurationSettings
 	IgnoreHosts          []string // override MySQLConfigurationSettings's, or leave empty to inherit those settings
@@ -55,6 +58,7 @@ type MySQLConfigurationSettings struct {
 	IgnoreDialTcpErrors  bool     // Skip hosts where a metric cannot be retrieved due to TCP dial errors
 	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
 	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // How hosts' values are aggregated: max (default), min, avg, median, percentile:N, quorum:N%
 	HttpCheckPort        int      // port for HTTP check. -1 to disable.
 	HttpCheckPath        string   // If non-empty, requires HttpCheckPort
 	IgnoreHosts          []string // If non empty, substrings to indicate hosts to be ignored/skipped
@@ -106,6 +110,12 @@ func (settings *MySQLConfigurationSettings) postReadAdjustments() error {
 		if clusterSettings.IgnoreHostsThreshold == 0 {
 			clusterSettings.IgnoreHostsThreshold = settings.IgnoreHostsThreshold
 		}
+		if clusterSettings.AggregationMode == "" {
+			clusterSettings.AggregationMode = settings.AggregationMode
+		}
+		if _, err := base.ParseAggregationMode(clusterSettings.AggregationMode); err != nil {
+			return err
+		}
 		if clusterSettings.HttpCheckPort == 0 {
 			clusterSettings.HttpCheckPort = settings.HttpCheckPort
 		}
@@ -0,0 +1,132 @@
+package config
+
+//
+// PostgreSQL-specific configuration
+//
+
+import (
+	"os"
+
+	"github.com/github/freno/pkg/base"
+)
+
+const DefaultPostgreSQLPort = 5432
+const DefaultPostgreSQLDatabase = "postgres"
+
+type PostgreSQLClusterConfigurationSettings struct {
+	User                 string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	Password             string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	Database             string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	SSLMode              string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	MetricQuery          string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis          int      // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	ThrottleThreshold    float64  // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	Port                 int      // Specify if different than 5432 or if different than specified by PostgreSQLConfigurationSettings
+	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+	IgnoreHosts          []string // override PostgreSQLConfigurationSettings's, or leave empty to inherit those settings
+
+	HAProxySettings     HAProxyConfigurationSettings // If list of servers is to be acquired via HAProxy, provide this field
+	StaticHostsSettings StaticHostsConfigurationSettings
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *PostgreSQLClusterConfigurationSettings) postReadAdjustments() error {
+	// Username & password may be given as plaintext in the config file, or can be delivered
+	// via environment variables. We accept user & password in the form "${SOME_ENV_VARIABLE}"
+	// in which case we get the value from this process' invoking environment.
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.User); len(submatch) > 1 {
+		settings.User = os.Getenv(submatch[1])
+	}
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.Password); len(submatch) > 1 {
+		settings.Password = os.Getenv(submatch[1])
+	}
+	if err := settings.HAProxySettings.postReadAdjustments(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type PostgreSQLConfigurationSettings struct {
+	User                 string
+	Password             string
+	Database             string // Database to connect to. Default: "postgres"
+	SSLMode              string // lib/pq sslmode, e.g. "disable", "require", "verify-full". Empty means driver default
+	MetricQuery          string // If empty, replication lag is computed based on pg_last_xact_replay_timestamp()
+	CacheMillis          int    // optional, if defined then probe result will be cached, and future probes may use cached value
+	ThrottleThreshold    float64
+	Port                 int      // Specify if different than 5432; applies to all clusters
+	IgnoreDialTcpErrors  bool     // Skip hosts where a metric cannot be retrieved due to TCP dial errors
+	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // How hosts' values are aggregated: max (default), min, avg, median, percentile:N, quorum:N%
+	IgnoreHosts          []string // If non empty, substrings to indicate hosts to be ignored/skipped
+
+	Clusters map[string](*PostgreSQLClusterConfigurationSettings) // cluster name -> cluster config
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *PostgreSQLConfigurationSettings) postReadAdjustments() error {
+	if settings.Port == 0 {
+		settings.Port = DefaultPostgreSQLPort
+	}
+	if settings.Database == "" {
+		settings.Database = DefaultPostgreSQLDatabase
+	}
+	// Username & password may be given as plaintext in the config file, or can be delivered
+	// via environment variables. We accept user & password in the form "${SOME_ENV_VARIABLE}"
+	// in which case we get the value from this process' invoking environment.
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.User); len(submatch) > 1 {
+		settings.User = os.Getenv(submatch[1])
+	}
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.Password); len(submatch) > 1 {
+		settings.Password = os.Getenv(submatch[1])
+	}
+
+	for _, clusterSettings := range settings.Clusters {
+		if err := clusterSettings.postReadAdjustments(); err != nil {
+			return err
+		}
+		if clusterSettings.User == "" {
+			clusterSettings.User = settings.User
+		}
+		if clusterSettings.Password == "" {
+			clusterSettings.Password = settings.Password
+		}
+		if clusterSettings.Database == "" {
+			clusterSettings.Database = settings.Database
+		}
+		if clusterSettings.SSLMode == "" {
+			clusterSettings.SSLMode = settings.SSLMode
+		}
+		if clusterSettings.MetricQuery == "" {
+			clusterSettings.MetricQuery = settings.MetricQuery
+		}
+		if clusterSettings.CacheMillis == 0 {
+			clusterSettings.CacheMillis = settings.CacheMillis
+		}
+		if clusterSettings.ThrottleThreshold == 0 {
+			clusterSettings.ThrottleThreshold = settings.ThrottleThreshold
+		}
+		if clusterSettings.Port == 0 {
+			clusterSettings.Port = settings.Port
+		}
+		if clusterSettings.IgnoreHostsCount == 0 {
+			clusterSettings.IgnoreHostsCount = settings.IgnoreHostsCount
+		}
+		if clusterSettings.IgnoreHostsThreshold == 0 {
+			clusterSettings.IgnoreHostsThreshold = settings.IgnoreHostsThreshold
+		}
+		if clusterSettings.AggregationMode == "" {
+			clusterSettings.AggregationMode = settings.AggregationMode
+		}
+		if _, err := base.ParseAggregationMode(clusterSettings.AggregationMode); err != nil {
+			return err
+		}
+		if len(clusterSettings.IgnoreHosts) == 0 {
+			clusterSettings.IgnoreHosts = settings.IgnoreHosts
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestPostgreSQLConfigurationSettingsInheritance(t *testing.T) {
+	settings := &PostgreSQLConfigurationSettings{
+		User:              "freno",
+		Password:          "secret",
+		ThrottleThreshold: 1.5,
+		IgnoreHostsCount:  1,
+		Clusters: map[string](*PostgreSQLClusterConfigurationSettings){
+			"pg1": {},
+			"pg2": {User: "other", Port: 6432, ThrottleThreshold: 0.5, Database: "app"},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectEquals(settings.Port, DefaultPostgreSQLPort)
+	test.S(t).ExpectEquals(settings.Database, DefaultPostgreSQLDatabase)
+
+	pg1 := settings.Clusters["pg1"]
+	test.S(t).ExpectEquals(pg1.User, "freno")
+	test.S(t).ExpectEquals(pg1.Password, "secret")
+	test.S(t).ExpectEquals(pg1.Database, DefaultPostgreSQLDatabase)
+	test.S(t).ExpectEquals(pg1.Port, DefaultPostgreSQLPort)
+	test.S(t).ExpectEquals(pg1.ThrottleThreshold, 1.5)
+	test.S(t).ExpectEquals(pg1.IgnoreHostsCount, 1)
+
+	pg2 := settings.Clusters["pg2"]
+	test.S(t).ExpectEquals(pg2.User, "other")
+	test.S(t).ExpectEquals(pg2.Password, "secret")
+	test.S(t).ExpectEquals(pg2.Database, "app")
+	test.S(t).ExpectEquals(pg2.Port, 6432)
+	test.S(t).ExpectEquals(pg2.ThrottleThreshold, 0.5)
+}
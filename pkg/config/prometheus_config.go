@@ -0,0 +1,86 @@
+package config
+
+//
+// Prometheus-specific configuration
+//
+
+import (
+	"github.com/github/freno/pkg/base"
+)
+
+const DefaultPrometheusTimeoutMillis = 1000
+const DefaultPrometheusHostLabel = "instance"
+
+type PrometheusClusterConfigurationSettings struct {
+	Query                string  // PromQL instant query. Each element in the result vector is considered a host
+	Address              string  // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+	HostLabel            string  // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+	TimeoutMillis        int     // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis          int     // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+	ThrottleThreshold    float64 // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+	IgnoreHostsCount     int     // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64 // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string  // override PrometheusConfigurationSettings's, or leave empty to inherit those settings
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *PrometheusClusterConfigurationSettings) postReadAdjustments() error {
+	return nil
+}
+
+type PrometheusConfigurationSettings struct {
+	Address              string // Prometheus HTTP API base URL, e.g. http://prometheus.mydomain.com:9090
+	HostLabel            string // Label identifying a host in query results. Default: "instance"
+	TimeoutMillis        int    // Query timeout. Default: 1000
+	CacheMillis          int    // optional, if defined then query result will be cached, and future probes may use cached value
+	ThrottleThreshold    float64
+	IgnoreDialTcpErrors  bool    // Skip hosts where a metric cannot be retrieved due to TCP dial errors
+	IgnoreHostsCount     int     // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64 // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string  // How hosts' values are aggregated: max (default), min, avg, median, percentile:N, quorum:N%
+
+	Clusters map[string](*PrometheusClusterConfigurationSettings) // store name -> query config
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *PrometheusConfigurationSettings) postReadAdjustments() error {
+	if settings.HostLabel == "" {
+		settings.HostLabel = DefaultPrometheusHostLabel
+	}
+	if settings.TimeoutMillis == 0 {
+		settings.TimeoutMillis = DefaultPrometheusTimeoutMillis
+	}
+	for _, clusterSettings := range settings.Clusters {
+		if err := clusterSettings.postReadAdjustments(); err != nil {
+			return err
+		}
+		if clusterSettings.Address == "" {
+			clusterSettings.Address = settings.Address
+		}
+		if clusterSettings.HostLabel == "" {
+			clusterSettings.HostLabel = settings.HostLabel
+		}
+		if clusterSettings.TimeoutMillis == 0 {
+			clusterSettings.TimeoutMillis = settings.TimeoutMillis
+		}
+		if clusterSettings.CacheMillis == 0 {
+			clusterSettings.CacheMillis = settings.CacheMillis
+		}
+		if clusterSettings.ThrottleThreshold == 0 {
+			clusterSettings.ThrottleThreshold = settings.ThrottleThreshold
+		}
+		if clusterSettings.IgnoreHostsCount == 0 {
+			clusterSettings.IgnoreHostsCount = settings.IgnoreHostsCount
+		}
+		if clusterSettings.IgnoreHostsThreshold == 0 {
+			clusterSettings.IgnoreHostsThreshold = settings.IgnoreHostsThreshold
+		}
+		if clusterSettings.AggregationMode == "" {
+			clusterSettings.AggregationMode = settings.AggregationMode
+		}
+		if _, err := base.ParseAggregationMode(clusterSettings.AggregationMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
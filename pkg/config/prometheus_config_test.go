@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestPrometheusConfigurationSettingsInheritance(t *testing.T) {
+	settings := &PrometheusConfigurationSettings{
+		Address:           "http://prometheus:9090",
+		ThrottleThreshold: 1,
+		Clusters: map[string](*PrometheusClusterConfigurationSettings){
+			"lag":    {Query: "mysql_slave_lag_seconds"},
+			"diskio": {Query: "rate(node_disk_io_time_seconds_total[1m])", Address: "http://prometheus2:9090", HostLabel: "host", ThrottleThreshold: 0.8},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNil(err)
+
+	lag := settings.Clusters["lag"]
+	test.S(t).ExpectEquals(lag.Address, "http://prometheus:9090")
+	test.S(t).ExpectEquals(lag.HostLabel, DefaultPrometheusHostLabel)
+	test.S(t).ExpectEquals(lag.TimeoutMillis, DefaultPrometheusTimeoutMillis)
+	test.S(t).ExpectEquals(lag.ThrottleThreshold, 1.0)
+
+	diskio := settings.Clusters["diskio"]
+	test.S(t).ExpectEquals(diskio.Address, "http://prometheus2:9090")
+	test.S(t).ExpectEquals(diskio.HostLabel, "host")
+	test.S(t).ExpectEquals(diskio.ThrottleThreshold, 0.8)
+}
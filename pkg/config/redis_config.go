@@ -0,0 +1,133 @@
+package config
+
+//
+// Redis-specific configuration
+//
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/github/freno/pkg/base"
+)
+
+const DefaultRedisPort = 6379
+const DefaultRedisTimeoutMillis = 1000
+const DefaultRedisLagMetric = "offset"
+
+var knownRedisLagMetrics = map[string]bool{"offset": true, "io-seconds": true}
+
+type RedisClusterConfigurationSettings struct {
+	Primary              string   // Primary's "hostname" or "hostname:port". Replicas are discovered via `INFO replication` on the primary unless StaticHostsSettings is given
+	User                 string   // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	Password             string   // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	LagMetric            string   // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	TimeoutMillis        int      // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	CacheMillis          int      // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	ThrottleThreshold    float64  // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	Port                 int      // Specify if different than 6379 or if different than specified by RedisConfigurationSettings
+	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // override RedisConfigurationSettings's, or leave empty to inherit those settings
+	IgnoreHosts          []string // override RedisConfigurationSettings's, or leave empty to inherit those settings
+
+	StaticHostsSettings StaticHostsConfigurationSettings // Replicas, if not to be discovered via Primary
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *RedisClusterConfigurationSettings) postReadAdjustments() error {
+	// Password may be given as plaintext in the config file, or can be delivered
+	// via environment variables, in the form "${SOME_ENV_VARIABLE}"
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.User); len(submatch) > 1 {
+		settings.User = os.Getenv(submatch[1])
+	}
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.Password); len(submatch) > 1 {
+		settings.Password = os.Getenv(submatch[1])
+	}
+	return nil
+}
+
+type RedisConfigurationSettings struct {
+	User                 string // For Redis 6 ACLs. Leave empty to authenticate with Password only
+	Password             string
+	LagMetric            string // "offset" (primary's master_repl_offset minus replica's offset, in bytes; default) or "io-seconds" (replica's master_last_io_seconds_ago)
+	TimeoutMillis        int    // Connect & read timeout. Default: 1000
+	CacheMillis          int    // optional, if defined then probe result will be cached, and future probes may use cached value
+	ThrottleThreshold    float64
+	Port                 int      // Specify if different than 6379; applies to all clusters
+	IgnoreDialTcpErrors  bool     // Skip hosts where a metric cannot be retrieved due to TCP dial errors
+	IgnoreHostsCount     int      // Number of hosts that can be skipped/ignored even on error or on exceeding theesholds
+	IgnoreHostsThreshold float64  // Threshold beyond which IgnoreHostsCount applies (default: 0)
+	AggregationMode      string   // How hosts' values are aggregated: max (default), min, avg, median, percentile:N, quorum:N%
+	IgnoreHosts          []string // If non empty, substrings to indicate hosts to be ignored/skipped
+
+	Clusters map[string](*RedisClusterConfigurationSettings) // cluster name -> cluster config
+}
+
+// Hook to implement adjustments after reading each configuration file.
+func (settings *RedisConfigurationSettings) postReadAdjustments() error {
+	if settings.Port == 0 {
+		settings.Port = DefaultRedisPort
+	}
+	if settings.TimeoutMillis == 0 {
+		settings.TimeoutMillis = DefaultRedisTimeoutMillis
+	}
+	if settings.LagMetric == "" {
+		settings.LagMetric = DefaultRedisLagMetric
+	}
+	if !knownRedisLagMetrics[settings.LagMetric] {
+		return fmt.Errorf("Unknown Redis LagMetric: %s", settings.LagMetric)
+	}
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.User); len(submatch) > 1 {
+		settings.User = os.Getenv(submatch[1])
+	}
+	if submatch := envVariableRegexp.FindStringSubmatch(settings.Password); len(submatch) > 1 {
+		settings.Password = os.Getenv(submatch[1])
+	}
+
+	for clusterName, clusterSettings := range settings.Clusters {
+		if err := clusterSettings.postReadAdjustments(); err != nil {
+			return err
+		}
+		if clusterSettings.User == "" {
+			clusterSettings.User = settings.User
+		}
+		if clusterSettings.Password == "" {
+			clusterSettings.Password = settings.Password
+		}
+		if clusterSettings.LagMetric == "" {
+			clusterSettings.LagMetric = settings.LagMetric
+		}
+		if !knownRedisLagMetrics[clusterSettings.LagMetric] {
+			return fmt.Errorf("Unknown Redis LagMetric for cluster %s: %s", clusterName, clusterSettings.LagMetric)
+		}
+		if clusterSettings.TimeoutMillis == 0 {
+			clusterSettings.TimeoutMillis = settings.TimeoutMillis
+		}
+		if clusterSettings.CacheMillis == 0 {
+			clusterSettings.CacheMillis = settings.CacheMillis
+		}
+		if clusterSettings.ThrottleThreshold == 0 {
+			clusterSettings.ThrottleThreshold = settings.ThrottleThreshold
+		}
+		if clusterSettings.Port == 0 {
+			clusterSettings.Port = settings.Port
+		}
+		if clusterSettings.IgnoreHostsCount == 0 {
+			clusterSettings.IgnoreHostsCount = settings.IgnoreHostsCount
+		}
+		if clusterSettings.IgnoreHostsThreshold == 0 {
+			clusterSettings.IgnoreHostsThreshold = settings.IgnoreHostsThreshold
+		}
+		if clusterSettings.AggregationMode == "" {
+			clusterSettings.AggregationMode = settings.AggregationMode
+		}
+		if _, err := base.ParseAggregationMode(clusterSettings.AggregationMode); err != nil {
+			return err
+		}
+		if len(clusterSettings.IgnoreHosts) == 0 {
+			clusterSettings.IgnoreHosts = settings.IgnoreHosts
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestRedisConfigurationSettingsInheritance(t *testing.T) {
+	settings := &RedisConfigurationSettings{
+		Password:          "secret",
+		ThrottleThreshold: 1000000,
+		IgnoreHostsCount:  1,
+		Clusters: map[string](*RedisClusterConfigurationSettings){
+			"cache": {Primary: "10.0.0.1"},
+			"queue": {StaticHostsSettings: StaticHostsConfigurationSettings{Hosts: []string{"10.0.0.2:6380"}}, LagMetric: "io-seconds", ThrottleThreshold: 5, Port: 6380},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectEquals(settings.Port, DefaultRedisPort)
+	test.S(t).ExpectEquals(settings.LagMetric, DefaultRedisLagMetric)
+
+	cache := settings.Clusters["cache"]
+	test.S(t).ExpectEquals(cache.Password, "secret")
+	test.S(t).ExpectEquals(cache.LagMetric, "offset")
+	test.S(t).ExpectEquals(cache.Port, DefaultRedisPort)
+	test.S(t).ExpectEquals(cache.TimeoutMillis, DefaultRedisTimeoutMillis)
+	test.S(t).ExpectEquals(cache.ThrottleThreshold, 1000000.0)
+	test.S(t).ExpectEquals(cache.IgnoreHostsCount, 1)
+
+	queue := settings.Clusters["queue"]
+	test.S(t).ExpectEquals(queue.LagMetric, "io-seconds")
+	test.S(t).ExpectEquals(queue.Port, 6380)
+	test.S(t).ExpectEquals(queue.ThrottleThreshold, 5.0)
+}
+
+func TestRedisConfigurationSettingsUnknownLagMetric(t *testing.T) {
+	settings := &RedisConfigurationSettings{
+		Clusters: map[string](*RedisClusterConfigurationSettings){
+			"cache": {Primary: "10.0.0.1", LagMetric: "bytes"},
+		},
+	}
+	err := settings.postReadAdjustments()
+	test.S(t).ExpectNotNil(err)
+}
@@ -5,7 +5,12 @@ package config
 //
 
 type StoresSettings struct {
-	MySQL MySQLConfigurationSettings // Any and all MySQL setups go here
+	MySQL      MySQLConfigurationSettings      // Any and all MySQL setups go here
+	PostgreSQL PostgreSQLConfigurationSettings // Any and all PostgreSQL setups go here
+	HTTP       HTTPConfigurationSettings       // Any and all HTTP/JSON metric endpoints go here
+	Prometheus PrometheusConfigurationSettings // Any and all Prometheus queries go here
+	Redis      RedisConfigurationSettings      // Any and all Redis setups go here
+	Kafka      KafkaConfigurationSettings      // Any and all Kafka consumer groups go here
 
 	// Futuristic stores can come here.
 }
@@ -15,5 +20,20 @@ func (settings *StoresSettings) postReadAdjustments() error {
 	if err := settings.MySQL.postReadAdjustments(); err != nil {
 		return err
 	}
+	if err := settings.PostgreSQL.postReadAdjustments(); err != nil {
+		return err
+	}
+	if err := settings.HTTP.postReadAdjustments(); err != nil {
+		return err
+	}
+	if err := settings.Prometheus.postReadAdjustments(); err != nil {
+		return err
+	}
+	if err := settings.Redis.postReadAdjustments(); err != nil {
+		return err
+	}
+	if err := settings.Kafka.postReadAdjustments(); err != nil {
+		return err
+	}
 	return nil
 }
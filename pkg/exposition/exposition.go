@@ -0,0 +1,125 @@
+// Package exposition maintains labeled metrics, and renders them in the Prometheus text exposition format.
+//
+// freno's go-metrics (see /debug/metrics) flatten labels into metric names, e.g. `check.<app>.<store-type>.<store-name>.total`.
+// The metrics in this package carry the same information as proper labels, to be scraped via /metrics.
+package exposition
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types
+const (
+	CounterType   = "counter"
+	GaugeType     = "gauge"
+	HistogramType = "histogram"
+)
+
+// Label is a single name/value label pair
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single exposed value. Suffix is appended to the family name, e.g. "_bucket" for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named group of samples of the same type
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// NewFamily creates an empty family
+func NewFamily(name string, help string, metricType string) *Family {
+	return &Family{Name: name, Help: help, Type: metricType}
+}
+
+// Add adds a sample to the family, pairing given label names and values
+func (family *Family) Add(value float64, labelNames []string, labelValues ...string) *Family {
+	family.Samples = append(family.Samples, Sample{Labels: pairLabels(labelNames, labelValues), Value: value})
+	return family
+}
+
+func pairLabels(labelNames []string, labelValues []string) []Label {
+	labels := make([]Label, len(labelNames))
+	for i, labelName := range labelNames {
+		labels[i] = Label{Name: labelName}
+		if i < len(labelValues) {
+			labels[i].Value = labelValues[i]
+		}
+	}
+	return labels
+}
+
+// Write renders given families in text format, sorted by family name
+func Write(w io.Writer, families ...*Family) error {
+	sorted := make([]*Family, len(families))
+	copy(sorted, families)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	buf := bufio.NewWriter(w)
+	for _, family := range sorted {
+		if len(family.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(buf, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name)
+			buf.WriteString(sample.Suffix)
+			if len(sample.Labels) > 0 {
+				buf.WriteString("{")
+				for i, label := range sample.Labels {
+					if i > 0 {
+						buf.WriteString(",")
+					}
+					fmt.Fprintf(buf, `%s="%s"`, label.Name, escapeLabelValue(label.Value))
+				}
+				buf.WriteString("}")
+			}
+			buf.WriteString(" ")
+			buf.WriteString(formatValue(sample.Value))
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Flush()
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
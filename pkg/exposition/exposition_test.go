@@ -0,0 +1,58 @@
+package exposition
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestWrite(t *testing.T) {
+	family := NewFamily("freno_test_value", "A test value.", GaugeType)
+	family.Add(1.5, []string{"app", "host"}, "my-app", `some"host\`)
+	family.Add(math.Inf(1), nil)
+
+	var buf bytes.Buffer
+	err := Write(&buf, family, NewFamily("freno_test_empty", "Not written.", GaugeType))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(buf.String(), `# HELP freno_test_value A test value.
+# TYPE freno_test_value gauge
+freno_test_value{app="my-app",host="some\"host\\"} 1.5
+freno_test_value +Inf
+`)
+}
+
+func TestCounterVec(t *testing.T) {
+	counter := NewCounterVec("freno_test_total", "Test counter.", "app", "status_code")
+	counter.Inc("b", "200")
+	counter.Inc("a", "429")
+	counter.Add(2, "b", "200")
+
+	var buf bytes.Buffer
+	Write(&buf, counter.Family())
+	test.S(t).ExpectEquals(buf.String(), `# HELP freno_test_total Test counter.
+# TYPE freno_test_total counter
+freno_test_total{app="a",status_code="429"} 1
+freno_test_total{app="b",status_code="200"} 3
+`)
+}
+
+func TestHistogramVec(t *testing.T) {
+	histogram := NewHistogramVec("freno_test_seconds", "Test histogram.", []float64{0.1, 1}, "host")
+	histogram.Observe(0.05, "h1")
+	histogram.Observe(0.1, "h1")
+	histogram.Observe(0.5, "h1")
+	histogram.Observe(3, "h1")
+
+	var buf bytes.Buffer
+	Write(&buf, histogram.Family())
+	test.S(t).ExpectEquals(buf.String(), `# HELP freno_test_seconds Test histogram.
+# TYPE freno_test_seconds histogram
+freno_test_seconds_bucket{host="h1",le="0.1"} 2
+freno_test_seconds_bucket{host="h1",le="1"} 3
+freno_test_seconds_bucket{host="h1",le="+Inf"} 4
+freno_test_seconds_sum{host="h1"} 3.65
+freno_test_seconds_count{host="h1"} 4
+`)
+}
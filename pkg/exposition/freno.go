@@ -0,0 +1,31 @@
+package exposition
+
+// DefaultRegistry holds freno's own collectors, as listed below
+var DefaultRegistry = NewRegistry()
+
+var (
+	// CheckTotal counts checks by app, store type, store name and status code
+	CheckTotal = NewCounterVec("freno_check_total", "Number of checks.", "app", "store_type", "store_name", "status_code")
+	// ProbeTotal counts probes by store type, store name and host
+	ProbeTotal = NewCounterVec("freno_probe_total", "Number of probes.", "store_type", "store_name", "host")
+	// ProbeErrorsTotal counts failed probes by store type, store name and host
+	ProbeErrorsTotal = NewCounterVec("freno_probe_errors_total", "Number of failed probes.", "store_type", "store_name", "host")
+	// ProbeDurationSeconds measures probe latency by store type, store name and host
+	ProbeDurationSeconds = NewHistogramVec("freno_probe_duration_seconds", "Probe latency, in seconds.", DefaultBuckets, "store_type", "store_name", "host")
+)
+
+func init() {
+	DefaultRegistry.Register(CheckTotal)
+	DefaultRegistry.Register(ProbeTotal)
+	DefaultRegistry.Register(ProbeErrorsTotal)
+	DefaultRegistry.Register(ProbeDurationSeconds)
+}
+
+// ObserveProbe accounts for a single probe
+func ObserveProbe(storeType string, storeName string, host string, durationSeconds float64, err error) {
+	ProbeTotal.Inc(storeType, storeName, host)
+	if err != nil {
+		ProbeErrorsTotal.Inc(storeType, storeName, host)
+	}
+	ProbeDurationSeconds.Observe(durationSeconds, storeType, storeName, host)
+}
@@ -0,0 +1,175 @@
+package exposition
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Collector is anything able to report its current state as a family
+type Collector interface {
+	Family() *Family
+}
+
+// labelsKey identifies a label values combination within a vector
+func labelsKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// vec holds per-label-values state, common to all vector types
+type vec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mutex  sync.Mutex
+	labels map[string][]string
+}
+
+func newVec(name string, help string, labelNames []string) vec {
+	return vec{name: name, help: help, labelNames: labelNames, labels: make(map[string][]string)}
+}
+
+// keyFor returns the key for given label values, registering them if new. Caller must hold the mutex.
+func (v *vec) keyFor(labelValues []string) string {
+	key := labelsKey(labelValues)
+	if _, found := v.labels[key]; !found {
+		v.labels[key] = append([]string{}, labelValues...)
+	}
+	return key
+}
+
+// sortedKeys returns known keys, sorted for a stable output. Caller must hold the mutex.
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.labels))
+	for key := range v.labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a labeled, monotonically increasing counter
+type CounterVec struct {
+	vec
+	values map[string]float64
+}
+
+// NewCounterVec creates a new counter vector
+func NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{vec: newVec(name, help, labelNames), values: make(map[string]float64)}
+}
+
+// Inc increments the counter for given label values
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add adds given (non-negative) delta to the counter for given label values
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.values[counter.keyFor(labelValues)] += delta
+}
+
+// Family reports the counter's current state
+func (counter *CounterVec) Family() *Family {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	family := NewFamily(counter.name, counter.help, CounterType)
+	for _, key := range counter.sortedKeys() {
+		family.Add(counter.values[key], counter.labelNames, counter.labels[key]...)
+	}
+	return family
+}
+
+// HistogramVec is a labeled histogram of observations, counted in cumulative buckets
+type HistogramVec struct {
+	vec
+	buckets []float64
+	counts  map[string][]uint64 // per bucket, non-cumulative; last entry is +Inf
+	sums    map[string]float64
+}
+
+// DefaultBuckets are suitable for latencies measured in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// NewHistogramVec creates a new histogram vector. Buckets are upper bounds, in ascending order.
+func NewHistogramVec(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{
+		vec:     newVec(name, help, labelNames),
+		buckets: buckets,
+		counts:  make(map[string][]uint64),
+		sums:    make(map[string]float64),
+	}
+}
+
+// Observe adds an observation for given label values
+func (histogram *HistogramVec) Observe(value float64, labelValues ...string) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	key := histogram.keyFor(labelValues)
+	counts, found := histogram.counts[key]
+	if !found {
+		counts = make([]uint64, len(histogram.buckets)+1)
+		histogram.counts[key] = counts
+	}
+	bucket := sort.SearchFloat64s(histogram.buckets, value)
+	counts[bucket]++
+	histogram.sums[key] += value
+}
+
+// Family reports the histogram's current state
+func (histogram *HistogramVec) Family() *Family {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	family := NewFamily(histogram.name, histogram.help, HistogramType)
+	for _, key := range histogram.sortedKeys() {
+		labels := pairLabels(histogram.labelNames, histogram.labels[key])
+		cumulative := uint64(0)
+		for i, count := range histogram.counts[key] {
+			cumulative += count
+			upperBound := math.Inf(1)
+			if i < len(histogram.buckets) {
+				upperBound = histogram.buckets[i]
+			}
+			bucketLabels := append(append([]Label{}, labels...), Label{Name: "le", Value: formatValue(upperBound)})
+			family.Samples = append(family.Samples, Sample{Suffix: "_bucket", Labels: bucketLabels, Value: float64(cumulative)})
+		}
+		family.Samples = append(family.Samples, Sample{Suffix: "_sum", Labels: labels, Value: histogram.sums[key]})
+		family.Samples = append(family.Samples, Sample{Suffix: "_count", Labels: labels, Value: float64(cumulative)})
+	}
+	return family
+}
+
+// Registry is a set of collectors
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a collector to the registry
+func (registry *Registry) Register(collector Collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.collectors = append(registry.collectors, collector)
+}
+
+// Families reports the current state of all registered collectors
+func (registry *Registry) Families() (families []*Family) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for _, collector := range registry.collectors {
+		families = append(families, collector.Family())
+	}
+	return families
+}
@@ -0,0 +1,66 @@
+package group
+
+import (
+	"sync"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+)
+
+// throttleAuditLog is the raft store's append-only log of throttle operations. It is populated by the FSM, hence
+// identical on all nodes, and is persisted in snapshots. Only the most recent maxEntries entries are retained.
+type throttleAuditLog struct {
+	mutex      sync.RWMutex
+	entries    []base.ThrottleAuditEntry
+	maxEntries int
+}
+
+func newThrottleAuditLog(maxEntries int) *throttleAuditLog {
+	return &throttleAuditLog{maxEntries: maxEntries}
+}
+
+// append adds an entry, discarding the oldest entries beyond maxEntries
+func (auditLog *throttleAuditLog) append(entry base.ThrottleAuditEntry) {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+
+	auditLog.entries = append(auditLog.entries, entry)
+	auditLog.trim()
+}
+
+// trim discards the oldest entries beyond maxEntries. Callers must hold the lock.
+// The slice is resliced rather than copied; append reallocates once capacity runs out, at which point the
+// discarded head is released, so memory stays bounded at a small multiple of maxEntries.
+func (auditLog *throttleAuditLog) trim() {
+	if auditLog.maxEntries > 0 && len(auditLog.entries) > auditLog.maxEntries {
+		excess := len(auditLog.entries) - auditLog.maxEntries
+		auditLog.entries = auditLog.entries[excess:]
+	}
+}
+
+// query returns the entries of given app (all apps when empty) made at or after `since`, oldest first
+func (auditLog *throttleAuditLog) query(appName string, since time.Time) []base.ThrottleAuditEntry {
+	auditLog.mutex.RLock()
+	defer auditLog.mutex.RUnlock()
+
+	result := []base.ThrottleAuditEntry{}
+	for _, entry := range auditLog.entries {
+		if appName != "" && entry.App != appName {
+			continue
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// restore replaces the log's entries, as read from a snapshot
+func (auditLog *throttleAuditLog) restore(entries []base.ThrottleAuditEntry) {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+
+	auditLog.entries = append([]base.ThrottleAuditEntry{}, entries...)
+	auditLog.trim()
+}
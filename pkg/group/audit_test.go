@@ -0,0 +1,68 @@
+package group
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/github/freno/internal/raft"
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/throttle"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestThrottleAuditLog(t *testing.T) {
+	auditLog := newThrottleAuditLog(3)
+	now := time.Now()
+	for i, app := range []string{"archiver", "gh-ost", "archiver", "archiver"} {
+		auditLog.append(base.ThrottleAuditEntry{Time: now.Add(time.Duration(i) * time.Minute), Operation: "throttle", App: app})
+	}
+	// oldest entry discarded
+	test.S(t).ExpectEquals(len(auditLog.query("", time.Time{})), 3)
+	test.S(t).ExpectEquals(len(auditLog.query("archiver", time.Time{})), 2)
+	test.S(t).ExpectEquals(len(auditLog.query("gh-ost", time.Time{})), 1)
+	test.S(t).ExpectEquals(len(auditLog.query("", now.Add(2*time.Minute))), 2)
+	test.S(t).ExpectEquals(len(auditLog.query("no-such-app", time.Time{})), 0)
+
+	// the log stays bounded and ordered across many appends
+	for i := 0; i < 1000; i++ {
+		auditLog.append(base.ThrottleAuditEntry{Time: now.Add(time.Duration(i) * time.Second), Operation: "throttle", App: "gh-ost"})
+	}
+	entries := auditLog.query("", time.Time{})
+	test.S(t).ExpectEquals(len(entries), 3)
+	test.S(t).ExpectTrue(entries[2].Time.Equal(now.Add(999 * time.Second)))
+	test.S(t).ExpectTrue(cap(auditLog.entries) <= 8)
+
+	auditLog.restore(nil)
+	test.S(t).ExpectEquals(len(auditLog.query("", time.Time{})), 0)
+}
+
+func TestFSMAudit(t *testing.T) {
+	f := (*fsm)(NewStore("", "", throttle.NewThrottler()))
+	apply := func(c *command) {
+		b, err := json.Marshal(c)
+		test.S(t).ExpectNil(err)
+		f.Apply(&raft.Log{Data: b})
+	}
+	now := time.Now()
+	audit := &base.AuditInfo{Requester: "ops-tooling", RemoteAddr: "10.0.0.1", Reason: "backfill"}
+	apply(&command{Operation: "throttle", Key: "archiver", ExpireAt: now.Add(time.Hour), Ratio: 0.5, TTLMinutes: 60, Time: now, Audit: audit})
+	apply(&command{Operation: "unthrottle", Key: "archiver", Time: now, Audit: &base.AuditInfo{}})
+	// commands written by older versions carry no audit info
+	apply(&command{Operation: "throttle", Key: "archiver", ExpireAt: now.Add(time.Hour), Ratio: 1})
+
+	entries := f.auditLog.query("archiver", time.Time{})
+	test.S(t).ExpectEquals(len(entries), 2)
+	test.S(t).ExpectEquals(entries[0].Operation, "throttle")
+	test.S(t).ExpectEquals(entries[0].TTLMinutes, int64(60))
+	test.S(t).ExpectEquals(entries[0].Ratio, 0.5)
+	test.S(t).ExpectEquals(entries[0].AuditInfo, *audit)
+	test.S(t).ExpectTrue(entries[0].Time.Equal(now))
+	test.S(t).ExpectEquals(entries[1].Operation, "unthrottle")
+
+	// commands which fail to apply are not audited
+	test.S(t).ExpectNotNil(f.Apply(&raft.Log{Data: []byte("{not json")}))
+	apply(&command{Operation: "no-such-operation", Key: "archiver", Time: now, Audit: audit})
+	test.S(t).ExpectEquals(len(f.auditLog.query("archiver", time.Time{})), 2)
+}
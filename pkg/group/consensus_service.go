@@ -22,10 +22,23 @@ type ConsensusServiceStatus struct {
 }
 
 // ConsensusService is a freno-oriented interface for making requests that require consensus.
+// Write operations return the identity of the node which applied the change.
+// A nil throttle metadata keeps an already throttled app's metadata as is.
+// An app's throttles of different store scopes are independent; an empty scope is the app's unscoped throttle.
+// Throttle and unthrottle operations are audited, along with the given audit info.
+// App thresholds set via consensus beat those of the configuration, which AppThresholds lists as well.
 type ConsensusService interface {
-	ThrottleApp(appName string, ttlMinutes int64, expireAt time.Time, ratio float64) error
+	ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (appliedBy string, err error)
 	ThrottledAppsMap() (result map[string](*base.AppThrottle))
-	UnthrottleApp(appName string) error
+	UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (appliedBy string, err error)
+	ThrottleAudit(appName string, since time.Time) ([]base.ThrottleAuditEntry, error)
+	CreateThrottleSchedule(schedule *base.ThrottleSchedule) (appliedBy string, err error)
+	DeleteThrottleSchedule(name string) (appliedBy string, err error)
+	ThrottleSchedules() ([]base.ThrottleSchedule, error)
+	SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (appliedBy string, err error)
+	SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) (appliedBy string, err error)
+	RemoveAppThreshold(appName string, store base.AppThrottleStore) (appliedBy string, err error)
+	AppThresholds() []base.AppThreshold
 	RecentAppsMap() (result map[string](*base.RecentApp))
 
 	IsHealthy() bool
@@ -37,3 +50,11 @@ type ConsensusService interface {
 
 	Monitor()
 }
+
+// nonNilAuditInfo returns the given audit info, or an empty one. Operations are audited even when the requester is unknown.
+func nonNilAuditInfo(audit *base.AuditInfo) *base.AuditInfo {
+	if audit == nil {
+		return &base.AuditInfo{}
+	}
+	return audit
+}
@@ -0,0 +1,181 @@
+package group
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/tlsutil"
+	"github.com/outbrain/golib/log"
+)
+
+// ForwardedByHeader marks a consensus write forwarded by a follower to the leader. Its value is the
+// follower's raft identity.
+const ForwardedByHeader = "X-Freno-Forwarded-By"
+
+// ForwardPath is the leader's HTTP API path to which followers forward consensus writes
+const ForwardPath = "/raft/apply"
+
+// ForwardResponse is the leader's response to a forwarded consensus write
+type ForwardResponse struct {
+	StatusCode int
+	Message    string
+	AppliedBy  string `json:",omitempty"`
+}
+
+// CommandApplier is implemented by consensus services which accept consensus writes forwarded
+// by followers
+type CommandApplier interface {
+	ApplyForwarded(data []byte, forwardedBy string) (appliedBy string, err error)
+}
+
+var forwardClient *http.Client
+var forwardClientOnce sync.Once
+
+// getForwardClient returns an HTTP client for forwarding to the leader. When the HTTP API is served over TLS,
+// the client presents this node's certificate and verifies the leader's against the configured CA.
+func getForwardClient() *http.Client {
+	forwardClientOnce.Do(func() {
+		forwardClient = &http.Client{Timeout: raftTimeout}
+		settings := config.Settings()
+		if settings.HTTPTLSCertFile == "" {
+			return
+		}
+		reloader, err := tlsutil.NewReloader(tlsutil.Files{
+			CAFile:   settings.HTTPTLSCAFile,
+			CertFile: settings.HTTPTLSCertFile,
+			KeyFile:  settings.HTTPTLSKeyFile,
+		})
+		if err != nil {
+			log.Errorf("forwarding to leader: cannot load TLS files: %+v", err)
+			return
+		}
+		forwardClient.Transport = &http.Transport{TLSClientConfig: tlsutil.ClientConfig(reloader)}
+	})
+	return forwardClient
+}
+
+// forwardToken returns the bearer token with which forwarded writes are authorized by the leader: the first
+// of AdminTokens, if any
+func forwardToken() string {
+	for _, token := range config.Settings().AdminTokens {
+		if token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// checkForwardCredentials verifies that, under admin authorization, this node is able to forward consensus
+// writes to the leader, which authorizes them as admin requests. Forwarded writes present a token of
+// AdminTokens, or else this node's HTTP TLS certificate, whose identity must then be one of AdminIdentities.
+func checkForwardCredentials() error {
+	settings := config.Settings()
+	if !settings.AdminAuthConfigured() || forwardToken() != "" {
+		return nil
+	}
+	if settings.HTTPTLSCertFile == "" || len(settings.AdminIdentities) == 0 {
+		return fmt.Errorf("admin authorization is configured, yet forwarding writes to the leader has no credentials: set AdminTokens, or serve HTTP over TLS with a certificate whose identity is in AdminIdentities")
+	}
+	keyPair, err := tls.LoadX509KeyPair(settings.HTTPTLSCertFile, settings.HTTPTLSKeyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load HTTP TLS certificate for forwarding writes to the leader: %+v", err)
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("cannot parse HTTP TLS certificate for forwarding writes to the leader: %+v", err)
+	}
+	if !tlsutil.HasIdentity(cert, settings.AdminIdentities) {
+		return fmt.Errorf("admin authorization is configured, yet forwarding writes to the leader has no credentials: none of the HTTP TLS certificate's identities %+v is in AdminIdentities, and no AdminTokens are set", tlsutil.Identities(cert))
+	}
+	return nil
+}
+
+// leaderForwardURL returns the HTTP API URL of the given raft leader. The leader's host is that of
+// its raft identity, and its port is expected to be RaftHTTPPort, or else same as ours.
+func leaderForwardURL(leader string) (string, error) {
+	host, _, err := net.SplitHostPort(leader)
+	if err != nil {
+		return "", err
+	}
+	port := config.Settings().RaftHTTPPort
+	if port == 0 {
+		port = config.Settings().ListenPort
+	}
+	scheme := "http"
+	if config.Settings().HTTPTLSCertFile != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)), ForwardPath), nil
+}
+
+// forwardCommand sends a command to the raft leader for applying
+func (store *Store) forwardCommand(data []byte) (appliedBy string, err error) {
+	leader := store.GetLeader()
+	if leader == "" {
+		return "", fmt.Errorf("not leader, and no leader known to forward to")
+	}
+	if leader == store.raftBind {
+		return "", fmt.Errorf("not leader, yet leader is reported to be this node")
+	}
+	url, err := leaderForwardURL(leader)
+	if err != nil {
+		return "", fmt.Errorf("cannot forward to leader %s: %+v", leader, err)
+	}
+	log.Debugf("forwarding command to leader at %s", url)
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(ForwardedByHeader, store.raftBind)
+	if token := forwardToken(); token != "" {
+		// the leader authorizes forwarded writes as admin requests
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := getForwardClient().Do(request)
+	if err != nil {
+		return "", fmt.Errorf("error forwarding to leader %s: %+v", leader, err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("error forwarding to leader %s: %+v", leader, err)
+	}
+	forwardResponse := &ForwardResponse{}
+	if err := json.Unmarshal(body, forwardResponse); err != nil {
+		return "", fmt.Errorf("error forwarding to leader %s: HTTP %d: %s", leader, response.StatusCode, bytes.TrimSpace(body))
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("leader %s: %s", leader, forwardResponse.Message)
+	}
+	return forwardResponse.AppliedBy, nil
+}
+
+// ApplyForwarded applies a command forwarded by a follower. A forwarded command is never forwarded again:
+// should this node not be the leader (e.g. leadership changed meanwhile), the command is rejected.
+func (store *Store) ApplyForwarded(data []byte, forwardedBy string) (appliedBy string, err error) {
+	var c command
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", fmt.Errorf("cannot parse forwarded command: %+v", err)
+	}
+	if !store.isRaftLeader() {
+		return "", fmt.Errorf("not leader; rejecting command forwarded by %s", forwardedBy)
+	}
+	log.Debugf("applying command forwarded by %s: %+v", forwardedBy, c)
+	switch c.Operation {
+	case joinOperation, removeOperation:
+		return store.applyPeerChange(&c)
+	}
+	return store.applyCommand(data)
+}
@@ -0,0 +1,98 @@
+package group
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/config"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestLeaderForwardURL(t *testing.T) {
+	config.Settings().ListenPort = 8087
+	{
+		url, err := leaderForwardURL("freno-2.example.com:10008")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(url, "http://freno-2.example.com:8087/raft/apply")
+	}
+	{
+		config.Settings().RaftHTTPPort = 9777
+		url, err := leaderForwardURL("10.0.0.2:10008")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(url, "http://10.0.0.2:9777/raft/apply")
+		config.Settings().RaftHTTPPort = 0
+	}
+	{
+		config.Settings().HTTPTLSCertFile = "/etc/freno/tls/freno.pem"
+		url, err := leaderForwardURL("10.0.0.2:10008")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(url, "https://10.0.0.2:8087/raft/apply")
+		config.Settings().HTTPTLSCertFile = ""
+	}
+	{
+		_, err := leaderForwardURL("freno-2.example.com")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+// writeTestCert writes a self signed certificate for given common name, and its key, into dir
+func writeTestCert(t *testing.T, dir string, commonName string) (certFile string, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.S(t).ExpectNil(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	test.S(t).ExpectNil(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	test.S(t).ExpectNil(err)
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	test.S(t).ExpectNil(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	test.S(t).ExpectNil(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestCheckForwardCredentials(t *testing.T) {
+	settings := config.Settings()
+	defer func() {
+		settings.AdminTokens = nil
+		settings.AdminIdentities = nil
+		settings.HTTPTLSCertFile = ""
+		settings.HTTPTLSKeyFile = ""
+	}()
+	dir, err := ioutil.TempDir("", "freno-forward")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCert(t, dir, "freno-1.example.com")
+
+	// no admin authorization
+	test.S(t).ExpectNil(checkForwardCredentials())
+
+	settings.AdminTokens = []string{"", "s3cret"}
+	test.S(t).ExpectNil(checkForwardCredentials())
+	test.S(t).ExpectEquals(forwardToken(), "s3cret")
+
+	settings.AdminTokens = []string{""}
+	settings.AdminIdentities = []string{"ops-tooling"}
+	test.S(t).ExpectNotNil(checkForwardCredentials())
+
+	settings.HTTPTLSCertFile, settings.HTTPTLSKeyFile = certFile, keyFile
+	test.S(t).ExpectNotNil(checkForwardCredentials())
+
+	settings.AdminIdentities = []string{"ops-tooling", "freno-1.example.com"}
+	test.S(t).ExpectNil(checkForwardCredentials())
+}
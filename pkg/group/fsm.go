@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/github/freno/internal/raft"
+	"github.com/github/freno/pkg/base"
 	"github.com/outbrain/golib/log"
 )
 
@@ -17,17 +18,41 @@ type fsm Store
 func (f *fsm) Apply(l *raft.Log) interface{} {
 	var c command
 	if err := json.Unmarshal(l.Data, &c); err != nil {
-		log.Errorf("failed to unmarshal command: %s", err.Error())
+		return log.Errorf("failed to unmarshal command: %s", err.Error())
 	}
 
 	log.Debugf("freno/raft: applying command: %+v", c)
+	if err := f.apply(&c); err != nil {
+		return err
+	}
+	// only commands which applied are audited
+	f.audit(&c)
+	return nil
+}
+
+// apply dispatches a command onto the freno state
+func (f *fsm) apply(c *command) error {
 	switch c.Operation {
 	case "throttle":
-		return f.applyThrottleApp(c.Key, c.ExpireAt, c.Ratio)
+		f.throttler.ThrottleAppOnStore(c.Key, c.store(), c.ExpireAt, c.Ratio, c.Metadata)
 	case "unthrottle":
-		return f.applyUnthrottleApp(c.Key)
+		f.throttler.UnthrottleAppOnStore(c.Key, c.store())
+	case "schedule":
+		if c.Schedule != nil {
+			f.schedules.set(*c.Schedule)
+		}
+	case "unschedule":
+		f.schedules.delete(c.Key)
+	case "schedule-applied":
+		f.schedules.setAppliedWindows(c.Key, c.AppliedWindows)
+	case "threshold":
+		f.throttler.SetAppThreshold(c.Key, c.store(), c.Threshold)
+	case "unthreshold":
+		f.throttler.RemoveAppThreshold(c.Key, c.store())
+	default:
+		return log.Errorf("unrecognized command operation: %s", c.Operation)
 	}
-	return log.Errorf("unrecognized command operation: %s", c.Operation)
+	return nil
 }
 
 // Snapshot returns a snapshot object of freno's state
@@ -36,7 +61,17 @@ func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
 	snapshot := newFsmSnapshot()
 
 	for appName, appThrottle := range f.throttler.ThrottledAppsMap() {
-		snapshot.data.throttledApps[appName] = *appThrottle
+		snapshot.data.ThrottledApps[appName] = *appThrottle
+	}
+	snapshot.data.ThrottleAudit = f.auditLog.query("", time.Time{})
+	for _, schedule := range f.schedules.list() {
+		snapshot.data.ThrottleSchedules[schedule.Name] = schedule
+	}
+	for _, appThreshold := range f.throttler.AppThresholds() {
+		// configured thresholds are not consensus state
+		if !appThreshold.Configured {
+			snapshot.data.AppThresholds = append(snapshot.data.AppThresholds, appThreshold)
+		}
 	}
 	return snapshot, nil
 }
@@ -45,25 +80,29 @@ func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
 func (f *fsm) Restore(rc io.ReadCloser) error {
 	defer rc.Close()
 
-	data := newSnapshotData()
-	if err := json.NewDecoder(rc).Decode(&data); err != nil {
+	data, err := readSnapshotData(rc)
+	if err != nil {
 		return err
 	}
-	for appName, appThrottle := range data.throttledApps {
-		f.throttler.ThrottleApp(appName, appThrottle.ExpireAt, appThrottle.Ratio)
-	}
-	log.Debugf("freno/raft: restored from snapshot: %d elements restored", len(data.throttledApps))
+	// Restoring discards the current state: throttles removed since the snapshot must not survive
+	f.throttler.ReplaceThrottledApps(data.ThrottledApps)
+	f.auditLog.restore(data.ThrottleAudit)
+	f.schedules.restore(data.ThrottleSchedules)
+	f.throttler.ReplaceAppThresholds(data.AppThresholds)
+	log.Debugf("freno/raft: restored from snapshot version %d: %d elements restored", data.Version, len(data.ThrottledApps))
 	return nil
 }
 
-// applyThrottleApp will apply a "throttle" command locally (this applies as result of the raft consensus algorithm)
-func (f *fsm) applyThrottleApp(appName string, expireAt time.Time, ratio float64) interface{} {
-	f.throttler.ThrottleApp(appName, expireAt, ratio)
-	return nil
-}
-
-// applyThrottleApp will apply a "unthrottle" command locally (this applies as result of the raft consensus algorithm)
-func (f *fsm) applyUnthrottleApp(appName string) interface{} {
-	f.throttler.UnthrottleApp(appName)
-	return nil
+// audit records a throttle or unthrottle command in the audit log. Commands carrying no audit info were written
+// by older versions, and are not recorded.
+func (f *fsm) audit(c *command) {
+	if c.Audit == nil {
+		return
+	}
+	switch c.Operation {
+	case "throttle":
+		f.auditLog.append(base.ThrottleAuditEntry{Time: c.Time, Operation: c.Operation, App: c.Key, AppThrottleStore: c.store(), TTLMinutes: c.TTLMinutes, Ratio: c.Ratio, AuditInfo: *c.Audit})
+	case "unthrottle":
+		f.auditLog.append(base.ThrottleAuditEntry{Time: c.Time, Operation: c.Operation, App: c.Key, AppThrottleStore: c.store(), AuditInfo: *c.Audit})
+	}
 }
@@ -2,32 +2,63 @@ package group
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/github/freno/pkg/base"
 
 	"github.com/github/freno/internal/raft"
 )
 
+// snapshotVersion is the version of the snapshot format written by this code.
+// Version 0 stands for legacy snapshots, which were written without a version.
+const snapshotVersion = 1
+
 // snapshotData holds whatever data we wish to persist as part of raft snapshotting
 // it will mostly duplicate data stored in `throttler`.
+// Any future FSM state should be added here as a new field; older snapshots would
+// simply restore it as empty.
 type snapshotData struct {
-	throttledApps map[string](base.AppThrottle)
+	Version       int                           `json:"version"`
+	ThrottledApps map[string](base.AppThrottle) `json:"throttledApps"`
+	ThrottleAudit []base.ThrottleAuditEntry     `json:"throttleAudit,omitempty"`
+
+	ThrottleSchedules map[string](base.ThrottleSchedule) `json:"throttleSchedules,omitempty"`
+	AppThresholds     []base.AppThreshold                `json:"appThresholds,omitempty"`
 }
 
 func newSnapshotData() *snapshotData {
 	return &snapshotData{
-		throttledApps: make(map[string](base.AppThrottle)),
+		Version:           snapshotVersion,
+		ThrottledApps:     make(map[string](base.AppThrottle)),
+		ThrottleSchedules: make(map[string](base.ThrottleSchedule)),
+	}
+}
+
+// readSnapshotData decodes snapshot data as persisted by fsmSnapshot. It accepts both
+// versioned and legacy (unversioned) snapshots, and refuses snapshots written by a newer format.
+func readSnapshotData(r io.Reader) (*snapshotData, error) {
+	data := &snapshotData{}
+	if err := json.NewDecoder(r).Decode(data); err != nil {
+		return nil, err
+	}
+	if data.Version > snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d (max supported: %d)", data.Version, snapshotVersion)
+	}
+	if data.ThrottledApps == nil {
+		data.ThrottledApps = make(map[string](base.AppThrottle))
 	}
+	return data, nil
 }
 
 // fsmSnapshot handles raft persisting of snapshots
 type fsmSnapshot struct {
-	data snapshotData
+	data *snapshotData
 }
 
 func newFsmSnapshot() *fsmSnapshot {
 	return &fsmSnapshot{
-		data: *newSnapshotData(),
+		data: newSnapshotData(),
 	}
 }
 
@@ -0,0 +1,106 @@
+package group
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/internal/raft"
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/throttle"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestSnapshotPersistRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-snapshot")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	expireAt := time.Now().Add(time.Hour).Round(time.Second)
+	source := (*fsm)(NewStore(dir, "", throttle.NewThrottler()))
+	source.throttler.ThrottleAppWithMetadata("archiver", expireAt, 0.5, &base.AppThrottleMetadata{Reason: "backfill", Owner: "data-team"})
+	source.throttler.ThrottleAppOnStore("gh-ost", base.AppThrottleStore{StoreType: "mysql", StoreName: "main7"}, expireAt, 1, nil)
+	source.throttler.SetAppThreshold("archiver", base.AppThrottleStore{StoreType: "mysql", StoreName: "main7"}, 0.5)
+	source.auditLog.append(base.ThrottleAuditEntry{Time: expireAt, Operation: "throttle", App: "archiver", Ratio: 0.5})
+
+	snapshot, err := source.Snapshot()
+	test.S(t).ExpectNil(err)
+	snapshots, err := raft.NewFileSnapshotStore(dir, retainSnapshotCount, ioutil.Discard)
+	test.S(t).ExpectNil(err)
+	sink, err := snapshots.Create(1, 1, nil)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNil(snapshot.Persist(sink))
+
+	inspections, err := InspectSnapshots(dir)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(inspections), 1)
+	test.S(t).ExpectEquals(inspections[0].Error, "")
+	test.S(t).ExpectEquals(inspections[0].Data.Version, snapshotVersion)
+	test.S(t).ExpectEquals(inspections[0].Data.ThrottledApps["archiver"].Ratio, 0.5)
+
+	_, rc, err := snapshots.Open(inspections[0].ID)
+	test.S(t).ExpectNil(err)
+	target := (*fsm)(NewStore(dir, "", throttle.NewThrottler()))
+	// state not in the snapshot is discarded
+	target.throttler.ThrottleApp("migration", expireAt, 1)
+	test.S(t).ExpectNil(target.Restore(rc))
+	_, ok := target.throttler.ThrottledAppsMap()["migration"]
+	test.S(t).ExpectFalse(ok)
+
+	appThrottle, ok := target.throttler.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.5)
+	test.S(t).ExpectTrue(appThrottle.ExpireAt.Equal(expireAt))
+	test.S(t).ExpectEquals(appThrottle.Reason, "backfill")
+	test.S(t).ExpectEquals(appThrottle.Owner, "data-team")
+	appThrottle, ok = target.throttler.ThrottledAppsMap()["gh-ost@mysql/main7"]
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(appThrottle.StoreName, "main7")
+	_, ok = target.throttler.ThrottledAppsMap()["gh-ost"]
+	test.S(t).ExpectFalse(ok)
+	test.S(t).ExpectEquals(len(target.auditLog.query("archiver", time.Time{})), 1)
+	appThresholds := target.throttler.AppThresholds()
+	test.S(t).ExpectEquals(len(appThresholds), 1)
+	test.S(t).ExpectEquals(appThresholds[0].Key(), "archiver@mysql/main7")
+	test.S(t).ExpectEquals(appThresholds[0].Threshold, 0.5)
+}
+
+func TestInspectSnapshotsReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-snapshot")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	_, err = InspectSnapshots(dir)
+	test.S(t).ExpectNotNil(err)
+	_, err = os.Stat(filepath.Join(dir, "snapshots"))
+	test.S(t).ExpectTrue(os.IsNotExist(err))
+}
+
+func TestReadSnapshotData(t *testing.T) {
+	{
+		// legacy snapshots persisted an empty object
+		data, err := readSnapshotData(strings.NewReader(`{}`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(data.Version, 0)
+		test.S(t).ExpectEquals(len(data.ThrottledApps), 0)
+	}
+	{
+		data, err := readSnapshotData(strings.NewReader(`{"version":1,"throttledApps":{"archiver":{"ExpireAt":"2030-01-01T00:00:00Z","Ratio":1}}}`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(data.Version, 1)
+		test.S(t).ExpectEquals(len(data.ThrottledApps), 1)
+		test.S(t).ExpectEquals(data.ThrottledApps["archiver"].Ratio, 1.0)
+	}
+	{
+		_, err := readSnapshotData(strings.NewReader(`{"version":999}`))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := readSnapshotData(strings.NewReader(`}{`))
+		test.S(t).ExpectNotNil(err)
+	}
+}
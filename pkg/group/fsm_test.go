@@ -0,0 +1,86 @@
+package group
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/internal/raft"
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/throttle"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestFSMStoreScopedThrottle(t *testing.T) {
+	f := (*fsm)(NewStore("", "", throttle.NewThrottler()))
+	apply := func(data string) {
+		f.Apply(&raft.Log{Data: []byte(data)})
+	}
+	expireAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	// an unscoped command, as encoded by older versions
+	apply(`{"op":"throttle","key":"gh-ost","expire":"` + expireAt + `","ratio":0.5}`)
+	apply(`{"op":"throttle","key":"gh-ost","expire":"` + expireAt + `","ratio":1,"store":{"StoreType":"mysql","StoreName":"main7"}}`)
+
+	throttledApps := f.throttler.ThrottledAppsMap()
+	test.S(t).ExpectEquals(len(throttledApps), 3) // along with "abusing-app"
+	test.S(t).ExpectEquals(throttledApps["gh-ost"].Ratio, 0.5)
+	test.S(t).ExpectEquals(throttledApps["gh-ost@mysql/main7"].Ratio, 1.0)
+	test.S(t).ExpectEquals(throttledApps["gh-ost@mysql/main7"].StoreType, "mysql")
+
+	// unthrottling a scope keeps the app's other throttles
+	apply(`{"op":"unthrottle","key":"gh-ost","store":{"StoreType":"mysql","StoreName":"main7"}}`)
+	throttledApps = f.throttler.ThrottledAppsMap()
+	test.S(t).ExpectEquals(len(throttledApps), 2)
+	test.S(t).ExpectEquals(throttledApps["gh-ost"].Ratio, 0.5)
+}
+
+func TestFSMAppThreshold(t *testing.T) {
+	f := (*fsm)(NewStore("", "", throttle.NewThrottler()))
+	apply := func(data string) {
+		f.Apply(&raft.Log{Data: []byte(data)})
+	}
+	main7 := base.AppThrottleStore{StoreType: "mysql", StoreName: "main7"}
+	apply(`{"op":"threshold","key":"prefix:archiver","threshold":0.5,"store":{"StoreType":"mysql","StoreName":"main7"}}`)
+	test.S(t).ExpectEquals(f.throttler.ResolveAppThreshold("archiver:daily", main7).Threshold, 0.5)
+	test.S(t).ExpectTrue(f.throttler.ResolveAppThreshold("archiver:daily", base.AppThrottleStore{StoreType: "mysql", StoreName: "main1"}) == nil)
+
+	apply(`{"op":"unthreshold","key":"prefix:archiver","store":{"StoreType":"mysql","StoreName":"main7"}}`)
+	test.S(t).ExpectTrue(f.throttler.ResolveAppThreshold("archiver:daily", main7) == nil)
+	test.S(t).ExpectEquals(len(f.throttler.AppThresholds()), 0)
+}
+
+func TestFSMScheduleAppliedWindows(t *testing.T) {
+	f := (*fsm)(NewStore("", "", throttle.NewThrottler()))
+	apply := func(data string) {
+		f.Apply(&raft.Log{Data: []byte(data)})
+	}
+	apply(`{"op":"schedule","key":"peak","schedule":{"Name":"peak","Cron":"0 9 * * *","Duration":"1h","Apps":["archiver"],"Ratio":1}}`)
+	apply(`{"op":"schedule-applied","key":"peak","appliedWindows":{"archiver":"2024-01-01T09:00:00Z"}}`)
+	// a schedule which no longer exists is not recreated
+	apply(`{"op":"schedule-applied","key":"deleted","appliedWindows":{"archiver":"2024-01-01T09:00:00Z"}}`)
+
+	schedules := f.schedules.list()
+	test.S(t).ExpectEquals(len(schedules), 1)
+	test.S(t).ExpectTrue(schedules[0].AppliedWindows["archiver"].Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	// replacing a schedule resets its applied windows
+	apply(`{"op":"schedule","key":"peak","schedule":{"Name":"peak","Cron":"0 10 * * *","Duration":"1h","Apps":["archiver"],"Ratio":1}}`)
+	test.S(t).ExpectEquals(len(f.schedules.list()[0].AppliedWindows), 0)
+}
+
+func TestCommandStoreEncoding(t *testing.T) {
+	{
+		b, err := json.Marshal(&command{Operation: "throttle", Key: "gh-ost", Store: commandStore(base.AppThrottleStore{})})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(strings.Contains(string(b), "store"))
+	}
+	{
+		b, err := json.Marshal(&command{Operation: "throttle", Key: "gh-ost", Store: commandStore(base.AppThrottleStore{StoreType: "mysql"})})
+		test.S(t).ExpectNil(err)
+		c := &command{}
+		test.S(t).ExpectNil(json.Unmarshal(b, c))
+		test.S(t).ExpectEquals(c.store(), base.AppThrottleStore{StoreType: "mysql"})
+	}
+}
@@ -26,14 +26,92 @@ CREATE TABLE throttled_apps (
 	throttled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   expires_at TIMESTAMP NOT NULL,
 	ratio DOUBLE,
-  PRIMARY KEY (app_name)
+  reason varchar(1024) NOT NULL DEFAULT '',
+  owner varchar(128) NOT NULL DEFAULT '',
+  origin varchar(160) NOT NULL DEFAULT '',
+  store_type varchar(64) NOT NULL DEFAULT '',
+  store_name varchar(128) NOT NULL DEFAULT '',
+  PRIMARY KEY (app_name, store_type, store_name)
 );
+
+CREATE TABLE throttle_schedules (
+  name varchar(128) NOT NULL,
+  cron varchar(128) NOT NULL,
+  duration varchar(32) NOT NULL,
+  apps text NOT NULL,
+  ratio DOUBLE NOT NULL,
+  reason varchar(1024) NOT NULL DEFAULT '',
+  owner varchar(128) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  applied_windows text,
+  PRIMARY KEY (name)
+);
+
+CREATE TABLE app_thresholds (
+  app_name varchar(128) NOT NULL,
+  store_type varchar(64) NOT NULL,
+  store_name varchar(128) NOT NULL,
+  threshold DOUBLE NOT NULL,
+  PRIMARY KEY (app_name, store_type, store_name)
+);
+
+CREATE TABLE throttle_audit (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  audited_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  operation varchar(32) NOT NULL,
+  app_name varchar(128) NOT NULL,
+  store_type varchar(64) NOT NULL DEFAULT '',
+  store_name varchar(128) NOT NULL DEFAULT '',
+  ttl_minutes bigint NOT NULL DEFAULT 0,
+  ratio DOUBLE,
+  requester varchar(256) NOT NULL DEFAULT '',
+  remote_addr varchar(128) NOT NULL DEFAULT '',
+  forwarded_for varchar(1024) NOT NULL DEFAULT '',
+  reason varchar(1024) NOT NULL DEFAULT '',
+  service_id varchar(128) NOT NULL DEFAULT '',
+  PRIMARY KEY (id),
+  KEY app_audited_idx (app_name, audited_at),
+  KEY audited_at_idx (audited_at)
+);
+
+-- upgrading from a schema without reason/owner:
+ALTER TABLE throttled_apps
+  ADD COLUMN reason varchar(1024) NOT NULL DEFAULT '',
+  ADD COLUMN owner varchar(128) NOT NULL DEFAULT '';
+
+-- upgrading from a schema without origin:
+ALTER TABLE throttled_apps
+  ADD COLUMN origin varchar(160) NOT NULL DEFAULT '';
+
+-- upgrading from a schema without store scoped throttles:
+ALTER TABLE throttled_apps
+  ADD COLUMN store_type varchar(64) NOT NULL DEFAULT '',
+  ADD COLUMN store_name varchar(128) NOT NULL DEFAULT '',
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (app_name, store_type, store_name);
+-- or, if throttled_apps already has store_type and store_name but is keyed by app_name alone:
+ALTER TABLE throttled_apps
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (app_name, store_type, store_name);
+UPDATE throttled_apps SET app_name = SUBSTRING_INDEX(app_name, '@', 1) WHERE store_type != '';
+ALTER TABLE throttle_audit
+  ADD COLUMN store_type varchar(64) NOT NULL DEFAULT '' AFTER app_name,
+  ADD COLUMN store_name varchar(128) NOT NULL DEFAULT '' AFTER store_type;
+
+-- upgrading from a schema without applied_windows:
+ALTER TABLE throttle_schedules
+  ADD COLUMN applied_windows text;
+
+-- upgrading from a schema without forwarded_for:
+ALTER TABLE throttle_audit
+  ADD COLUMN forwarded_for varchar(1024) NOT NULL DEFAULT '' AFTER remote_addr;
 */
 
 package group
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -136,6 +214,7 @@ func (backend *MySQLBackend) continuousOperations() {
 		case <-stateTicker.C:
 			{
 				backend.readThrottledApps()
+				backend.readAppThresholds()
 			}
 		}
 	}
@@ -145,6 +224,7 @@ func (backend *MySQLBackend) onLeaderStateChange(newLeaderState int64) error {
 	if newLeaderState > 0 {
 		log.Infof("Transitioned into leader state")
 		backend.readThrottledApps()
+		backend.readAppThresholds()
 	} else {
 		log.Infof("Transitioned out of leader state")
 	}
@@ -324,69 +404,309 @@ func (backend *MySQLBackend) readThrottledApps() error {
 		select
 			app_name,
 			timestampdiff(second, now(), expires_at) as ttl_seconds,
-			ratio
+			ratio,
+			reason,
+			owner,
+			origin,
+			store_type,
+			store_name
 		from
 			throttled_apps
 	`
 
 	err := sqlutils.QueryRowsMap(backend.db, query, func(m sqlutils.RowMap) error {
+		store := base.AppThrottleStore{StoreType: m.GetString("store_type"), StoreName: m.GetString("store_name")}
 		appName := m.GetString("app_name")
 		ttlSeconds := m.GetInt64("ttl_seconds")
 		ratio, _ := strconv.ParseFloat(m.GetString("ratio"), 64)
 		expiresAt := time.Now().Add(time.Duration(ttlSeconds) * time.Second)
+		metadata := &base.AppThrottleMetadata{Reason: m.GetString("reason"), Owner: m.GetString("owner"), Origin: m.GetString("origin")}
 
-		go log.Debugf("read-throttled-apps: app=%s, ttlSeconds%+v, expiresAt=%+v, ratio=%+v", appName, ttlSeconds, expiresAt, ratio)
-		go backend.throttler.ThrottleApp(appName, expiresAt, ratio)
+		go log.Debugf("read-throttled-apps: app=%s, store=%s, ttlSeconds%+v, expiresAt=%+v, ratio=%+v", appName, store, ttlSeconds, expiresAt, ratio)
+		go backend.throttler.ThrottleAppOnStore(appName, store, expiresAt, ratio, metadata)
 		return nil
 	})
 
 	return err
 }
 
-func (backend *MySQLBackend) ThrottleApp(appName string, ttlMinutes int64, expireAt time.Time, ratio float64) error {
-	log.Debugf("throttle-app: app=%s, ttlMinutes=%+v, expireAt=%+v, ratio=%+v", appName, ttlMinutes, expireAt, ratio)
+func (backend *MySQLBackend) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (appliedBy string, err error) {
+	log.Debugf("throttle-app: app=%s, store=%s, ttlMinutes=%+v, expireAt=%+v, ratio=%+v, metadata=%+v", appName, store, ttlMinutes, expireAt, ratio, metadata)
+	var reason, owner, origin string
+	// A nil metadata keeps an existing row's reason/owner/origin
+	updateMetadata := ""
+	if metadata != nil {
+		reason, owner, origin = metadata.Reason, metadata.Owner, metadata.Origin
+		updateMetadata = ", reason=values(reason), owner=values(owner), origin=values(origin)"
+	}
 	var query string
 	var args []interface{}
 	if ttlMinutes > 0 {
 		query = `
-	    replace into throttled_apps (
-	        app_name, throttled_at, expires_at, ratio
+	    insert into throttled_apps (
+	        app_name, throttled_at, expires_at, ratio, reason, owner, origin, store_type, store_name
 	      ) values (
-	        ?, now(), now() + interval ? minute, ?
+	        ?, now(), now() + interval ? minute, ?, ?, ?, ?, ?, ?
 	      )
-	  `
-		args = sqlutils.Args(appName, ttlMinutes, ratio)
+			on duplicate key update
+				throttled_at=values(throttled_at), expires_at=values(expires_at), ratio=values(ratio)` + updateMetadata
+		args = sqlutils.Args(appName, ttlMinutes, ratio, reason, owner, origin, store.StoreType, store.StoreName)
 	} else {
 		// TTL=0 ; if app is already throttled, keep existing TTL and only update ratio.
 		// if app does not exist use DefaultThrottleTTL
 		query = `
 	    insert into throttled_apps (
-	        app_name, throttled_at, expires_at, ratio
+	        app_name, throttled_at, expires_at, ratio, reason, owner, origin, store_type, store_name
 	      ) values (
-	        ?, now(), now() + interval ? minute, ?
+	        ?, now(), now() + interval ? minute, ?, ?, ?, ?, ?, ?
 	      )
 			on duplicate key update
-				ratio=values(ratio)
-		`
-		args = sqlutils.Args(appName, throttle.DefaultThrottleTTLMinutes, ratio)
+				ratio=values(ratio)` + updateMetadata
+		args = sqlutils.Args(appName, throttle.DefaultThrottleTTLMinutes, ratio, reason, owner, origin, store.StoreType, store.StoreName)
 	}
-	_, err := sqlutils.ExecNoPrepare(backend.db, query, args...)
-	backend.throttler.ThrottleApp(appName, expireAt, ratio)
-	return err
+	_, err = sqlutils.ExecNoPrepare(backend.db, query, args...)
+	backend.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	if err == nil {
+		backend.audit("throttle", appName, store, ttlMinutes, ratio, audit)
+	}
+	return backend.serviceId, err
 }
 
 func (backend *MySQLBackend) ThrottledAppsMap() (result map[string](*base.AppThrottle)) {
 	return backend.throttler.ThrottledAppsMap()
 }
 
-func (backend *MySQLBackend) UnthrottleApp(appName string) error {
-	backend.throttler.UnthrottleApp(appName)
+func (backend *MySQLBackend) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (appliedBy string, err error) {
+	backend.throttler.UnthrottleAppOnStore(appName, store)
 	query := `
-    update throttled_apps set expires_at=now() where app_name=?
+    update throttled_apps set expires_at=now() where app_name=? and store_type=? and store_name=?
   `
-	args := sqlutils.Args(appName)
-	_, err := sqlutils.ExecNoPrepare(backend.db, query, args...)
-	return err
+	args := sqlutils.Args(appName, store.StoreType, store.StoreName)
+	_, err = sqlutils.ExecNoPrepare(backend.db, query, args...)
+	if err == nil {
+		backend.audit("unthrottle", appName, store, 0, 0, audit)
+	}
+	return backend.serviceId, err
+}
+
+// audit records a throttle operation in the throttle_audit table. Failing to audit does not fail the operation.
+func (backend *MySQLBackend) audit(operation string, appName string, store base.AppThrottleStore, ttlMinutes int64, ratio float64, audit *base.AuditInfo) {
+	audit = nonNilAuditInfo(audit)
+	query := `
+		insert into throttle_audit (
+				audited_at, operation, app_name, store_type, store_name, ttl_minutes, ratio, requester, remote_addr, forwarded_for, reason, service_id
+			) values (
+				now(6), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			)
+	`
+	args := sqlutils.Args(operation, appName, store.StoreType, store.StoreName, ttlMinutes, ratio, audit.Requester, audit.RemoteAddr, audit.ForwardedFor, audit.Reason, backend.serviceId)
+	if _, err := sqlutils.ExecNoPrepare(backend.db, query, args...); err != nil {
+		log.Errorf("throttle-audit: failed auditing %s of %s: %+v", operation, appName, err)
+	}
+}
+
+// ThrottleAudit returns the audited throttle operations of given app (all apps when empty), made at or after `since`.
+// At most AuditLogMaxEntries, most recent, entries are returned.
+func (backend *MySQLBackend) ThrottleAudit(appName string, since time.Time) (entries []base.ThrottleAuditEntry, err error) {
+	// Timestamps are computed relative to the backend's clock, as with throttled_apps
+	query := `
+		select
+			timestampdiff(microsecond, audited_at, now(6)) as age_microseconds,
+			operation,
+			app_name,
+			store_type,
+			store_name,
+			ttl_minutes,
+			ratio,
+			requester,
+			remote_addr,
+			forwarded_for,
+			reason
+		from
+			throttle_audit
+		where
+			(app_name = ? or ? = '')
+			and (? or audited_at >= now(6) - interval ? microsecond)
+		order by
+			id desc
+		limit ?
+	`
+	sinceMicroseconds := time.Since(since).Microseconds()
+	args := sqlutils.Args(appName, appName, since.IsZero(), sinceMicroseconds, config.Settings().AuditLogMaxEntries)
+	now := time.Now()
+	entries = []base.ThrottleAuditEntry{}
+	err = sqlutils.QueryRowsMap(backend.db, query, func(m sqlutils.RowMap) error {
+		ratio, _ := strconv.ParseFloat(m.GetString("ratio"), 64)
+		entry := base.ThrottleAuditEntry{
+			Time:      now.Add(-time.Duration(m.GetInt64("age_microseconds")) * time.Microsecond),
+			Operation: m.GetString("operation"),
+			App:       m.GetString("app_name"),
+			AppThrottleStore: base.AppThrottleStore{
+				StoreType: m.GetString("store_type"),
+				StoreName: m.GetString("store_name"),
+			},
+			TTLMinutes: m.GetInt64("ttl_minutes"),
+			Ratio:      ratio,
+			AuditInfo: base.AuditInfo{
+				Requester:    m.GetString("requester"),
+				RemoteAddr:   m.GetString("remote_addr"),
+				ForwardedFor: m.GetString("forwarded_for"),
+				Reason:       m.GetString("reason"),
+			},
+		}
+		entries = append(entries, entry)
+		return nil
+	}, args...)
+	// list oldest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, err
+}
+
+// CreateThrottleSchedule stores a throttle schedule, replacing a schedule of the same name
+func (backend *MySQLBackend) CreateThrottleSchedule(schedule *base.ThrottleSchedule) (appliedBy string, err error) {
+	apps, err := json.Marshal(schedule.Apps)
+	if err != nil {
+		return "", err
+	}
+	query := `
+		replace into throttle_schedules (
+				name, cron, duration, apps, ratio, reason, owner, created_at
+			) values (
+				?, ?, ?, ?, ?, ?, ?, now()
+			)
+	`
+	args := sqlutils.Args(schedule.Name, schedule.Cron, schedule.Duration, string(apps), schedule.Ratio, schedule.Reason, schedule.Owner)
+	_, err = sqlutils.ExecNoPrepare(backend.db, query, args...)
+	return backend.serviceId, err
+}
+
+// SetThrottleScheduleAppliedWindows records the apps a schedule throttled, such that a new leader does not throttle them again
+func (backend *MySQLBackend) SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (appliedBy string, err error) {
+	b, err := json.Marshal(appliedWindows)
+	if err != nil {
+		return "", err
+	}
+	query := `
+		update throttle_schedules set applied_windows=? where name=?
+	`
+	_, err = sqlutils.ExecNoPrepare(backend.db, query, sqlutils.Args(string(b), name)...)
+	return backend.serviceId, err
+}
+
+// DeleteThrottleSchedule deletes a throttle schedule
+func (backend *MySQLBackend) DeleteThrottleSchedule(name string) (appliedBy string, err error) {
+	query := `
+		delete from throttle_schedules where name=?
+	`
+	_, err = sqlutils.ExecNoPrepare(backend.db, query, sqlutils.Args(name)...)
+	return backend.serviceId, err
+}
+
+// ThrottleSchedules returns all throttle schedules, sorted by name
+func (backend *MySQLBackend) ThrottleSchedules() (schedules []base.ThrottleSchedule, err error) {
+	query := `
+		select
+			name,
+			cron,
+			duration,
+			apps,
+			ratio,
+			reason,
+			owner,
+			timestampdiff(second, created_at, now()) as age_seconds,
+			ifnull(applied_windows, '') as applied_windows
+		from
+			throttle_schedules
+		order by
+			name
+	`
+	now := time.Now()
+	schedules = []base.ThrottleSchedule{}
+	err = sqlutils.QueryRowsMap(backend.db, query, func(m sqlutils.RowMap) error {
+		schedule := base.ThrottleSchedule{
+			Name:      m.GetString("name"),
+			Cron:      m.GetString("cron"),
+			Duration:  m.GetString("duration"),
+			Reason:    m.GetString("reason"),
+			Owner:     m.GetString("owner"),
+			CreatedAt: now.Add(-time.Duration(m.GetInt64("age_seconds")) * time.Second),
+		}
+		schedule.Ratio, _ = strconv.ParseFloat(m.GetString("ratio"), 64)
+		if err := json.Unmarshal([]byte(m.GetString("apps")), &schedule.Apps); err != nil {
+			return fmt.Errorf("schedule %s: cannot parse apps: %+v", schedule.Name, err)
+		}
+		if appliedWindows := m.GetString("applied_windows"); appliedWindows != "" {
+			if err := json.Unmarshal([]byte(appliedWindows), &schedule.AppliedWindows); err != nil {
+				return fmt.Errorf("schedule %s: cannot parse applied windows: %+v", schedule.Name, err)
+			}
+		}
+		schedules = append(schedules, schedule)
+		return nil
+	})
+	return schedules, err
+}
+
+// readAppThresholds replaces the throttler's runtime app thresholds with those of the app_thresholds table
+func (backend *MySQLBackend) readAppThresholds() error {
+	query := `
+		select
+			app_name,
+			store_type,
+			store_name,
+			threshold
+		from
+			app_thresholds
+	`
+	appThresholds := []base.AppThreshold{}
+	err := sqlutils.QueryRowsMap(backend.db, query, func(m sqlutils.RowMap) error {
+		appThreshold := base.AppThreshold{
+			App:              m.GetString("app_name"),
+			AppThrottleStore: base.AppThrottleStore{StoreType: m.GetString("store_type"), StoreName: m.GetString("store_name")},
+		}
+		appThreshold.Threshold, _ = strconv.ParseFloat(m.GetString("threshold"), 64)
+		appThresholds = append(appThresholds, appThreshold)
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	backend.throttler.ReplaceAppThresholds(appThresholds)
+	return nil
+}
+
+// SetAppThreshold stores an app threshold, replacing one of the same app and store
+func (backend *MySQLBackend) SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) (appliedBy string, err error) {
+	query := `
+		replace into app_thresholds (
+				app_name, store_type, store_name, threshold
+			) values (
+				?, ?, ?, ?
+			)
+	`
+	args := sqlutils.Args(appName, store.StoreType, store.StoreName, threshold)
+	if _, err = sqlutils.ExecNoPrepare(backend.db, query, args...); err == nil {
+		backend.throttler.SetAppThreshold(appName, store, threshold)
+	}
+	return backend.serviceId, err
+}
+
+// RemoveAppThreshold deletes an app threshold
+func (backend *MySQLBackend) RemoveAppThreshold(appName string, store base.AppThrottleStore) (appliedBy string, err error) {
+	query := `
+		delete from app_thresholds where app_name=? and store_type=? and store_name=?
+	`
+	args := sqlutils.Args(appName, store.StoreType, store.StoreName)
+	if _, err = sqlutils.ExecNoPrepare(backend.db, query, args...); err == nil {
+		backend.throttler.RemoveAppThreshold(appName, store)
+	}
+	return backend.serviceId, err
+}
+
+// AppThresholds returns the app thresholds of the app_thresholds table, as last read, along with the configured ones
+func (backend *MySQLBackend) AppThresholds() []base.AppThreshold {
+	return backend.throttler.AppThresholds()
 }
 
 func (backend *MySQLBackend) RecentAppsMap() (result map[string](*base.RecentApp)) {
@@ -0,0 +1,159 @@
+package group
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/github/freno/internal/raft"
+	"github.com/outbrain/golib/log"
+)
+
+const (
+	joinOperation   = "join"
+	removeOperation = "remove"
+)
+
+// peerLiveThreshold is the time since last contact within which a peer is considered live, for
+// the purpose of quorum safety checks
+const peerLiveThreshold = 5 * time.Second
+
+// RaftPeer describes a raft member as seen by this node. The leader tracks contact with all followers;
+// a follower only tracks contact with the leader.
+type RaftPeer struct {
+	Address                 string
+	IsLeader                bool
+	IsSelf                  bool
+	LastContact             time.Time // zero when unknown
+	SecondsSinceLastContact float64   // -1 when unknown
+}
+
+// RaftPeersStatus lists raft members and this node's raft stats
+type RaftPeersStatus struct {
+	Leader string
+	Peers  []*RaftPeer
+	Stats  map[string]string
+}
+
+// PeerManager is implemented by consensus services which support runtime membership changes
+type PeerManager interface {
+	Join(addr string) (appliedBy string, err error)
+	Remove(addr string) (appliedBy string, err error)
+	Peers() (*RaftPeersStatus, error)
+}
+
+// Join joins a node, located at addr, to the raft group. The node must be ready to
+// respond to Raft communications at that address. A follower forwards the request to the leader.
+func (store *Store) Join(addr string) (appliedBy string, err error) {
+	return store.peerCommand(&command{Operation: joinOperation, Key: normalizeRaftNode(addr)})
+}
+
+// Remove removes the node located at addr from the raft group. It refuses to do so if
+// the remaining group would not have a live quorum. A follower forwards the request to the leader.
+func (store *Store) Remove(addr string) (appliedBy string, err error) {
+	return store.peerCommand(&command{Operation: removeOperation, Key: normalizeRaftNode(addr)})
+}
+
+func (store *Store) peerCommand(c *command) (appliedBy string, err error) {
+	if !store.isRaftLeader() {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return "", err
+		}
+		return store.forwardCommand(b)
+	}
+	return store.applyPeerChange(c)
+}
+
+// applyPeerChange changes raft membership on the leader
+func (store *Store) applyPeerChange(c *command) (appliedBy string, err error) {
+	if c.Key == "" {
+		return "", fmt.Errorf("no address given")
+	}
+	peers, err := store.raft.Peers()
+	if err != nil {
+		return "", err
+	}
+	var future raft.Future
+	switch c.Operation {
+	case joinOperation:
+		log.Infof("received join request for remote node as %s", c.Key)
+		if raft.PeerContained(peers, c.Key) {
+			return "", fmt.Errorf("%s is already a raft peer", c.Key)
+		}
+		future = store.raft.AddPeer(c.Key)
+	case removeOperation:
+		log.Infof("received remove request for remote node as %s", c.Key)
+		if err := checkRemoveQuorum(peers, c.Key, store.raftBind, store.raft.PeerLastContact(), time.Now()); err != nil {
+			return "", err
+		}
+		future = store.raft.RemovePeer(c.Key)
+	default:
+		return "", fmt.Errorf("unrecognized peer operation: %s", c.Operation)
+	}
+	if err := future.Error(); err != nil {
+		return "", err
+	}
+	log.Infof("%s of node at %s applied successfully", c.Operation, c.Key)
+	return store.raftBind, nil
+}
+
+// checkRemoveQuorum verifies removing given peer leaves a group which has a live quorum.
+// `self` is the leader, which is live by definition, and `contacts` is the leader's last contact with each follower.
+func checkRemoveQuorum(peers []string, addr string, self string, contacts map[string]time.Time, now time.Time) error {
+	if !raft.PeerContained(peers, addr) {
+		return fmt.Errorf("%s is not a raft peer", addr)
+	}
+	remaining := raft.ExcludePeer(peers, addr)
+	if len(remaining) == 0 {
+		return fmt.Errorf("refusing to remove %s: it is the last raft peer", addr)
+	}
+	live := 0
+	for _, peer := range remaining {
+		if peer == self {
+			live++
+		} else if lastContact, ok := contacts[peer]; ok && now.Sub(lastContact) <= peerLiveThreshold {
+			live++
+		}
+	}
+	quorum := len(remaining)/2 + 1
+	if live < quorum {
+		return fmt.Errorf("refusing to remove %s: only %d of remaining %d peers are live, below quorum of %d", addr, live, len(remaining), quorum)
+	}
+	return nil
+}
+
+// Peers lists the raft members and their last contact as seen by this node
+func (store *Store) Peers() (*RaftPeersStatus, error) {
+	peers, err := store.raft.Peers()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	leader := store.GetLeader()
+	isLeader := store.isRaftLeader()
+	contacts := store.raft.PeerLastContact()
+
+	status := &RaftPeersStatus{Leader: leader, Stats: store.raft.Stats()}
+	for _, peer := range peers {
+		raftPeer := &RaftPeer{
+			Address:                 peer,
+			IsLeader:                peer == leader,
+			IsSelf:                  peer == store.raftBind,
+			SecondsSinceLastContact: -1,
+		}
+		switch {
+		case raftPeer.IsSelf:
+			raftPeer.LastContact = now
+		case isLeader:
+			raftPeer.LastContact = contacts[peer]
+		case raftPeer.IsLeader:
+			raftPeer.LastContact = store.raft.LastContact()
+		}
+		if !raftPeer.LastContact.IsZero() {
+			raftPeer.SecondsSinceLastContact = now.Sub(raftPeer.LastContact).Seconds()
+		}
+		status.Peers = append(status.Peers, raftPeer)
+	}
+	return status, nil
+}
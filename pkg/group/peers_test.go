@@ -0,0 +1,29 @@
+package group
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestCheckRemoveQuorum(t *testing.T) {
+	now := time.Now()
+	peers := []string{"10.0.0.1:10008", "10.0.0.2:10008", "10.0.0.3:10008"}
+	self := "10.0.0.1:10008"
+	{
+		contacts := map[string]time.Time{"10.0.0.2:10008": now, "10.0.0.3:10008": now}
+		test.S(t).ExpectNil(checkRemoveQuorum(peers, "10.0.0.3:10008", self, contacts, now))
+		test.S(t).ExpectNil(checkRemoveQuorum(peers, self, self, contacts, now))
+		test.S(t).ExpectNotNil(checkRemoveQuorum(peers, "10.0.0.4:10008", self, contacts, now))
+	}
+	{
+		// 10.0.0.2 is down: removing it is fine, removing healthy 10.0.0.3 would leave no quorum
+		contacts := map[string]time.Time{"10.0.0.2:10008": now.Add(-time.Minute), "10.0.0.3:10008": now}
+		test.S(t).ExpectNil(checkRemoveQuorum(peers, "10.0.0.2:10008", self, contacts, now))
+		test.S(t).ExpectNotNil(checkRemoveQuorum(peers, "10.0.0.3:10008", self, contacts, now))
+	}
+	{
+		test.S(t).ExpectNotNil(checkRemoveQuorum([]string{self}, self, self, nil, now))
+	}
+}
@@ -25,6 +25,9 @@ var store *Store
 // Setup creates the entire raft shananga. Creates the store, associates with the throttler,
 // contacts peer nodes, and subscribes to leader changes to export them.
 func SetupRaft(throttler *throttle.Throttler) (ConsensusService, error) {
+	if err := checkForwardCredentials(); err != nil {
+		return nil, log.Errore(err)
+	}
 	store = NewStore(config.Settings().RaftDataDir, normalizeRaftNode(config.Settings().RaftBind), throttler)
 
 	peerNodes := []string{}
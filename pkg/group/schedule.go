@@ -0,0 +1,275 @@
+package group
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+
+	"github.com/outbrain/golib/log"
+	"github.com/robfig/cron/v3"
+)
+
+const scheduleInterval = 10 * time.Second
+
+// scheduleOriginPrefix marks throttles applied by a schedule, in their metadata's Origin
+const scheduleOriginPrefix = "schedule:"
+
+// ThrottleScheduleStatus describes a schedule along with its current, or else next, window
+type ThrottleScheduleStatus struct {
+	base.ThrottleSchedule
+	Active      bool
+	WindowStart time.Time
+	WindowEnd   time.Time
+}
+
+// ValidateThrottleSchedule checks a schedule's cron expression, duration, apps and ratio
+func ValidateThrottleSchedule(schedule *base.ThrottleSchedule) error {
+	if schedule.Name == "" {
+		return fmt.Errorf("name must be given")
+	}
+	if len(schedule.Apps) == 0 {
+		return fmt.Errorf("apps must be given")
+	}
+	for _, app := range schedule.Apps {
+		if err := base.ValidateAppName(app); err != nil {
+			return err
+		}
+		if base.IsAppRule(app) {
+			continue
+		}
+		if _, err := path.Match(app, ""); err != nil {
+			return fmt.Errorf("invalid app pattern %s: %+v", app, err)
+		}
+	}
+	if schedule.Ratio < 0 || schedule.Ratio > 1 {
+		return fmt.Errorf("ratio must be in [0..1] range; got %+v", schedule.Ratio)
+	}
+	_, _, err := parseThrottleSchedule(schedule)
+	return err
+}
+
+func parseThrottleSchedule(schedule *base.ThrottleSchedule) (cronSchedule cron.Schedule, duration time.Duration, err error) {
+	if cronSchedule, err = cron.ParseStandard(schedule.Cron); err != nil {
+		return nil, 0, fmt.Errorf("cannot parse cron expression %s: %+v", schedule.Cron, err)
+	}
+	if duration, err = time.ParseDuration(schedule.Duration); err != nil {
+		return nil, 0, fmt.Errorf("cannot parse duration %s: %+v", schedule.Duration, err)
+	}
+	if duration <= 0 {
+		return nil, 0, fmt.Errorf("duration must be positive; got %s", schedule.Duration)
+	}
+	return cronSchedule, duration, nil
+}
+
+// ThrottleScheduleWindow returns the schedule's window in effect at `now`, if any, or else its next window.
+// Cron expressions are evaluated in UTC, unless prefixed with e.g. `CRON_TZ=America/New_York`.
+func ThrottleScheduleWindow(schedule *base.ThrottleSchedule, now time.Time) (start time.Time, end time.Time, active bool, err error) {
+	cronSchedule, duration, err := parseThrottleSchedule(schedule)
+	if err != nil {
+		return start, end, false, err
+	}
+	now = now.UTC()
+	// The earliest window starting after now-duration is either in effect, or the next one
+	start = cronSchedule.Next(now.Add(-duration))
+	if start.IsZero() {
+		return start, end, false, nil
+	}
+	if start.After(now) {
+		start = cronSchedule.Next(now)
+		return start, start.Add(duration), false, nil
+	}
+	return start, start.Add(duration), true, nil
+}
+
+// NewThrottleScheduleStatuses describes given schedules along with their windows, sorted by name
+func NewThrottleScheduleStatuses(schedules []base.ThrottleSchedule, now time.Time) []ThrottleScheduleStatus {
+	statuses := []ThrottleScheduleStatus{}
+	for _, schedule := range schedules {
+		status := ThrottleScheduleStatus{ThrottleSchedule: schedule}
+		status.WindowStart, status.WindowEnd, status.Active, _ = ThrottleScheduleWindow(&schedule, now)
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// throttleSchedules is the raft store's set of schedules, keyed by name. It is populated by the FSM, and is
+// persisted in snapshots.
+type throttleSchedules struct {
+	mutex     sync.RWMutex
+	schedules map[string]base.ThrottleSchedule
+}
+
+func newThrottleSchedules() *throttleSchedules {
+	return &throttleSchedules{schedules: make(map[string]base.ThrottleSchedule)}
+}
+
+func (s *throttleSchedules) set(schedule base.ThrottleSchedule) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.schedules[schedule.Name] = schedule
+}
+
+// setAppliedWindows records the apps a schedule throttled. It is a no-op for a schedule which no longer exists.
+func (s *throttleSchedules) setAppliedWindows(name string, appliedWindows map[string]time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	schedule, ok := s.schedules[name]
+	if !ok {
+		return
+	}
+	schedule.AppliedWindows = appliedWindows
+	s.schedules[name] = schedule
+}
+
+func (s *throttleSchedules) delete(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.schedules, name)
+}
+
+func (s *throttleSchedules) list() []base.ThrottleSchedule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := []base.ThrottleSchedule{}
+	for _, schedule := range s.schedules {
+		result = append(result, schedule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// restore replaces the schedules, as read from a snapshot
+func (s *throttleSchedules) restore(schedules map[string]base.ThrottleSchedule) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.schedules = make(map[string]base.ThrottleSchedule)
+	for name, schedule := range schedules {
+		s.schedules[name] = schedule
+	}
+}
+
+// ThrottleScheduler applies throttle schedules. While this node is the leader, it throttles the scheduled apps
+// for the duration of each window, via the consensus service. Throttles of deleted schedules are removed.
+// Each app is throttled once per window, and the apps throttled are recorded via the consensus service, such that
+// an app unthrottled by hand during a window stays unthrottled, even as leadership changes.
+type ThrottleScheduler struct {
+	consensusService ConsensusService
+}
+
+func NewThrottleScheduler(consensusService ConsensusService) *ThrottleScheduler {
+	return &ThrottleScheduler{
+		consensusService: consensusService,
+	}
+}
+
+// Run applies schedules periodically
+func (scheduler *ThrottleScheduler) Run() {
+	t := time.NewTicker(scheduleInterval)
+	for range t.C {
+		scheduler.tick(time.Now())
+	}
+}
+
+// scheduledApps lists the app names a schedule throttles. Glob patterns, such as gh-ost:*, are throttled as glob:
+// rules, applying to any matching app, just as rules such as prefix:archiver/ are.
+func scheduledApps(patterns []string) []string {
+	apps := []string{}
+	for _, pattern := range patterns {
+		if !base.IsAppRule(pattern) && strings.ContainsAny(pattern, `*?[\`) {
+			pattern = base.AppRuleGlob + ":" + pattern
+		}
+		apps = append(apps, pattern)
+	}
+	return apps
+}
+
+// sameAppliedWindows compares two schedule applied windows maps
+func sameAppliedWindows(a map[string]time.Time, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for app, start := range a {
+		if other, ok := b[app]; !ok || !other.Equal(start) {
+			return false
+		}
+	}
+	return true
+}
+
+func (scheduler *ThrottleScheduler) tick(now time.Time) {
+	if !scheduler.consensusService.IsLeader() {
+		return
+	}
+	schedules, err := scheduler.consensusService.ThrottleSchedules()
+	if err != nil {
+		log.Errorf("throttle-scheduler: %+v", err)
+		return
+	}
+	throttledApps := scheduler.consensusService.ThrottledAppsMap()
+	scheduleNames := map[string]bool{}
+	for i := range schedules {
+		scheduleNames[schedules[i].Name] = true
+		scheduler.apply(&schedules[i], throttledApps, now)
+	}
+
+	// Remove throttles of deleted schedules
+	for key, appThrottle := range throttledApps {
+		if !strings.HasPrefix(appThrottle.Origin, scheduleOriginPrefix) || !appThrottle.ExpireAt.After(now) {
+			continue
+		}
+		scheduleName := strings.TrimPrefix(appThrottle.Origin, scheduleOriginPrefix)
+		if scheduleNames[scheduleName] {
+			continue
+		}
+		appName := base.AppThrottleKeyApp(key, appThrottle.AppThrottleStore)
+		if _, err := scheduler.consensusService.UnthrottleApp(appName, appThrottle.AppThrottleStore, &base.AuditInfo{Requester: "scheduler", Reason: fmt.Sprintf("schedule %s deleted", scheduleName)}); err != nil {
+			log.Errorf("throttle-scheduler: cannot unthrottle %s of deleted schedule %s: %+v", appName, scheduleName, err)
+		}
+	}
+}
+
+// apply throttles the apps of a schedule whose window is in effect, unless already throttled for this window.
+// An app throttled otherwise, by hand or by another schedule, is left as is.
+func (scheduler *ThrottleScheduler) apply(schedule *base.ThrottleSchedule, throttledApps map[string]*base.AppThrottle, now time.Time) {
+	start, end, active, err := ThrottleScheduleWindow(schedule, now)
+	if err != nil {
+		log.Errorf("throttle-scheduler: schedule %s: %+v", schedule.Name, err)
+		return
+	}
+	appliedWindows := make(map[string]time.Time)
+	if active {
+		origin := scheduleOriginPrefix + schedule.Name
+		metadata := &base.AppThrottleMetadata{Reason: schedule.Reason, Owner: schedule.Owner, Origin: origin}
+		audit := &base.AuditInfo{Requester: "scheduler", Reason: schedule.Reason}
+		ttlMinutes := int64(math.Ceil(end.Sub(now).Minutes()))
+		for _, app := range scheduledApps(schedule.Apps) {
+			if appliedStart, ok := schedule.AppliedWindows[app]; ok && appliedStart.Equal(start) {
+				appliedWindows[app] = start
+				continue
+			}
+			if appThrottle, ok := throttledApps[app]; ok && appThrottle.ExpireAt.After(now) && appThrottle.Origin != origin {
+				log.Debugf("throttle-scheduler: schedule %s: %s is already throttled by %q; leaving it be", schedule.Name, app, appThrottle.Origin)
+				continue
+			}
+			if _, err := scheduler.consensusService.ThrottleApp(app, base.AppThrottleStore{}, ttlMinutes, end, schedule.Ratio, metadata, audit); err != nil {
+				log.Errorf("throttle-scheduler: schedule %s: cannot throttle %s: %+v", schedule.Name, app, err)
+				continue
+			}
+			log.Infof("throttle-scheduler: schedule %s: throttled %s until %s", schedule.Name, app, end)
+			appliedWindows[app] = start
+		}
+	}
+	if sameAppliedWindows(schedule.AppliedWindows, appliedWindows) {
+		return
+	}
+	if _, err := scheduler.consensusService.SetThrottleScheduleAppliedWindows(schedule.Name, appliedWindows); err != nil {
+		log.Errorf("throttle-scheduler: schedule %s: cannot record applied windows: %+v", schedule.Name, err)
+	}
+}
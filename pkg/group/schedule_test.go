@@ -0,0 +1,197 @@
+package group
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/throttle"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+// fakeConsensusService applies requests directly onto the throttler, as a single leader node would
+type fakeConsensusService struct {
+	throttler     *throttle.Throttler
+	schedules     *throttleSchedules
+	throttleCount int
+}
+
+func newFakeConsensusService() *fakeConsensusService {
+	return &fakeConsensusService{throttler: throttle.NewThrottler(), schedules: newThrottleSchedules()}
+}
+
+func (service *fakeConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.throttleCount++
+	service.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottledAppsMap() map[string](*base.AppThrottle) {
+	return service.throttler.ThrottledAppsMap()
+}
+func (service *fakeConsensusService) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (string, error) {
+	service.throttler.UnthrottleAppOnStore(appName, store)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleAudit(appName string, since time.Time) ([]base.ThrottleAuditEntry, error) {
+	return nil, nil
+}
+func (service *fakeConsensusService) CreateThrottleSchedule(schedule *base.ThrottleSchedule) (string, error) {
+	service.schedules.set(*schedule)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) DeleteThrottleSchedule(name string) (string, error) {
+	service.schedules.delete(name)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleSchedules() ([]base.ThrottleSchedule, error) {
+	return service.schedules.list(), nil
+}
+func (service *fakeConsensusService) SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (string, error) {
+	service.schedules.setAppliedWindows(name, appliedWindows)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) (string, error) {
+	service.throttler.SetAppThreshold(appName, store, threshold)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) RemoveAppThreshold(appName string, store base.AppThrottleStore) (string, error) {
+	service.throttler.RemoveAppThreshold(appName, store)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) AppThresholds() []base.AppThreshold {
+	return service.throttler.AppThresholds()
+}
+func (service *fakeConsensusService) RecentAppsMap() map[string](*base.RecentApp) {
+	return service.throttler.RecentAppsMap()
+}
+func (service *fakeConsensusService) IsHealthy() bool             { return true }
+func (service *fakeConsensusService) IsLeader() bool              { return true }
+func (service *fakeConsensusService) GetLeader() string           { return "localhost" }
+func (service *fakeConsensusService) GetStateDescription() string { return "Leader" }
+func (service *fakeConsensusService) GetSharedDomainServices() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (service *fakeConsensusService) GetStatus() *ConsensusServiceStatus {
+	return &ConsensusServiceStatus{}
+}
+func (service *fakeConsensusService) Monitor() {}
+
+func TestValidateThrottleSchedule(t *testing.T) {
+	valid := base.ThrottleSchedule{Name: "nightly", Cron: "0 2 * * *", Duration: "2h", Apps: []string{"archiver"}, Ratio: 1}
+	test.S(t).ExpectNil(ValidateThrottleSchedule(&valid))
+
+	invalid := []func(schedule *base.ThrottleSchedule){
+		func(schedule *base.ThrottleSchedule) { schedule.Name = "" },
+		func(schedule *base.ThrottleSchedule) { schedule.Cron = "at 2am" },
+		func(schedule *base.ThrottleSchedule) { schedule.Duration = "" },
+		func(schedule *base.ThrottleSchedule) { schedule.Duration = "-1h" },
+		func(schedule *base.ThrottleSchedule) { schedule.Apps = nil },
+		func(schedule *base.ThrottleSchedule) { schedule.Apps = []string{"gh-ost:["} },
+		func(schedule *base.ThrottleSchedule) { schedule.Apps = []string{"regex:gh-ost:("} },
+		func(schedule *base.ThrottleSchedule) { schedule.Apps = []string{"archiver@mysql"} },
+		func(schedule *base.ThrottleSchedule) { schedule.Ratio = 1.5 },
+	}
+	for _, invalidate := range invalid {
+		schedule := valid
+		invalidate(&schedule)
+		test.S(t).ExpectNotNil(ValidateThrottleSchedule(&schedule))
+	}
+}
+
+func TestThrottleScheduleWindow(t *testing.T) {
+	schedule := &base.ThrottleSchedule{Name: "nightly", Cron: "0 2 * * *", Duration: "2h"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	{
+		start, end, active, err := ThrottleScheduleWindow(schedule, day.Add(time.Hour))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(active)
+		test.S(t).ExpectTrue(start.Equal(day.Add(2 * time.Hour)))
+		test.S(t).ExpectTrue(end.Equal(day.Add(4 * time.Hour)))
+	}
+	{
+		start, end, active, err := ThrottleScheduleWindow(schedule, day.Add(3*time.Hour))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(active)
+		test.S(t).ExpectTrue(start.Equal(day.Add(2 * time.Hour)))
+		test.S(t).ExpectTrue(end.Equal(day.Add(4 * time.Hour)))
+	}
+	{
+		start, _, active, err := ThrottleScheduleWindow(schedule, day.Add(4*time.Hour))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(active)
+		test.S(t).ExpectTrue(start.Equal(day.Add(26 * time.Hour)))
+	}
+}
+
+func TestScheduledApps(t *testing.T) {
+	test.S(t).ExpectEquals(strings.Join(scheduledApps([]string{"gh-ost:*", "backup"}), ","), "glob:gh-ost:*,backup")
+	test.S(t).ExpectEquals(strings.Join(scheduledApps([]string{"glob:pt-*", "prefix:archiver/"}), ","), "glob:pt-*,prefix:archiver/")
+	test.S(t).ExpectEquals(strings.Join(scheduledApps([]string{"regex:pt-.*"}), ","), "regex:pt-.*")
+}
+
+func TestThrottleSchedulerTick(t *testing.T) {
+	service := newFakeConsensusService()
+	scheduler := NewThrottleScheduler(service)
+	now := time.Now().UTC()
+	// a window which started a minute ago, lasting an hour
+	service.CreateThrottleSchedule(&base.ThrottleSchedule{
+		Name: "peak", Cron: now.Add(-time.Minute).Format("4 15 * * *"), Duration: "1h", Apps: []string{"gh-ost:*", "archiver"}, Ratio: 0.5, Reason: "peak hours",
+	})
+
+	scheduler.tick(now)
+	test.S(t).ExpectEquals(service.throttleCount, 2)
+	appThrottle, ok := service.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(appThrottle.Origin, "schedule:peak")
+	test.S(t).ExpectEquals(appThrottle.Reason, "peak hours")
+	test.S(t).ExpectEquals(appThrottle.Ratio, 0.5)
+	// a glob pattern is throttled as a rule, applying to apps which have yet to check
+	_, ok = service.ThrottledAppsMap()["glob:gh-ost:*"]
+	test.S(t).ExpectTrue(ok)
+
+	// Each app is throttled once per window; an app unthrottled by hand stays unthrottled, even once another
+	// scheduler, e.g. that of a new leader, takes over
+	service.UnthrottleApp("archiver", base.AppThrottleStore{}, nil)
+	NewThrottleScheduler(service).tick(now.Add(time.Minute))
+	test.S(t).ExpectEquals(service.throttleCount, 2)
+	_, ok = service.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectFalse(ok)
+
+	// Throttles of a deleted schedule are removed
+	service.DeleteThrottleSchedule("peak")
+	scheduler.tick(now.Add(2 * time.Minute))
+	test.S(t).ExpectEquals(len(service.ThrottledAppsMap()), 1) // "abusing-app" is throttled by default
+}
+
+func TestThrottleSchedulerKeepsManualThrottles(t *testing.T) {
+	service := newFakeConsensusService()
+	scheduler := NewThrottleScheduler(service)
+	now := time.Now().UTC()
+	manualExpireAt := now.Add(3 * time.Hour)
+	service.ThrottleApp("archiver", base.AppThrottleStore{}, 180, manualExpireAt, 1, &base.AppThrottleMetadata{Reason: "incident"}, nil)
+	service.CreateThrottleSchedule(&base.ThrottleSchedule{
+		Name: "peak", Cron: now.Add(-time.Minute).Format("4 15 * * *"), Duration: "1h", Apps: []string{"archiver", "backup"}, Ratio: 0.5,
+	})
+
+	// the manual throttle is neither overwritten, nor recorded as the schedule's
+	scheduler.tick(now)
+	appThrottle := service.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectEquals(appThrottle.Origin, "")
+	test.S(t).ExpectEquals(appThrottle.Reason, "incident")
+	test.S(t).ExpectEquals(appThrottle.Ratio, 1.0)
+	test.S(t).ExpectTrue(appThrottle.ExpireAt.Equal(manualExpireAt))
+	schedules, _ := service.ThrottleSchedules()
+	test.S(t).ExpectEquals(len(schedules[0].AppliedWindows), 1)
+	_, ok := schedules[0].AppliedWindows["backup"]
+	test.S(t).ExpectTrue(ok)
+
+	// deleting the schedule keeps the manual throttle
+	service.DeleteThrottleSchedule("peak")
+	scheduler.tick(now.Add(time.Minute))
+	_, ok = service.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectTrue(ok)
+	_, ok = service.ThrottledAppsMap()["backup"]
+	test.S(t).ExpectFalse(ok)
+}
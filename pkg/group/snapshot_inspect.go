@@ -0,0 +1,62 @@
+package group
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/github/freno/internal/raft"
+)
+
+// SnapshotInspection describes a single raft snapshot found on disk, along with
+// the freno state it would restore.
+type SnapshotInspection struct {
+	ID    string
+	Index uint64
+	Term  uint64
+	Size  int64
+	Data  *snapshotData `json:",omitempty"`
+	Error string        `json:",omitempty"`
+}
+
+// InspectSnapshots reads all snapshots in a FileSnapshotStore directory (typically the
+// RaftDataDir), most recent first. Snapshots which cannot be read or decoded are still
+// listed, along with the error.
+func InspectSnapshots(dir string) (inspections [](*SnapshotInspection), err error) {
+	if _, err := os.Stat(dir); err != nil {
+		return inspections, err
+	}
+	// Inspection is read only: the snapshot store is not created if missing
+	snapshots, err := raft.OpenFileSnapshotStore(dir, ioutil.Discard)
+	if err != nil {
+		return inspections, fmt.Errorf("file snapshot store: %s", err)
+	}
+	metas, err := snapshots.List()
+	if err != nil {
+		return inspections, err
+	}
+	for _, meta := range metas {
+		inspection := &SnapshotInspection{
+			ID:    meta.ID,
+			Index: meta.Index,
+			Term:  meta.Term,
+			Size:  meta.Size,
+		}
+		inspection.Data, err = inspectSnapshot(snapshots, meta.ID)
+		if err != nil {
+			inspection.Error = err.Error()
+		}
+		inspections = append(inspections, inspection)
+	}
+	return inspections, nil
+}
+
+func inspectSnapshot(snapshots raft.SnapshotStore, id string) (*snapshotData, error) {
+	_, rc, err := snapshots.Open(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readSnapshotData(rc)
+}
@@ -16,7 +16,9 @@ import (
 	"time"
 
 	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
 	"github.com/github/freno/pkg/throttle"
+	"github.com/github/freno/pkg/tlsutil"
 
 	"github.com/github/freno/internal/raft"
 	"github.com/github/freno/internal/raft-boltdb"
@@ -37,6 +39,19 @@ type command struct {
 	Value     string    `json:"value,omitempty"`
 	ExpireAt  time.Time `json:"expire,omitempty"`
 	Ratio     float64   `json:"ratio,omitempty"`
+
+	Metadata *base.AppThrottleMetadata `json:"metadata,omitempty"`
+	// Store scoped throttle and unthrottle commands; nil for unscoped ones, which encode as older versions do
+	Store *base.AppThrottleStore `json:"store,omitempty"`
+	// Throttle and unthrottle commands are audited. Commands written by older versions have no audit info.
+	TTLMinutes int64           `json:"ttl,omitempty"`
+	Time       time.Time       `json:"time,omitempty"`
+	Audit      *base.AuditInfo `json:"audit,omitempty"`
+
+	Schedule       *base.ThrottleSchedule `json:"schedule,omitempty"`
+	AppliedWindows map[string]time.Time   `json:"appliedWindows,omitempty"`
+
+	Threshold float64 `json:"threshold,omitempty"`
 }
 
 // The store is a raft store that is freno-aware.
@@ -48,6 +63,8 @@ type Store struct {
 	raftBind string
 
 	throttler *throttle.Throttler
+	auditLog  *throttleAuditLog
+	schedules *throttleSchedules
 
 	raft *raft.Raft // The consensus mechanism
 }
@@ -58,6 +75,8 @@ func NewStore(raftDir string, raftBind string, throttler *throttle.Throttler) *S
 		raftDir:   raftDir,
 		raftBind:  raftBind,
 		throttler: throttler,
+		auditLog:  newThrottleAuditLog(config.Settings().AuditLogMaxEntries),
+		schedules: newThrottleSchedules(),
 	}
 }
 
@@ -66,13 +85,16 @@ func NewStore(raftDir string, raftBind string, throttler *throttle.Throttler) *S
 func (store *Store) Open(peerNodes []string) error {
 	// Setup Raft configuration.
 	config := raft.DefaultConfig()
+	// A newly joined node replays past membership changes, some of which exclude it. It must not shut down on those.
+	// A removed node thus stays up as a follower with no peers, and can be re-joined.
+	config.ShutdownOnRemove = false
 
 	// Setup Raft communication.
 	addr, err := net.ResolveTCPAddr("tcp", store.raftBind)
 	if err != nil {
 		return err
 	}
-	transport, err := raft.NewTCPTransport(store.raftBind, addr, 3, 10*time.Second, os.Stderr)
+	transport, err := newRaftTransport(store.raftBind, addr)
 	if err != nil {
 		return err
 	}
@@ -119,62 +141,165 @@ func (store *Store) Open(peerNodes []string) error {
 	return nil
 }
 
-// genericCommand requests consensus for applying a single command.
-func (store *Store) genericCommand(c *command) error {
-	if store.raft.State() != raft.Leader {
-		return fmt.Errorf("not leader")
+// newRaftTransport creates a TLS transport if so configured, or else a plain TCP transport
+func newRaftTransport(raftBind string, addr net.Addr) (*raft.NetworkTransport, error) {
+	settings := config.Settings()
+	if settings.RaftTLSCertFile == "" {
+		return raft.NewTCPTransport(raftBind, addr, 3, 10*time.Second, os.Stderr)
+	}
+	reloader, err := tlsutil.NewReloader(tlsutil.Files{
+		CAFile:   settings.RaftTLSCAFile,
+		CertFile: settings.RaftTLSCertFile,
+		KeyFile:  settings.RaftTLSKeyFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("raft TLS: %+v", err)
 	}
+	log.Infof("raft communication over TLS")
+	return raft.NewTLSTransport(raftBind, addr, tlsutil.MutualConfig(reloader, settings.RaftTLSAllowedPeers), 3, 10*time.Second, os.Stderr)
+}
 
+// genericCommand requests consensus for applying a single command. A follower forwards the command to the leader.
+// It returns the identity of the node which applied the command.
+func (store *Store) genericCommand(c *command) (appliedBy string, err error) {
 	b, err := json.Marshal(c)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if !store.isRaftLeader() {
+		return store.forwardCommand(b)
 	}
+	return store.applyCommand(b)
+}
 
+// applyCommand applies a marshalled command on the leader
+func (store *Store) applyCommand(b []byte) (appliedBy string, err error) {
 	f := store.raft.Apply(b, raftTimeout)
-	return f.Error()
+	if err := f.Error(); err != nil {
+		return "", err
+	}
+	return store.raftBind, nil
 }
 
 // ThrottleApp, as implied by consensusService, is a raft oepration request which
 // will ask for consensus.
-func (store *Store) ThrottleApp(appName string, ttlMinutes int64, expireAt time.Time, ratio float64) error {
+func (store *Store) ThrottleApp(appName string, throttleStore base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (appliedBy string, err error) {
 	c := &command{
-		Operation: "throttle",
-		Key:       appName,
-		ExpireAt:  expireAt,
-		Ratio:     ratio,
+		Operation:  "throttle",
+		Key:        appName,
+		ExpireAt:   expireAt,
+		Ratio:      ratio,
+		Metadata:   metadata,
+		Store:      commandStore(throttleStore),
+		TTLMinutes: ttlMinutes,
+		Time:       time.Now(),
+		Audit:      nonNilAuditInfo(audit),
 	}
 	return store.genericCommand(c)
 }
 
 // UnthrottleApp, as implied by consensusService, is a raft oepration request which
 // will ask for consensus.
-func (store *Store) UnthrottleApp(appName string) error {
+func (store *Store) UnthrottleApp(appName string, throttleStore base.AppThrottleStore, audit *base.AuditInfo) (appliedBy string, err error) {
 	c := &command{
 		Operation: "unthrottle",
 		Key:       appName,
+		Store:     commandStore(throttleStore),
+		Time:      time.Now(),
+		Audit:     nonNilAuditInfo(audit),
 	}
 	return store.genericCommand(c)
 }
 
-func (store *Store) ThrottledAppsMap() (result map[string](*base.AppThrottle)) {
-	return store.throttler.ThrottledAppsMap()
+// commandStore returns the command encoding of a store scope: nil when unscoped
+func commandStore(throttleStore base.AppThrottleStore) *base.AppThrottleStore {
+	if throttleStore == (base.AppThrottleStore{}) {
+		return nil
+	}
+	return &throttleStore
 }
 
-func (store *Store) RecentAppsMap() (result map[string](*base.RecentApp)) {
-	return store.throttler.RecentAppsMap()
+// store returns the command's store scope, which is empty for unscoped commands
+func (c *command) store() base.AppThrottleStore {
+	if c.Store == nil {
+		return base.AppThrottleStore{}
+	}
+	return *c.Store
 }
 
-// Join joins a node, located at addr, to this store. The node must be ready to
-// respond to Raft communications at that address.
-func (store *Store) Join(addr string) error {
-	log.Infof("received join request for remote node as %s", addr)
+// ThrottleAudit returns the audited throttle operations of given app (all apps when empty), made at or after `since`
+func (store *Store) ThrottleAudit(appName string, since time.Time) ([]base.ThrottleAuditEntry, error) {
+	return store.auditLog.query(appName, since), nil
+}
 
-	f := store.raft.AddPeer(addr)
-	if f.Error() != nil {
-		return f.Error()
+// CreateThrottleSchedule, as implied by consensusService, is a raft operation request which will ask for consensus.
+// A schedule of the same name is replaced.
+func (store *Store) CreateThrottleSchedule(schedule *base.ThrottleSchedule) (appliedBy string, err error) {
+	c := &command{
+		Operation: "schedule",
+		Key:       schedule.Name,
+		Schedule:  schedule,
 	}
-	log.Infof("node at %s joined successfully", addr)
-	return nil
+	return store.genericCommand(c)
+}
+
+// DeleteThrottleSchedule, as implied by consensusService, is a raft operation request which will ask for consensus.
+func (store *Store) DeleteThrottleSchedule(name string) (appliedBy string, err error) {
+	c := &command{
+		Operation: "unschedule",
+		Key:       name,
+	}
+	return store.genericCommand(c)
+}
+
+// ThrottleSchedules returns all throttle schedules, sorted by name
+func (store *Store) ThrottleSchedules() ([]base.ThrottleSchedule, error) {
+	return store.schedules.list(), nil
+}
+
+// SetThrottleScheduleAppliedWindows, as implied by consensusService, is a raft operation request which will ask for
+// consensus. It records the apps a schedule throttled, such that a new leader does not throttle them again.
+func (store *Store) SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (appliedBy string, err error) {
+	c := &command{
+		Operation:      "schedule-applied",
+		Key:            name,
+		AppliedWindows: appliedWindows,
+	}
+	return store.genericCommand(c)
+}
+
+// SetAppThreshold, as implied by consensusService, is a raft operation request which will ask for consensus.
+func (store *Store) SetAppThreshold(appName string, throttleStore base.AppThrottleStore, threshold float64) (appliedBy string, err error) {
+	c := &command{
+		Operation: "threshold",
+		Key:       appName,
+		Store:     commandStore(throttleStore),
+		Threshold: threshold,
+	}
+	return store.genericCommand(c)
+}
+
+// RemoveAppThreshold, as implied by consensusService, is a raft operation request which will ask for consensus.
+func (store *Store) RemoveAppThreshold(appName string, throttleStore base.AppThrottleStore) (appliedBy string, err error) {
+	c := &command{
+		Operation: "unthreshold",
+		Key:       appName,
+		Store:     commandStore(throttleStore),
+	}
+	return store.genericCommand(c)
+}
+
+// AppThresholds returns the app thresholds set via consensus, along with the configured ones
+func (store *Store) AppThresholds() []base.AppThreshold {
+	return store.throttler.AppThresholds()
+}
+
+func (store *Store) ThrottledAppsMap() (result map[string](*base.AppThrottle)) {
+	return store.throttler.ThrottledAppsMap()
+}
+
+func (store *Store) RecentAppsMap() (result map[string](*base.RecentApp)) {
+	return store.throttler.RecentAppsMap()
 }
 
 func (store *Store) IsHealthy() bool {
@@ -193,7 +318,13 @@ func (store *Store) IsLeader() bool {
 	if ForceLeadership {
 		return true
 	}
-	return store.GetState() == raft.Leader
+	return store.isRaftLeader()
+}
+
+// isRaftLeader tells if this node is the raft leader, regardless of forced leadership.
+// Only the raft leader may apply commands.
+func (store *Store) isRaftLeader() bool {
+	return store.raft.State() == raft.Leader
 }
 
 // GetLeader returns identity of raft leader
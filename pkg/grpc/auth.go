@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/tlsutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// adminMethods change freno's state, and are subject to AdminIdentities/AdminTokens authorization, as are the
+// HTTP API's admin routes
+var adminMethods = map[string]bool{
+	"/freno.Freno/ThrottleApp":   true,
+	"/freno.Freno/UnthrottleApp": true,
+}
+
+// bearerToken returns the bearer token of the request's `authorization` metadata, if any
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	const prefix = "Bearer "
+	for _, authorization := range md.Get("authorization") {
+		if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+			return strings.TrimSpace(authorization[len(prefix):])
+		}
+	}
+	return ""
+}
+
+// clientIdentities returns the identities presented by the client's verified certificate, if any
+func clientIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return tlsutil.Identities(tlsInfo.State.VerifiedChains[0][0])
+}
+
+// requesterIdentity identifies the requester for auditing; see ConfigurationSettings.AdminRequester
+func requesterIdentity(ctx context.Context) string {
+	return config.Settings().AdminRequester(bearerToken(ctx), clientIdentities(ctx))
+}
+
+// authorizeMethod checks whether the request may call given method; see ConfigurationSettings.AuthorizeAdmin
+func authorizeMethod(ctx context.Context, fullMethod string) error {
+	if !adminMethods[fullMethod] {
+		return nil
+	}
+	authorized, credentials := config.Settings().AuthorizeAdmin(bearerToken(ctx), clientIdentities(ctx))
+	if authorized {
+		return nil
+	}
+	if !credentials {
+		return status.Error(codes.Unauthenticated, "admin method: unauthorized")
+	}
+	return status.Error(codes.PermissionDenied, "admin method: unauthorized")
+}
+
+func authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authorizeMethod(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authorizeStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authorizeMethod(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// ServerOptions returns the options to serve the gRPC API with, per configuration: over TLS, with the same
+// HTTPTLS* settings as the HTTP API, when configured. Admin credentials must not travel in plaintext, hence
+// serving without TLS is refused while AdminTokens or AdminIdentities are configured.
+func ServerOptions() ([]grpc.ServerOption, error) {
+	settings := config.Settings()
+	if settings.HTTPTLSCertFile == "" {
+		if settings.AdminAuthConfigured() {
+			return nil, fmt.Errorf("gRPC: AdminTokens and AdminIdentities require TLS; set HTTPTLSCertFile and HTTPTLSKeyFile")
+		}
+		return nil, nil
+	}
+	reloader, err := tlsutil.NewReloader(tlsutil.Files{
+		CAFile:   settings.HTTPTLSCAFile,
+		CertFile: settings.HTTPTLSCertFile,
+		KeyFile:  settings.HTTPTLSKeyFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsutil.ServerConfig(reloader, settings.HTTPTLSRequireClient)))}, nil
+}
@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	test "github.com/outbrain/golib/tests"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// auditingConsensusService records the audit info of throttle requests
+type auditingConsensusService struct {
+	fakeConsensusService
+	audits []*base.AuditInfo
+}
+
+func (service *auditingConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.audits = append(service.audits, audit)
+	return service.fakeConsensusService.ThrottleApp(appName, store, ttlMinutes, expireAt, ratio, metadata, audit)
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	settings := config.Settings()
+	settings.AdminTokens = []string{"s3cret"}
+	settings.AdminIdentities = []string{"ops-tooling"}
+	defer func() {
+		settings.AdminTokens = nil
+		settings.AdminIdentities = nil
+	}()
+
+	throttler := throttle.NewThrottler()
+	service := &auditingConsensusService{fakeConsensusService: fakeConsensusService{throttler: throttler}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	test.S(t).ExpectNil(err)
+	server := NewServer(NewServerImpl(throttle.NewThrottlerCheck(throttler), service))
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	test.S(t).ExpectNil(err)
+	defer conn.Close()
+	client := NewFrenoClient(conn)
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+	{
+		_, err := client.ThrottleApp(context.Background(), &ThrottleAppRequest{App: "test"})
+		test.S(t).ExpectEquals(status.Code(err), codes.Unauthenticated)
+		_, err = client.UnthrottleApp(context.Background(), &UnthrottleAppRequest{App: "test"})
+		test.S(t).ExpectEquals(status.Code(err), codes.Unauthenticated)
+	}
+	{
+		_, err := client.ThrottleApp(withToken("guess"), &ThrottleAppRequest{App: "test"})
+		test.S(t).ExpectEquals(status.Code(err), codes.PermissionDenied)
+	}
+	{
+		_, err := client.ThrottleApp(withToken("s3cret"), &ThrottleAppRequest{App: "test", Reason: "maintenance"})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(service.audits), 1)
+		test.S(t).ExpectEquals(service.audits[0].Requester, "token:1ec1c26b")
+		test.S(t).ExpectEquals(service.audits[0].RemoteAddr, "127.0.0.1")
+		test.S(t).ExpectEquals(service.audits[0].Reason, "maintenance")
+	}
+	{
+		// reads are not subject to admin authorization
+		_, err := client.ListThrottledApps(context.Background(), &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		_, err = client.Check(context.Background(), &CheckRequest{App: "test", StoreType: "mysql", StoreName: "nosuchcluster"})
+		test.S(t).ExpectNil(err)
+	}
+}
+
+func TestAuthorizeMethodIdentities(t *testing.T) {
+	settings := config.Settings()
+	settings.AdminIdentities = []string{"ops-tooling"}
+	defer func() { settings.AdminIdentities = nil }()
+
+	withCert := func(commonName string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		tlsInfo := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tlsInfo})
+	}
+	test.S(t).ExpectNil(authorizeMethod(withCert("ops-tooling"), "/freno.Freno/ThrottleApp"))
+	test.S(t).ExpectEquals(requesterIdentity(withCert("ops-tooling")), "ops-tooling")
+	test.S(t).ExpectEquals(status.Code(authorizeMethod(withCert("some-app"), "/freno.Freno/ThrottleApp")), codes.PermissionDenied)
+	test.S(t).ExpectEquals(status.Code(authorizeMethod(context.Background(), "/freno.Freno/ThrottleApp")), codes.Unauthenticated)
+	test.S(t).ExpectNil(authorizeMethod(context.Background(), "/freno.Freno/Check"))
+}
+
+func TestServerOptions(t *testing.T) {
+	settings := config.Settings()
+	{
+		opts, err := ServerOptions()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(opts), 0)
+	}
+	{
+		settings.AdminTokens = []string{"s3cret"}
+		defer func() { settings.AdminTokens = nil }()
+		_, err := ServerOptions()
+		test.S(t).ExpectNotNil(err)
+	}
+}
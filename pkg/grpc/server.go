@@ -0,0 +1,236 @@
+//go:generate ../../script/generate-grpc
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AppliedByMetadataKey is the response header reporting which node applied a throttle/unthrottle request
+const AppliedByMetadataKey = "freno-applied-by"
+
+// ServerImpl implements the gRPC FrenoServer. It serves the same throttler and consensus service as the HTTP API
+type ServerImpl struct {
+	throttlerCheck   *throttle.ThrottlerCheck
+	consensusService group.ConsensusService
+}
+
+// NewServerImpl creates a new instance of the gRPC server implementation
+func NewServerImpl(throttlerCheck *throttle.ThrottlerCheck, consensusService group.ConsensusService) *ServerImpl {
+	return &ServerImpl{
+		throttlerCheck:   throttlerCheck,
+		consensusService: consensusService,
+	}
+}
+
+// NewServer creates a gRPC server serving given implementation. Admin methods are subject to admin authorization.
+func NewServer(impl FrenoServer, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(authorizeUnary), grpc.ChainStreamInterceptor(authorizeStream))
+	server := grpc.NewServer(opts...)
+	RegisterFrenoServer(server, impl)
+	return server
+}
+
+// remoteAddr returns the client's address, sans port, for "recent apps" bookkeeping
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return strings.Split(p.Addr.String(), ":")[0]
+	}
+	return ""
+}
+
+// requestAuditInfo describes the requester of a throttle operation, and the given reason, for auditing
+func requestAuditInfo(ctx context.Context, reason string) *base.AuditInfo {
+	auditInfo := &base.AuditInfo{Requester: requesterIdentity(ctx), Reason: reason}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		auditInfo.RemoteAddr = p.Addr.String()
+		if host, _, err := net.SplitHostPort(auditInfo.RemoteAddr); err == nil {
+			auditInfo.RemoteAddr = host
+		}
+	}
+	return auditInfo
+}
+
+// checkFlags translates a check request's options into throttler check flags
+func checkFlags(req *CheckRequest) *throttle.CheckFlags {
+	return &throttle.CheckFlags{
+		LowPriority:       req.LowPriority,
+		OverrideThreshold: req.OverrideThreshold,
+		ReadCheck:         req.OverrideThreshold > 0,
+		OKIfNotExists:     req.OkIfNotExists,
+	}
+}
+
+// checkResponse translates a throttler check result into a check response
+func checkResponse(checkResult *throttle.CheckResult, flags *throttle.CheckFlags) *CheckResponse {
+	response := &CheckResponse{
+		StatusCode: int32(checkResult.StatusCode),
+		Value:      checkResult.Value,
+		Threshold:  checkResult.Threshold,
+		Message:    checkResult.Message,
+	}
+	if checkResult.StatusCode == http.StatusNotFound && flags.OKIfNotExists {
+		response.StatusCode = http.StatusOK // 200
+	}
+	return response
+}
+
+// Check checks whether a collected metric is within its threshold
+func (impl *ServerImpl) Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error) {
+	flags := checkFlags(req)
+	checkResult := impl.throttlerCheck.Check(req.App, req.StoreType, req.StoreName, remoteAddr(ctx), flags)
+	return checkResponse(checkResult, flags), nil
+}
+
+// WatchCheck streams the check response upon start, and then whenever its status code changes. The check is
+// re-evaluated upon each aggregation of metrics, as the HTTP check stream is; only the first check counts as a
+// check made by the app.
+func (impl *ServerImpl) WatchCheck(req *WatchCheckRequest, stream Freno_WatchCheckServer) error {
+	if req.Check == nil {
+		return status.Error(codes.InvalidArgument, "check must be given")
+	}
+	ctx := stream.Context()
+	flags := checkFlags(req.Check)
+
+	var sendErr error
+	var lastStatusCode int32
+	impl.throttlerCheck.WatchCheck(ctx, req.Check.App, req.Check.StoreType, req.Check.StoreName, remoteAddr(ctx), flags, func(checkResult *throttle.CheckResult) bool {
+		response := checkResponse(checkResult, flags)
+		if response.StatusCode == lastStatusCode {
+			// e.g. 404 and 200 are both reported as 200 with ok_if_not_exists
+			return true
+		}
+		lastStatusCode = response.StatusCode
+		sendErr = stream.Send(response)
+		return sendErr == nil
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	return ctx.Err()
+}
+
+// ThrottleApp forcibly marks given app as throttled. Future requests by this app may be denied.
+func (impl *ServerImpl) ThrottleApp(ctx context.Context, req *ThrottleAppRequest) (*empty.Empty, error) {
+	if req.App == "" {
+		return nil, status.Error(codes.InvalidArgument, "app must be given")
+	}
+	if err := base.ValidateAppName(req.App); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	store := base.AppThrottleStore{StoreType: req.StoreType, StoreName: req.StoreName}
+	if err := store.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var expireAt time.Time // default zero
+	if req.TtlMinutes != 0 {
+		expireAt = time.Now().Add(time.Duration(req.TtlMinutes) * time.Minute)
+	}
+	ratio := -1.0
+	if req.Ratio != nil {
+		ratio = req.Ratio.Value
+		if ratio < 0 || ratio > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "ratio must be in [0..1] range; got %+v", ratio)
+		}
+	}
+	var appMetadata *base.AppThrottleMetadata // nil keeps an existing throttle's metadata
+	if req.Reason != "" || req.Owner != "" {
+		appMetadata = &base.AppThrottleMetadata{Reason: req.Reason, Owner: req.Owner}
+	}
+	appliedBy, err := impl.consensusService.ThrottleApp(req.App, store, req.TtlMinutes, expireAt, ratio, appMetadata, requestAuditInfo(ctx, req.Reason))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(AppliedByMetadataKey, appliedBy))
+	return &empty.Empty{}, nil
+}
+
+// UnthrottleApp removes any imposed throttling constraint from given app
+func (impl *ServerImpl) UnthrottleApp(ctx context.Context, req *UnthrottleAppRequest) (*empty.Empty, error) {
+	if req.App == "" {
+		return nil, status.Error(codes.InvalidArgument, "app must be given")
+	}
+	if err := base.ValidateAppName(req.App); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	store := base.AppThrottleStore{StoreType: req.StoreType, StoreName: req.StoreName}
+	if err := store.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	appliedBy, err := impl.consensusService.UnthrottleApp(req.App, store, requestAuditInfo(ctx, req.Reason))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(AppliedByMetadataKey, appliedBy))
+	return &empty.Empty{}, nil
+}
+
+// ListThrottledApps returns a snapshot of all currently throttled apps
+func (impl *ServerImpl) ListThrottledApps(ctx context.Context, _ *empty.Empty) (*ListThrottledAppsResponse, error) {
+	response := &ListThrottledAppsResponse{Apps: make(map[string]*AppThrottle)}
+	for key, appThrottle := range impl.consensusService.ThrottledAppsMap() {
+		expireAt, err := ptypes.TimestampProto(appThrottle.ExpireAt)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Apps[key] = &AppThrottle{
+			ExpireAt:  expireAt,
+			Ratio:     appThrottle.Ratio,
+			App:       base.AppThrottleKeyApp(key, appThrottle.AppThrottleStore),
+			StoreType: appThrottle.StoreType,
+			StoreName: appThrottle.StoreName,
+			Reason:    appThrottle.Reason,
+			Owner:     appThrottle.Owner,
+			Origin:    appThrottle.Origin,
+		}
+	}
+	return response, nil
+}
+
+// AggregatedMetrics returns a snapshot of all current aggregated metrics
+func (impl *ServerImpl) AggregatedMetrics(ctx context.Context, _ *empty.Empty) (*AggregatedMetricsResponse, error) {
+	response := &AggregatedMetricsResponse{Metrics: make(map[string]*AggregatedMetric)}
+	for metricName, metricResult := range impl.throttlerCheck.AggregatedMetrics() {
+		aggregatedMetric := &AggregatedMetric{}
+		if value, err := metricResult.Get(); err == nil {
+			aggregatedMetric.Value = value
+		} else {
+			aggregatedMetric.Error = err.Error()
+		}
+		response.Metrics[metricName] = aggregatedMetric
+	}
+	return response, nil
+}
+
+// ConsensusStatus returns the consensus status of this node
+func (impl *ServerImpl) ConsensusStatus(ctx context.Context, _ *empty.Empty) (*ConsensusStatusResponse, error) {
+	consensusStatus := impl.consensusService.GetStatus()
+	return &ConsensusStatusResponse{
+		ServiceId:                 consensusStatus.ServiceID,
+		Healthy:                   consensusStatus.Healthy,
+		IsLeader:                  consensusStatus.IsLeader,
+		Leader:                    consensusStatus.Leader,
+		State:                     consensusStatus.State,
+		Domain:                    consensusStatus.Domain,
+		ShareDomain:               consensusStatus.ShareDomain,
+		ShareDomainServices:       consensusStatus.ShareDomainServices,
+		ShareDomainServicesList:   consensusStatus.ShareDomainServicesList,
+		HealthyDomainServicesList: consensusStatus.HealthyDomainServicesList,
+	}, nil
+}
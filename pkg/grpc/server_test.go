@@ -0,0 +1,263 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/outbrain/golib/log"
+	test "github.com/outbrain/golib/tests"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	log.SetLevel(log.ERROR)
+}
+
+// fakeConsensusService applies throttle requests directly onto the throttler, as a single node would
+type fakeConsensusService struct {
+	throttler *throttle.Throttler
+}
+
+func (service *fakeConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottledAppsMap() map[string](*base.AppThrottle) {
+	return service.throttler.ThrottledAppsMap()
+}
+func (service *fakeConsensusService) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (string, error) {
+	service.throttler.UnthrottleAppOnStore(appName, store)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleAudit(appName string, since time.Time) ([]base.ThrottleAuditEntry, error) {
+	return nil, nil
+}
+func (service *fakeConsensusService) CreateThrottleSchedule(schedule *base.ThrottleSchedule) (string, error) {
+	return "localhost", nil
+}
+func (service *fakeConsensusService) DeleteThrottleSchedule(name string) (string, error) {
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleSchedules() ([]base.ThrottleSchedule, error) {
+	return nil, nil
+}
+func (service *fakeConsensusService) SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (string, error) {
+	return "localhost", nil
+}
+func (service *fakeConsensusService) SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) (string, error) {
+	service.throttler.SetAppThreshold(appName, store, threshold)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) RemoveAppThreshold(appName string, store base.AppThrottleStore) (string, error) {
+	service.throttler.RemoveAppThreshold(appName, store)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) AppThresholds() []base.AppThreshold {
+	return service.throttler.AppThresholds()
+}
+func (service *fakeConsensusService) RecentAppsMap() map[string](*base.RecentApp) {
+	return service.throttler.RecentAppsMap()
+}
+func (service *fakeConsensusService) IsHealthy() bool             { return true }
+func (service *fakeConsensusService) IsLeader() bool              { return true }
+func (service *fakeConsensusService) GetLeader() string           { return "localhost" }
+func (service *fakeConsensusService) GetStateDescription() string { return "Leader" }
+func (service *fakeConsensusService) GetSharedDomainServices() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (service *fakeConsensusService) GetStatus() *group.ConsensusServiceStatus {
+	return &group.ConsensusServiceStatus{ServiceID: "test", Healthy: true, IsLeader: true, Leader: "localhost", State: "Leader"}
+}
+func (service *fakeConsensusService) Monitor() {}
+
+// newTestServer runs an in-process server on a local port, and returns a client connected to it
+func newTestServer(t *testing.T) (client FrenoClient, throttler *throttle.Throttler, cleanup func()) {
+	throttler = throttle.NewThrottler()
+	impl := NewServerImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	test.S(t).ExpectNil(err)
+	server := NewServer(impl)
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	test.S(t).ExpectNil(err)
+	return NewFrenoClient(conn), throttler, func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestCheck(t *testing.T) {
+	client, _, cleanup := newTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	{
+		response, err := client.Check(ctx, &CheckRequest{App: "test", StoreType: "mysql", StoreName: "nosuchcluster"})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(response.StatusCode, int32(http.StatusNotFound))
+	}
+	{
+		response, err := client.Check(ctx, &CheckRequest{App: "test", StoreType: "mysql", StoreName: "nosuchcluster", OkIfNotExists: true})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(response.StatusCode, int32(http.StatusOK))
+	}
+	{
+		response, err := client.Check(ctx, &CheckRequest{App: "abusing-app", StoreType: "mysql", StoreName: "nosuchcluster"})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(response.StatusCode, int32(http.StatusExpectationFailed))
+	}
+}
+
+func TestThrottleApp(t *testing.T) {
+	client, _, cleanup := newTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	{
+		var header metadata.MD
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", TtlMinutes: 10, Ratio: &wrappers.DoubleValue{Value: 0.5}}, grpc.Header(&header))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(header.Get(AppliedByMetadataKey)[0], "localhost")
+		response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		appThrottle, found := response.Apps["test"]
+		test.S(t).ExpectTrue(found)
+		test.S(t).ExpectEquals(appThrottle.Ratio, 0.5)
+		test.S(t).ExpectTrue(appThrottle.ExpireAt.Seconds > time.Now().Unix())
+		test.S(t).ExpectEquals(appThrottle.App, "test")
+		test.S(t).ExpectEquals(appThrottle.StoreType, "")
+	}
+	{
+		_, err := client.UnthrottleApp(ctx, &UnthrottleAppRequest{App: "test"})
+		test.S(t).ExpectNil(err)
+		response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		_, found := response.Apps["test"]
+		test.S(t).ExpectFalse(found)
+	}
+	{
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", Ratio: &wrappers.DoubleValue{Value: 1.5}})
+		test.S(t).ExpectEquals(status.Code(err), codes.InvalidArgument)
+	}
+	{
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test@mysql"})
+		test.S(t).ExpectEquals(status.Code(err), codes.InvalidArgument)
+		_, err = client.UnthrottleApp(ctx, &UnthrottleAppRequest{App: "test@mysql"})
+		test.S(t).ExpectEquals(status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestThrottleAppScoped(t *testing.T) {
+	client, _, cleanup := newTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+	{
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", StoreType: "mysql", StoreName: "main1", Reason: "migration", Owner: "dba-team"})
+		test.S(t).ExpectNil(err)
+		_, err = client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", Ratio: &wrappers.DoubleValue{Value: 0.5}})
+		test.S(t).ExpectNil(err)
+		response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		appThrottle, found := response.Apps["test@mysql/main1"]
+		test.S(t).ExpectTrue(found)
+		test.S(t).ExpectEquals(appThrottle.StoreType, "mysql")
+		test.S(t).ExpectEquals(appThrottle.StoreName, "main1")
+		test.S(t).ExpectEquals(appThrottle.Reason, "migration")
+		test.S(t).ExpectEquals(appThrottle.Owner, "dba-team")
+		_, found = response.Apps["test"]
+		test.S(t).ExpectTrue(found)
+	}
+	{
+		// an already throttled app keeps its metadata unless given
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", StoreType: "mysql", StoreName: "main1", Ratio: &wrappers.DoubleValue{Value: 0.3}})
+		test.S(t).ExpectNil(err)
+		response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		appThrottle := response.Apps["test@mysql/main1"]
+		test.S(t).ExpectEquals(appThrottle.Ratio, 0.3)
+		test.S(t).ExpectEquals(appThrottle.Reason, "migration")
+	}
+	{
+		_, err := client.UnthrottleApp(ctx, &UnthrottleAppRequest{App: "test", StoreType: "mysql", StoreName: "main1"})
+		test.S(t).ExpectNil(err)
+		response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+		test.S(t).ExpectNil(err)
+		_, found := response.Apps["test@mysql/main1"]
+		test.S(t).ExpectFalse(found)
+		_, found = response.Apps["test"]
+		test.S(t).ExpectTrue(found)
+	}
+	{
+		_, err := client.ThrottleApp(ctx, &ThrottleAppRequest{App: "test", StoreName: "main1"})
+		test.S(t).ExpectEquals(status.Code(err), codes.InvalidArgument)
+		_, err = client.UnthrottleApp(ctx, &UnthrottleAppRequest{App: "test", StoreName: "main1"})
+		test.S(t).ExpectEquals(status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestListThrottledAppsScoped(t *testing.T) {
+	client, throttler, cleanup := newTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	throttler.ThrottleAppOnStore("test", base.AppThrottleStore{StoreType: "mysql", StoreName: "main1"}, time.Now().Add(time.Hour), 1, &base.AppThrottleMetadata{Reason: "migration", Owner: "dba-team", Origin: "schedule:peak"})
+	response, err := client.ListThrottledApps(ctx, &empty.Empty{})
+	test.S(t).ExpectNil(err)
+	appThrottle, found := response.Apps["test@mysql/main1"]
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(appThrottle.App, "test")
+	test.S(t).ExpectEquals(appThrottle.StoreType, "mysql")
+	test.S(t).ExpectEquals(appThrottle.StoreName, "main1")
+	test.S(t).ExpectEquals(appThrottle.Reason, "migration")
+	test.S(t).ExpectEquals(appThrottle.Owner, "dba-team")
+	test.S(t).ExpectEquals(appThrottle.Origin, "schedule:peak")
+}
+
+func TestWatchCheck(t *testing.T) {
+	client, throttler, cleanup := newTestServer(t)
+	defer cleanup()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	stream, err := client.WatchCheck(ctx, &WatchCheckRequest{
+		Check: &CheckRequest{App: "test", StoreType: "mysql", StoreName: "nosuchcluster"},
+	})
+	test.S(t).ExpectNil(err)
+	{
+		response, err := stream.Recv()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(response.StatusCode, int32(http.StatusNotFound))
+	}
+	// The check is only re-evaluated upon aggregation of metrics, which does not run here
+	throttler.ThrottleApp("test", time.Now().Add(time.Hour), 1)
+	{
+		_, err := stream.Recv()
+		test.S(t).ExpectEquals(status.Code(err), codes.DeadlineExceeded)
+	}
+}
+
+func TestConsensusStatus(t *testing.T) {
+	client, _, cleanup := newTestServer(t)
+	defer cleanup()
+
+	response, err := client.ConsensusStatus(context.Background(), &empty.Empty{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(response.ServiceId, "test")
+	test.S(t).ExpectTrue(response.IsLeader)
+
+	metricsResponse, err := client.AggregatedMetrics(context.Background(), &empty.Empty{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(metricsResponse.Metrics), 0)
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package hostprobe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InstanceKey is an instance indicator, identified by hostname and port
+type InstanceKey struct {
+	Hostname string
+	Port     int
+}
+
+// ParseInstanceKey will parse an InstanceKey from a string representation such as 127.0.0.1:5432 or some.hostname
+// `defaultPort` is used if `hostPort` does not include a port.
+func ParseInstanceKey(hostPort string, defaultPort int) (*InstanceKey, error) {
+	if !strings.Contains(hostPort, ":") {
+		return &InstanceKey{Hostname: hostPort, Port: defaultPort}, nil
+	}
+	tokens := strings.SplitN(hostPort, ":", 2)
+	instanceKey := &InstanceKey{Hostname: tokens[0]}
+	var err error
+	if instanceKey.Port, err = strconv.Atoi(tokens[1]); err != nil {
+		return instanceKey, fmt.Errorf("Invalid port: %s", tokens[1])
+	}
+	return instanceKey, nil
+}
+
+// Equals tests equality between this key and another key
+func (this *InstanceKey) Equals(other *InstanceKey) bool {
+	if other == nil {
+		return false
+	}
+	return this.Hostname == other.Hostname && this.Port == other.Port
+}
+
+// IsValid uses simple heuristics to see whether this key represents an actual instance
+func (this *InstanceKey) IsValid() bool {
+	return len(this.Hostname) > 0 && this.Port > 0
+}
+
+// StringCode returns an official string representation of this key
+func (this *InstanceKey) StringCode() string {
+	return fmt.Sprintf("%s:%d", this.Hostname, this.Port)
+}
+
+// String returns a user-friendly string representation of this key
+func (this InstanceKey) String() string {
+	return this.StringCode()
+}
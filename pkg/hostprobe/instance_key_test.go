@@ -0,0 +1,44 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package hostprobe
+
+import (
+	"testing"
+
+	"github.com/outbrain/golib/log"
+	test "github.com/outbrain/golib/tests"
+)
+
+func init() {
+	log.SetLevel(log.ERROR)
+}
+
+func TestParseInstanceKey(t *testing.T) {
+	cases := []struct {
+		hostPort    string
+		defaultPort int
+		expected    InstanceKey
+		expectErr   bool
+	}{
+		{hostPort: "127.0.0.1:5433", defaultPort: 5432, expected: InstanceKey{Hostname: "127.0.0.1", Port: 5433}},
+		{hostPort: "127.0.0.1", defaultPort: 5432, expected: InstanceKey{Hostname: "127.0.0.1", Port: 5432}},
+		{hostPort: "redis.example.com:6380", defaultPort: 6379, expected: InstanceKey{Hostname: "redis.example.com", Port: 6380}},
+		{hostPort: "redis.example.com", defaultPort: 6379, expected: InstanceKey{Hostname: "redis.example.com", Port: 6379}},
+		{hostPort: "127.0.0.1:abcd", defaultPort: 5432, expectErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.hostPort, func(t *testing.T) {
+			key, err := ParseInstanceKey(c.hostPort, c.defaultPort)
+			if c.expectErr {
+				test.S(t).ExpectNotNil(err)
+				return
+			}
+			test.S(t).ExpectNil(err)
+			test.S(t).ExpectEquals(*key, c.expected)
+			test.S(t).ExpectTrue(key.IsValid())
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package hostprobe
+
+import (
+	"fmt"
+
+	"github.com/github/freno/pkg/base"
+)
+
+type ClusterInstanceKey struct {
+	ClusterName string
+	Key         InstanceKey
+}
+
+func GetClusterInstanceKey(clusterName string, key *InstanceKey) ClusterInstanceKey {
+	return ClusterInstanceKey{ClusterName: clusterName, Key: *key}
+}
+
+func (c ClusterInstanceKey) HashCode() string {
+	return fmt.Sprintf("%s:%s", c.ClusterName, c.Key.StringCode())
+}
+
+type InstanceMetricResultMap map[ClusterInstanceKey]base.MetricResult
+
+// Inventory holds the probes of a store type's clusters, and their latest metrics
+type Inventory struct {
+	ClustersProbes       map[string](*Probes)
+	IgnoreHostsCount     map[string]int
+	IgnoreHostsThreshold map[string]float64
+	AggregationModes     map[string]base.AggregationMode
+	InstanceKeyMetrics   InstanceMetricResultMap
+}
+
+func NewInventory() *Inventory {
+	inventory := &Inventory{
+		ClustersProbes:       make(map[string](*Probes)),
+		IgnoreHostsCount:     make(map[string]int),
+		IgnoreHostsThreshold: make(map[string]float64),
+		AggregationModes:     make(map[string]base.AggregationMode),
+		InstanceKeyMetrics:   make(map[ClusterInstanceKey]base.MetricResult),
+	}
+	return inventory
+}
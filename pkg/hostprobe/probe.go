@@ -0,0 +1,47 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+// Package hostprobe holds what store types which probe each of their hosts for a metric have in common:
+// instance keys, inventory, and cached, instrumented reads. Each store type provides its own HostProbe.
+package hostprobe
+
+import (
+	"github.com/github/freno/pkg/base"
+)
+
+// Probe is the part of a probe common to all store types. Store type specific probes embed it.
+type Probe struct {
+	Key             InstanceKey
+	CacheMillis     int
+	QueryInProgress int64
+}
+
+// HostProbe reads a metric off a single host
+type HostProbe interface {
+	// GetProbe returns the embedded Probe
+	GetProbe() *Probe
+	// CacheKey identifies the metric read, such that probes reading the same metric off the same host share a cache entry
+	CacheKey() string
+	// ReadMetric reads the metric off the host
+	ReadMetric() (float64, error)
+}
+
+func (this *Probe) GetProbe() *Probe {
+	return this
+}
+
+type Probes map[InstanceKey]HostProbe
+
+type ClusterProbes struct {
+	ClusterName          string
+	IgnoreHostsCount     int
+	IgnoreHostsThreshold float64
+	AggregationMode      base.AggregationMode
+	InstanceProbes       *Probes
+}
+
+func NewProbes() *Probes {
+	return &Probes{}
+}
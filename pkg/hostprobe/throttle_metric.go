@@ -0,0 +1,96 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package hostprobe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/freno/pkg/exposition"
+
+	"github.com/patrickmn/go-cache"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+var metricCache = cache.New(cache.NoExpiration, 10*time.Millisecond)
+
+func getMetricCacheKey(storeType string, hostProbe HostProbe) string {
+	return fmt.Sprintf("%s:%s", storeType, hostProbe.CacheKey())
+}
+
+func cacheThrottleMetric(storeType string, hostProbe HostProbe, throttleMetric *ThrottleMetric) *ThrottleMetric {
+	if throttleMetric.Err != nil {
+		return throttleMetric
+	}
+	if cacheMillis := hostProbe.GetProbe().CacheMillis; cacheMillis > 0 {
+		metricCache.Set(getMetricCacheKey(storeType, hostProbe), throttleMetric, time.Duration(cacheMillis)*time.Millisecond)
+	}
+	return throttleMetric
+}
+
+func getCachedThrottleMetric(storeType string, hostProbe HostProbe) *ThrottleMetric {
+	if hostProbe.GetProbe().CacheMillis == 0 {
+		return nil
+	}
+	if metric, found := metricCache.Get(getMetricCacheKey(storeType, hostProbe)); found {
+		throttleMetric, _ := metric.(*ThrottleMetric)
+		return throttleMetric
+	}
+	return nil
+}
+
+type ThrottleMetric struct {
+	ClusterName string
+	Key         InstanceKey
+	Value       float64
+	Err         error
+	Timestamp   time.Time // when probed
+}
+
+func NewThrottleMetric() *ThrottleMetric {
+	return &ThrottleMetric{Value: 0}
+}
+
+func (metric *ThrottleMetric) GetClusterInstanceKey() ClusterInstanceKey {
+	return GetClusterInstanceKey(metric.ClusterName, &metric.Key)
+}
+
+func (metric *ThrottleMetric) HashCode() string {
+	return metric.GetClusterInstanceKey().HashCode()
+}
+
+func (metric *ThrottleMetric) Get() (float64, error) {
+	return metric.Value, metric.Err
+}
+
+// ReadThrottleMetric reads a host's metric via given probe, unless cached. Probes are instrumented
+// per store type, as `probes.<storeType>.*`
+func ReadThrottleMetric(storeType string, hostProbe HostProbe, clusterName string) (throttleMetric *ThrottleMetric) {
+	if throttleMetric := getCachedThrottleMetric(storeType, hostProbe); throttleMetric != nil {
+		return throttleMetric
+		// On cached results we avoid taking latency metrics
+	}
+
+	started := time.Now()
+	throttleMetric = NewThrottleMetric()
+	throttleMetric.Timestamp = started
+	throttleMetric.ClusterName = clusterName
+	throttleMetric.Key = hostProbe.GetProbe().Key
+
+	defer func(metric *ThrottleMetric, started time.Time) {
+		go func() {
+			metrics.GetOrRegisterTimer(fmt.Sprintf("probes.%s.latency", storeType), nil).Update(time.Since(started))
+			exposition.ObserveProbe(storeType, metric.ClusterName, metric.Key.StringCode(), time.Since(started).Seconds(), metric.Err)
+			metrics.GetOrRegisterCounter(fmt.Sprintf("probes.%s.total", storeType), nil).Inc(1)
+			if metric.Err != nil {
+				metrics.GetOrRegisterCounter(fmt.Sprintf("probes.%s.error", storeType), nil).Inc(1)
+			}
+		}()
+	}(throttleMetric, started)
+
+	throttleMetric.Value, throttleMetric.Err = hostProbe.ReadMetric()
+	return cacheThrottleMetric(storeType, hostProbe, throttleMetric)
+}
@@ -3,12 +3,16 @@ package http
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/github/freno/pkg/base"
 	"github.com/github/freno/pkg/config"
 	"github.com/github/freno/pkg/group"
 	"github.com/github/freno/pkg/throttle"
@@ -30,23 +34,49 @@ type API interface {
 	WriteCheckIfExists(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	ReadCheck(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	ReadCheckIfExists(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	CheckExplain(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	CheckStream(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	AggregatedMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	MetricsHealth(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	StoreHostMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	PrometheusMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	ThrottleApp(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	UnthrottleApp(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	ThrottledApps(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	ThrottleAudit(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	CreateAppThrottle(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	GetAppThrottle(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	DeleteAppThrottle(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	OpenAPI(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	ThrottleSchedules(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	CreateThrottleSchedule(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	DeleteThrottleSchedule(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	AppThresholds(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	CreateAppThreshold(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	GetAppThreshold(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	DeleteAppThreshold(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	RecentApps(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	ApplyForwarded(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	RaftJoin(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	RaftRemove(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	RaftPeers(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	Help(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	MemcacheConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 }
 
 var endpoints = []string{} // known API URIs
 
+// appliedByHeader reports which node applied a consensus write
+const appliedByHeader = "X-Freno-Applied-By"
+
+const maxForwardedBodySize = 1 << 20
+
 var okIfNotExistsFlags = &throttle.CheckFlags{OKIfNotExists: true}
 
 type GeneralResponse struct {
 	StatusCode int
 	Message    string
+	AppliedBy  string `json:",omitempty"` // for consensus writes: the node which applied the change
 }
 
 func NewGeneralResponse(statusCode int, message string) *GeneralResponse {
@@ -90,6 +120,25 @@ func (api *APIImpl) respondGeneric(w http.ResponseWriter, r *http.Request, e err
 	}
 }
 
+// respondApplied responds to a consensus write just as respondGeneric does, further indicating which node
+// applied the change
+func (api *APIImpl) respondApplied(w http.ResponseWriter, r *http.Request, appliedBy string, e error) {
+	if e != nil {
+		api.respondGeneric(w, r, e)
+		return
+	}
+	w.Header().Set(appliedByHeader, appliedBy)
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	generalRespnse := NewGeneralResponse(http.StatusOK, "OK")
+	generalRespnse.AppliedBy = appliedBy
+	w.WriteHeader(generalRespnse.StatusCode)
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(generalRespnse)
+	}
+}
+
 // LbCheck responds to LbCheck with HTTP 200
 func (api *APIImpl) LbCheck(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	api.respondGeneric(w, r, nil)
@@ -149,18 +198,62 @@ func (api *APIImpl) respondToCheckRequest(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// Check checks whether a collected metric is within its threshold
-func (api *APIImpl) check(w http.ResponseWriter, r *http.Request, ps httprouter.Params, flags *throttle.CheckFlags) {
-	appName := ps.ByName("app")
-	storeType := ps.ByName("storeType")
-	storeName := ps.ByName("storeName")
+// requestRemoteAddr returns the requesting client's address, sans port
+func requestRemoteAddr(r *http.Request) string {
 	remoteAddr := r.Header.Get("X-Forwarded-For")
 	if remoteAddr == "" {
 		remoteAddr = r.RemoteAddr
 		remoteAddr = strings.Split(remoteAddr, ":")[0]
 	}
+	return remoteAddr
+}
+
+// requestAuditInfo describes the requester of a throttle operation, for auditing. The address audited is that of
+// the connecting peer; X-Forwarded-For is client supplied, hence is recorded separately and never in its stead.
+func requestAuditInfo(r *http.Request, reason string) *base.AuditInfo {
+	remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteAddr = r.RemoteAddr
+	}
+	return &base.AuditInfo{
+		Requester:    requesterIdentity(r),
+		RemoteAddr:   remoteAddr,
+		ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		Reason:       reason,
+	}
+}
+
+// Check checks whether a collected metric is within its threshold
+func (api *APIImpl) check(w http.ResponseWriter, r *http.Request, ps httprouter.Params, flags *throttle.CheckFlags) {
+	api.explainableCheck(w, r, ps, flags, r.URL.Query().Get("explain") == "true")
+}
+
+// explainableCheck checks a metric, and optionally responds with the full decision trace rather than just the check result
+func (api *APIImpl) explainableCheck(w http.ResponseWriter, r *http.Request, ps httprouter.Params, flags *throttle.CheckFlags, explain bool) {
+	appName := ps.ByName("app")
+	storeType := ps.ByName("storeType")
+	storeName := ps.ByName("storeName")
+	remoteAddr := requestRemoteAddr(r)
 	flags.LowPriority = (r.URL.Query().Get("p") == "low")
 
+	if wait := r.URL.Query().Get("wait"); wait != "" && !explain {
+		waitDuration, err := time.ParseDuration(wait)
+		if err != nil {
+			api.respondGeneric(w, r, err)
+			return
+		}
+		api.waitCheck(w, r, appName, storeType, storeName, remoteAddr, flags, waitDuration)
+		return
+	}
+	if explain {
+		explanation := api.throttlerCheck.CheckExplain(appName, storeType, storeName, remoteAddr, flags)
+		checkResult := explanation.CheckResult
+		if checkResult.StatusCode == http.StatusNotFound && flags.OKIfNotExists {
+			explanation.CheckResult = throttle.NewCheckResult(http.StatusOK, checkResult.Value, checkResult.Threshold, checkResult.Error)
+		}
+		api.respondToCheckExplanation(w, r, explanation)
+		return
+	}
 	checkResult := api.throttlerCheck.Check(appName, storeType, storeName, remoteAddr, flags)
 	if checkResult.StatusCode == http.StatusNotFound && flags.OKIfNotExists {
 		checkResult.StatusCode = http.StatusOK // 200
@@ -169,6 +262,21 @@ func (api *APIImpl) check(w http.ResponseWriter, r *http.Request, ps httprouter.
 	api.respondToCheckRequest(w, r, checkResult)
 }
 
+func (api *APIImpl) respondToCheckExplanation(w http.ResponseWriter, r *http.Request, explanation *throttle.CheckExplanation) {
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(explanation.CheckResult.StatusCode)
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(explanation)
+	}
+}
+
+// CheckExplain checks like WriteCheck does, and responds with the full decision trace
+func (api *APIImpl) CheckExplain(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	api.explainableCheck(w, r, ps, &throttle.CheckFlags{}, true)
+}
+
 // WriteCheck
 func (api *APIImpl) WriteCheck(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	api.check(w, r, ps, throttle.StandardCheckFlags)
@@ -232,12 +340,30 @@ func (api *APIImpl) MetricsHealth(w http.ResponseWriter, r *http.Request, ps htt
 	json.NewEncoder(w).Encode(metricsHealth)
 }
 
+// StoreHostMetrics returns the latest per-host metrics of a given store, and their part in its aggregated metric
+func (api *APIImpl) StoreHostMetrics(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	hostMetrics, err := api.throttlerCheck.HostMetrics(ps.ByName("storeType"), ps.ByName("storeName"))
+	if err == base.NoSuchMetricError {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(NewGeneralResponse(http.StatusNotFound, err.Error()))
+		return
+	}
+	if err != nil {
+		api.respondGeneric(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hostMetrics)
+}
+
 // ThrottleApp forcibly marks given app as throttled. Future requests by this app may be denied.
 func (api *APIImpl) ThrottleApp(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	appName := ps.ByName("app")
 	var expireAt time.Time // default zero
 	var ttlMinutes int64
 	var ratio float64
+	var appliedBy string
 	var err error
 	if ps.ByName("ttlMinutes") == "" {
 		ttlMinutes = 0
@@ -248,6 +374,9 @@ func (api *APIImpl) ThrottleApp(w http.ResponseWriter, r *http.Request, ps httpr
 		expireAt = time.Now().Add(time.Duration(ttlMinutes) * time.Minute)
 	}
 	// if ttlMinutes is zero, we keep expireAt as zero, which is handled in a special way
+	if err = base.ValidateAppName(appName); err != nil {
+		goto response
+	}
 	if ps.ByName("ratio") == "" {
 		ratio = -1
 	} else if ratio, err = strconv.ParseFloat(ps.ByName("ratio"), 64); err != nil {
@@ -256,18 +385,91 @@ func (api *APIImpl) ThrottleApp(w http.ResponseWriter, r *http.Request, ps httpr
 		err = fmt.Errorf("ratio must be in [0..1] range; got %+v", ratio)
 		goto response
 	}
-	err = api.consensusService.ThrottleApp(appName, ttlMinutes, expireAt, ratio)
+	appliedBy, err = api.consensusService.ThrottleApp(appName, base.AppThrottleStore{}, ttlMinutes, expireAt, ratio, nil, requestAuditInfo(r, r.URL.Query().Get("reason")))
 
 response:
-	api.respondGeneric(w, r, err)
+	api.respondApplied(w, r, appliedBy, err)
 }
 
 // ThrottleApp unthrottles given app.
 func (api *APIImpl) UnthrottleApp(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	appName := ps.ByName("app")
-	err := api.consensusService.UnthrottleApp(appName)
+	if err := base.ValidateAppName(appName); err != nil {
+		api.respondApplied(w, r, "", err)
+		return
+	}
+	appliedBy, err := api.consensusService.UnthrottleApp(appName, base.AppThrottleStore{}, requestAuditInfo(r, r.URL.Query().Get("reason")))
 
-	api.respondGeneric(w, r, err)
+	api.respondApplied(w, r, appliedBy, err)
+}
+
+// peerManager returns the consensus service as a group.PeerManager, or responds with an error when membership
+// changes are unsupported (e.g. with a MySQL backend)
+func (api *APIImpl) peerManager(w http.ResponseWriter, r *http.Request) (group.PeerManager, bool) {
+	peerManager, ok := api.consensusService.(group.PeerManager)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(NewGeneralResponse(http.StatusNotImplemented, "raft membership is not managed by this consensus service"))
+	}
+	return peerManager, ok
+}
+
+// RaftJoin adds a node to the raft group
+func (api *APIImpl) RaftJoin(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if peerManager, ok := api.peerManager(w, r); ok {
+		appliedBy, err := peerManager.Join(ps.ByName("addr"))
+		api.respondApplied(w, r, appliedBy, err)
+	}
+}
+
+// RaftRemove removes a node from the raft group
+func (api *APIImpl) RaftRemove(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if peerManager, ok := api.peerManager(w, r); ok {
+		appliedBy, err := peerManager.Remove(ps.ByName("addr"))
+		api.respondApplied(w, r, appliedBy, err)
+	}
+}
+
+// RaftPeers lists the raft group members and their last contact
+func (api *APIImpl) RaftPeers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	peerManager, ok := api.peerManager(w, r)
+	if !ok {
+		return
+	}
+	peers, err := peerManager.Peers()
+	if err != nil {
+		api.respondGeneric(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(peers)
+}
+
+// ApplyForwarded applies a consensus write forwarded by a follower. It is only expected to be called
+// by freno nodes.
+func (api *APIImpl) ApplyForwarded(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	forwardedBy := r.Header.Get(group.ForwardedByHeader)
+	applier, ok := api.consensusService.(group.CommandApplier)
+	if !ok || forwardedBy == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&group.ForwardResponse{StatusCode: http.StatusBadRequest, Message: "not a forwarded consensus write, or forwarding unsupported by consensus service"})
+		return
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxForwardedBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&group.ForwardResponse{StatusCode: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+	appliedBy, err := applier.ApplyForwarded(data, forwardedBy)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&group.ForwardResponse{StatusCode: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(&group.ForwardResponse{StatusCode: http.StatusOK, Message: "OK", AppliedBy: appliedBy})
 }
 
 // ThrottledApps returns a snapshot of all currently throttled apps
@@ -277,6 +479,27 @@ func (api *APIImpl) ThrottledApps(w http.ResponseWriter, r *http.Request, ps htt
 	json.NewEncoder(w).Encode(throttledApps)
 }
 
+// ThrottleAudit lists audited throttle operations, optionally filtered by app (`?app=`) and by time (`?since=`).
+// `since` is either an RFC3339 timestamp or a duration (e.g. `24h`) back from now.
+func (api *APIImpl) ThrottleAudit(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var since time.Time
+	if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+		if duration, err := time.ParseDuration(sinceParam); err == nil {
+			since = time.Now().Add(-duration)
+		} else if since, err = time.Parse(time.RFC3339, sinceParam); err != nil {
+			respondJSON(w, http.StatusBadRequest, NewGeneralResponse(http.StatusBadRequest, fmt.Sprintf("cannot parse since: expecting RFC3339 timestamp or duration; got %s", sinceParam)))
+			return
+		}
+	}
+	entries, err := api.consensusService.ThrottleAudit(r.URL.Query().Get("app"), since)
+	if err != nil {
+		api.respondGeneric(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
 // ThrottledApps returns a snapshot of all currently throttled apps
 func (api *APIImpl) RecentApps(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	var err error
@@ -334,6 +557,11 @@ func metricsHandle(w http.ResponseWriter, r *http.Request, p httprouter.Params)
 	handler.ServeHTTP(w, r)
 }
 
+// registerAdmin registers a route which changes freno's state, and is subject to admin authorization
+func registerAdmin(router *httprouter.Router, path string, f httprouter.Handle) {
+	register(router, path, adminOnly(f))
+}
+
 // ConfigureRoutes configures a set of HTTP routes to be actions dispatched by the
 // given api's methods.
 func ConfigureRoutes(api API) *httprouter.Router {
@@ -345,6 +573,7 @@ func ConfigureRoutes(api API) *httprouter.Router {
 	register(router, "/leader-check", api.LeaderCheck)
 	register(router, "/raft/leader", api.ConsensusLeader)
 	register(router, "/raft/state", api.ConsensusState)
+	register(router, "/raft/peers", api.RaftPeers)
 	register(router, "/consensus/leader", api.ConsensusLeader)
 	register(router, "/consensus/state", api.ConsensusState)
 	register(router, "/consensus/status", api.ConsensusStatus)
@@ -354,24 +583,36 @@ func ConfigureRoutes(api API) *httprouter.Router {
 	register(router, "/check-if-exists/:app/:storeType/:storeName", api.WriteCheckIfExists)
 	register(router, "/check-read/:app/:storeType/:storeName/:threshold", api.ReadCheck)
 	register(router, "/check-read-if-exists/:app/:storeType/:storeName/:threshold", api.ReadCheckIfExists)
+	register(router, "/check-explain/:app/:storeType/:storeName", api.CheckExplain)
+	register(router, "/check-stream/:app/:storeType/:storeName", api.CheckStream)
 
 	register(router, "/aggregated-metrics", api.AggregatedMetrics)
 	register(router, "/metrics-health", api.MetricsHealth)
+	register(router, "/metrics/:storeType/:storeName/hosts", api.StoreHostMetrics)
 
-	register(router, "/throttle-app/:app", api.ThrottleApp)
-	register(router, "/throttle-app/:app/ratio/:ratio", api.ThrottleApp)
-	register(router, "/throttle-app/:app/ttl/:ttlMinutes", api.ThrottleApp)
-	register(router, "/throttle-app/:app/ttl/:ttlMinutes/ratio/:ratio", api.ThrottleApp)
-	register(router, "/unthrottle-app/:app", api.UnthrottleApp)
 	register(router, "/throttled-apps", api.ThrottledApps)
+	register(router, "/throttle-audit", api.ThrottleAudit)
 	register(router, "/recent-apps", api.RecentApps)
 	register(router, "/recent-apps/:lastMinutes", api.RecentApps)
 
 	register(router, "/debug/vars", metricsHandle)
 	register(router, "/debug/metrics", metricsHandle)
+	register(router, "/metrics", api.PrometheusMetrics)
 
 	register(router, "/help", api.Help)
 
+	// Admin routes: these change freno's state, and are subject to AdminIdentities/AdminTokens authorization
+	registerAdmin(router, "/throttle-app/:app", api.ThrottleApp)
+	registerAdmin(router, "/throttle-app/:app/ratio/:ratio", api.ThrottleApp)
+	registerAdmin(router, "/throttle-app/:app/ttl/:ttlMinutes", api.ThrottleApp)
+	registerAdmin(router, "/throttle-app/:app/ttl/:ttlMinutes/ratio/:ratio", api.ThrottleApp)
+	registerAdmin(router, "/unthrottle-app/:app", api.UnthrottleApp)
+	registerAdmin(router, "/raft/join/:addr", api.RaftJoin)
+	registerAdmin(router, "/raft/remove/:addr", api.RaftRemove)
+	router.POST(group.ForwardPath, adminOnly(api.ApplyForwarded))
+
+	configureV1Routes(router, api)
+
 	router.GET("/config/memcache", api.MemcacheConfig)
 
 	return router
@@ -1,6 +1,9 @@
 package http
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +11,9 @@ import (
 	"testing"
 
 	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/julienschmidt/httprouter"
 )
 
 func TestLbCheck(t *testing.T) {
@@ -37,6 +43,7 @@ func TestRoutes(t *testing.T) {
 	}{
 		{http.MethodGet, "/lb-check", http.StatusOK},
 		{http.MethodGet, "/config/memcache", http.StatusOK},
+		{http.MethodGet, "/metrics", http.StatusOK},
 	}
 	for _, route := range expectedRoutes {
 		r, _ := http.NewRequest(route.verb, route.path, nil)
@@ -101,3 +108,89 @@ func TestMemcacheConfigWhenDefault(t *testing.T) {
 		t.Errorf("Expected MemcacheConfig body to be %s, but it's %s", expected, body)
 	}
 }
+
+func TestWaitCheck(t *testing.T) {
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttle.NewThrottler()), nil))
+
+	expectedRoutes := []struct {
+		path string
+		code int
+	}{
+		{"/check/app/mysql/nosuchcluster?wait=100ms", http.StatusNotFound},
+		{"/check-if-exists/app/mysql/nosuchcluster?wait=1h", http.StatusOK},
+		{"/check/app/mysql/nosuchcluster?wait=nonsense", http.StatusInternalServerError},
+	}
+	for _, route := range expectedRoutes {
+		r, _ := http.NewRequest(http.MethodGet, route.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if !(w.Code == route.code) {
+			t.Errorf("Route %s failed: code {expected=%d, actual=%d}", route.path, route.code, w.Code)
+		}
+	}
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	settings := config.Settings()
+	settings.AdminTokens = []string{"s3cret"}
+	settings.AdminIdentities = []string{"ops-tooling"}
+	defer func() {
+		settings.AdminTokens = nil
+		settings.AdminIdentities = nil
+	}()
+	handler := adminOnly(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	verifiedCert := func(commonName string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	requests := []struct {
+		description   string
+		authorization string
+		tlsState      *tls.ConnectionState
+		code          int
+	}{
+		{"no credentials", "", nil, http.StatusUnauthorized},
+		{"valid token", "Bearer s3cret", nil, http.StatusOK},
+		{"invalid token", "Bearer guess", nil, http.StatusForbidden},
+		{"allowed identity", "", verifiedCert("ops-tooling"), http.StatusOK},
+		{"unlisted identity", "", verifiedCert("some-app"), http.StatusForbidden},
+		{"unverified certificate", "", &tls.ConnectionState{}, http.StatusUnauthorized},
+	}
+	for _, request := range requests {
+		r, _ := http.NewRequest(http.MethodGet, "/throttle-app/app", nil)
+		if request.authorization != "" {
+			r.Header.Set("Authorization", request.authorization)
+		}
+		r.TLS = request.tlsState
+		w := httptest.NewRecorder()
+		handler(w, r, nil)
+		if w.Code != request.code {
+			t.Errorf("Admin request with %s: code {expected=%d, actual=%d}", request.description, request.code, w.Code)
+		}
+	}
+
+	// Without configured tokens or identities, admin routes are open
+	settings.AdminTokens = nil
+	settings.AdminIdentities = nil
+	r, _ := http.NewRequest(http.MethodGet, "/throttle-app/app", nil)
+	w := httptest.NewRecorder()
+	handler(w, r, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected open admin route to respond with %d status code, but responded with %d", http.StatusOK, w.Code)
+	}
+
+	// Check routes are not subject to admin authorization
+	settings.AdminTokens = []string{"s3cret"}
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttle.NewThrottler()), nil))
+	for path, code := range map[string]int{"/check-if-exists/app/mysql/nosuchcluster": http.StatusOK, "/throttle-app/app": http.StatusUnauthorized} {
+		r, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("Route %s failed: code {expected=%d, actual=%d}", path, code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// The versioned REST API lives under apiV1Prefix, next to the legacy routes
+const apiV1Prefix = "/api/v1"
+
+const maxRequestBodySize = 1 << 16
+
+// ThrottleAppRequest is the JSON body of a POST /api/v1/throttled-apps request. A request fully describes the
+// throttle: omitted TTL and ratio take their defaults, rather than keeping those of an existing throttle.
+type ThrottleAppRequest struct {
+	App       string   `json:"app"`
+	StoreType string   `json:"storeType,omitempty"` // scopes the throttle to a store type; empty for all stores
+	StoreName string   `json:"storeName,omitempty"` // scopes the throttle to a single store of StoreType
+	TTL       string   `json:"ttl,omitempty"`       // a duration, e.g. "30m"; defaults to DefaultThrottleTTLMinutes
+	Ratio     *float64 `json:"ratio,omitempty"`     // [0..1]; defaults to DefaultThrottleRatio
+	Reason    string   `json:"reason,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+}
+
+// AppThrottleResponse is the response of the /api/v1/throttled-apps routes, indicating the resulting throttle
+// of the app, if any. Rule names the rule the throttle comes from, when the app is not throttled by name.
+type AppThrottleResponse struct {
+	GeneralResponse
+	App         string
+	AppThrottle *base.AppThrottle `json:",omitempty"`
+	Rule        string            `json:",omitempty"`
+}
+
+func respondJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// respondAppThrottle responds with an app's throttle. appliedBy is given for consensus writes.
+func respondAppThrottle(w http.ResponseWriter, statusCode int, message string, appliedBy string, appName string, appThrottle *base.AppThrottle) {
+	if appliedBy != "" {
+		w.Header().Set(appliedByHeader, appliedBy)
+	}
+	respondJSON(w, statusCode, &AppThrottleResponse{
+		GeneralResponse: GeneralResponse{StatusCode: statusCode, Message: message, AppliedBy: appliedBy},
+		App:             appName,
+		AppThrottle:     appThrottle,
+	})
+}
+
+// parseThrottleAppRequest reads and validates a throttle request, and computes the resulting throttle
+func parseThrottleAppRequest(r io.Reader) (request *ThrottleAppRequest, ttlMinutes int64, appThrottle *base.AppThrottle, err error) {
+	request = &ThrottleAppRequest{}
+	decoder := json.NewDecoder(io.LimitReader(r, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return nil, 0, nil, fmt.Errorf("cannot parse request: %+v", err)
+	}
+	if request.App == "" {
+		return nil, 0, nil, fmt.Errorf("app must be given")
+	}
+	if err := base.ValidateAppName(request.App); err != nil {
+		return nil, 0, nil, err
+	}
+	store := base.AppThrottleStore{StoreType: request.StoreType, StoreName: request.StoreName}
+	if err := store.Validate(); err != nil {
+		return nil, 0, nil, err
+	}
+	ttl := throttle.DefaultThrottleTTLMinutes * time.Minute
+	if request.TTL != "" {
+		if ttl, err = time.ParseDuration(request.TTL); err != nil {
+			return nil, 0, nil, fmt.Errorf("cannot parse ttl: %+v", err)
+		}
+		if ttl <= 0 {
+			return nil, 0, nil, fmt.Errorf("ttl must be positive; got %s", request.TTL)
+		}
+	}
+	ratio := throttle.DefaultThrottleRatio
+	if request.Ratio != nil {
+		ratio = *request.Ratio
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, 0, nil, fmt.Errorf("ratio must be in [0..1] range; got %+v", ratio)
+	}
+	appThrottle = base.NewAppThrottle(time.Now().Add(ttl), ratio)
+	appThrottle.AppThrottleStore = store
+	appThrottle.AppThrottleMetadata = base.AppThrottleMetadata{Reason: request.Reason, Owner: request.Owner}
+	// The MySQL backend keeps whole minutes
+	ttlMinutes = int64(math.Ceil(ttl.Minutes()))
+	return request, ttlMinutes, appThrottle, nil
+}
+
+// CreateAppThrottle throttles an app as described by a JSON body, and responds with the resulting throttle
+func (api *APIImpl) CreateAppThrottle(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	request, ttlMinutes, appThrottle, err := parseThrottleAppRequest(r.Body)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, NewGeneralResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	metadata := appThrottle.AppThrottleMetadata
+	appliedBy, err := api.consensusService.ThrottleApp(request.App, appThrottle.AppThrottleStore, ttlMinutes, appThrottle.ExpireAt, appThrottle.Ratio, &metadata, requestAuditInfo(r, request.Reason))
+	if err != nil {
+		respondAppThrottle(w, http.StatusInternalServerError, err.Error(), "", request.App, nil)
+		return
+	}
+	respondAppThrottle(w, http.StatusOK, "OK", appliedBy, request.App, appThrottle)
+}
+
+// appNameParam reads the app of the catch-all `*app` route parameter, which may contain slashes
+func appNameParam(ps httprouter.Params) string {
+	return strings.TrimPrefix(ps.ByName("app"), "/")
+}
+
+// storeQuery reads a store scope from the `?storeType=&storeName=` query
+func storeQuery(r *http.Request) (base.AppThrottleStore, error) {
+	store := base.AppThrottleStore{StoreType: r.URL.Query().Get("storeType"), StoreName: r.URL.Query().Get("storeName")}
+	return store, store.Validate()
+}
+
+// appStoreQuery reads the app of the `*app` route parameter, along with the query's store scope. Given
+// `validateApp`, as when the app names a stored key, the app name is validated as well; reads take any app name
+// a check may take.
+func appStoreQuery(r *http.Request, ps httprouter.Params, validateApp bool) (appName string, store base.AppThrottleStore, err error) {
+	appName = appNameParam(ps)
+	if store, err = storeQuery(r); err != nil {
+		return appName, store, err
+	}
+	if validateApp {
+		err = base.ValidateAppName(appName)
+	}
+	return appName, store, err
+}
+
+// GetAppThrottle responds with the throttle in effect for an app, which is either its own or that of the most
+// specific rule matching it, or with 404 when the app is not throttled. Given `?storeType=` and optionally
+// `?storeName=`, it considers throttles scoped to that store as well.
+func (api *APIImpl) GetAppThrottle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	appName, store, err := appStoreQuery(r, ps, false)
+	if err != nil {
+		respondAppThrottle(w, http.StatusBadRequest, err.Error(), "", appName, nil)
+		return
+	}
+	throttledApps := api.consensusService.ThrottledAppsMap()
+	appNames := []string{}
+	for key, appThrottle := range throttledApps {
+		appNames = append(appNames, base.AppThrottleKeyApp(key, appThrottle.AppThrottleStore))
+	}
+	now := time.Now()
+	appThrottle, ruleName := base.ResolveAppThrottle(appName, store, base.NewAppRules(appNames), func(key string) *base.AppThrottle {
+		if appThrottle, ok := throttledApps[key]; ok && appThrottle.ExpireAt.After(now) {
+			return appThrottle
+		}
+		return nil
+	})
+	if appThrottle == nil {
+		respondAppThrottle(w, http.StatusNotFound, "app is not throttled", "", appName, nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, &AppThrottleResponse{
+		GeneralResponse: GeneralResponse{StatusCode: http.StatusOK, Message: "OK"},
+		App:             appName,
+		AppThrottle:     appThrottle,
+		Rule:            ruleName,
+	})
+}
+
+// DeleteAppThrottle unthrottles an app, or removes a rule. Unthrottling an app which is not throttled is not an error.
+// `?storeType=` and optionally `?storeName=` remove the throttle of that scope, keeping the app's other throttles.
+// An optional `?reason=` is audited.
+func (api *APIImpl) DeleteAppThrottle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	appName, store, err := appStoreQuery(r, ps, true)
+	if err != nil {
+		respondAppThrottle(w, http.StatusBadRequest, err.Error(), "", appName, nil)
+		return
+	}
+	appliedBy, err := api.consensusService.UnthrottleApp(appName, store, requestAuditInfo(r, r.URL.Query().Get("reason")))
+	if err != nil {
+		respondAppThrottle(w, http.StatusInternalServerError, err.Error(), "", appName, nil)
+		return
+	}
+	respondAppThrottle(w, http.StatusOK, "OK", appliedBy, appName, nil)
+}
+
+// ThrottleScheduleRequest is the JSON body of a POST /api/v1/throttle-schedules request
+type ThrottleScheduleRequest struct {
+	Name     string   `json:"name"`
+	Cron     string   `json:"cron"`
+	Duration string   `json:"duration"`
+	Apps     []string `json:"apps"`
+	Ratio    *float64 `json:"ratio,omitempty"` // [0..1]; defaults to DefaultThrottleRatio
+	Reason   string   `json:"reason,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+}
+
+// ThrottleScheduleResponse is the response of the /api/v1/throttle-schedules write routes
+type ThrottleScheduleResponse struct {
+	GeneralResponse
+	Name     string
+	Schedule *group.ThrottleScheduleStatus `json:",omitempty"`
+}
+
+func respondThrottleSchedule(w http.ResponseWriter, statusCode int, message string, appliedBy string, name string, schedule *group.ThrottleScheduleStatus) {
+	if appliedBy != "" {
+		w.Header().Set(appliedByHeader, appliedBy)
+	}
+	respondJSON(w, statusCode, &ThrottleScheduleResponse{
+		GeneralResponse: GeneralResponse{StatusCode: statusCode, Message: message, AppliedBy: appliedBy},
+		Name:            name,
+		Schedule:        schedule,
+	})
+}
+
+// parseThrottleScheduleRequest reads and validates a schedule request
+func parseThrottleScheduleRequest(r io.Reader) (*base.ThrottleSchedule, error) {
+	request := &ThrottleScheduleRequest{}
+	decoder := json.NewDecoder(io.LimitReader(r, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return nil, fmt.Errorf("cannot parse request: %+v", err)
+	}
+	schedule := &base.ThrottleSchedule{
+		Name:      request.Name,
+		Cron:      request.Cron,
+		Duration:  request.Duration,
+		Apps:      request.Apps,
+		Ratio:     throttle.DefaultThrottleRatio,
+		Reason:    request.Reason,
+		Owner:     request.Owner,
+		CreatedAt: time.Now(),
+	}
+	if request.Ratio != nil {
+		schedule.Ratio = *request.Ratio
+	}
+	if err := group.ValidateThrottleSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// ThrottleSchedules lists throttle schedules, along with their current or next windows
+func (api *APIImpl) ThrottleSchedules(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	schedules, err := api.consensusService.ThrottleSchedules()
+	if err != nil {
+		api.respondGeneric(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group.NewThrottleScheduleStatuses(schedules, time.Now()))
+}
+
+// CreateThrottleSchedule creates, or replaces, a throttle schedule as described by a JSON body
+func (api *APIImpl) CreateThrottleSchedule(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	schedule, err := parseThrottleScheduleRequest(r.Body)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, NewGeneralResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	appliedBy, err := api.consensusService.CreateThrottleSchedule(schedule)
+	if err != nil {
+		respondThrottleSchedule(w, http.StatusInternalServerError, err.Error(), "", schedule.Name, nil)
+		return
+	}
+	status := group.NewThrottleScheduleStatuses([]base.ThrottleSchedule{*schedule}, time.Now())[0]
+	respondThrottleSchedule(w, http.StatusOK, "OK", appliedBy, schedule.Name, &status)
+}
+
+// DeleteThrottleSchedule cancels a throttle schedule. Throttles applied by the schedule are removed by the leader shortly after.
+func (api *APIImpl) DeleteThrottleSchedule(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	name := ps.ByName("name")
+	appliedBy, err := api.consensusService.DeleteThrottleSchedule(name)
+	if err != nil {
+		respondThrottleSchedule(w, http.StatusInternalServerError, err.Error(), "", name, nil)
+		return
+	}
+	respondThrottleSchedule(w, http.StatusOK, "OK", appliedBy, name, nil)
+}
+
+// AppThresholdRequest is the JSON body of a POST /api/v1/app-thresholds request
+type AppThresholdRequest struct {
+	App       string  `json:"app"`
+	StoreType string  `json:"storeType"`
+	StoreName string  `json:"storeName"`
+	Threshold float64 `json:"threshold"`
+}
+
+// AppThresholdResponse is the response of the /api/v1/app-thresholds routes, indicating the app's threshold, if any
+type AppThresholdResponse struct {
+	GeneralResponse
+	App          string
+	AppThreshold *base.AppThreshold `json:",omitempty"`
+}
+
+// respondAppThreshold responds with an app's threshold. appliedBy is given for consensus writes.
+func respondAppThreshold(w http.ResponseWriter, statusCode int, message string, appliedBy string, appName string, appThreshold *base.AppThreshold) {
+	if appliedBy != "" {
+		w.Header().Set(appliedByHeader, appliedBy)
+	}
+	respondJSON(w, statusCode, &AppThresholdResponse{
+		GeneralResponse: GeneralResponse{StatusCode: statusCode, Message: message, AppliedBy: appliedBy},
+		App:             appName,
+		AppThreshold:    appThreshold,
+	})
+}
+
+// parseAppThresholdRequest reads and validates an app threshold request
+func parseAppThresholdRequest(r io.Reader) (*base.AppThreshold, error) {
+	request := &AppThresholdRequest{}
+	decoder := json.NewDecoder(io.LimitReader(r, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return nil, fmt.Errorf("cannot parse request: %+v", err)
+	}
+	appThreshold := &base.AppThreshold{
+		App:              request.App,
+		Threshold:        request.Threshold,
+		AppThrottleStore: base.AppThrottleStore{StoreType: request.StoreType, StoreName: request.StoreName},
+	}
+	return appThreshold, appThreshold.Validate()
+}
+
+// AppThresholds lists the app thresholds set at runtime along with the configured ones
+func (api *APIImpl) AppThresholds(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.consensusService.AppThresholds())
+}
+
+// CreateAppThreshold sets, or replaces, the threshold of an app or app rule on a store, as described by a JSON body.
+// It beats a configured threshold of the same app and store.
+func (api *APIImpl) CreateAppThreshold(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	appThreshold, err := parseAppThresholdRequest(r.Body)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, NewGeneralResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+	appliedBy, err := api.consensusService.SetAppThreshold(appThreshold.App, appThreshold.AppThrottleStore, appThreshold.Threshold)
+	if err != nil {
+		respondAppThreshold(w, http.StatusInternalServerError, err.Error(), "", appThreshold.App, nil)
+		return
+	}
+	respondAppThreshold(w, http.StatusOK, "OK", appliedBy, appThreshold.App, appThreshold)
+}
+
+// GetAppThreshold responds with the threshold in effect for an app on the store given by `?storeType=&storeName=`,
+// which is either its own or that of the most specific rule matching it, or with 404 when the app has no threshold
+// of its own and checks against the store's threshold
+func (api *APIImpl) GetAppThreshold(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	appName, store, err := appStoreQuery(r, ps, false)
+	if err == nil && store.StoreName == "" {
+		err = fmt.Errorf("storeType and storeName must be given")
+	}
+	if err != nil {
+		respondAppThreshold(w, http.StatusBadRequest, err.Error(), "", appName, nil)
+		return
+	}
+	appThresholds := map[string]*base.AppThreshold{}
+	appNames := []string{}
+	for _, appThreshold := range api.consensusService.AppThresholds() {
+		appThreshold := appThreshold
+		// runtime thresholds are listed before configured ones, which they beat
+		if _, found := appThresholds[appThreshold.Key()]; !found {
+			appThresholds[appThreshold.Key()] = &appThreshold
+		}
+		appNames = append(appNames, appThreshold.App)
+	}
+	appThreshold := base.ResolveAppThreshold(appName, store, base.NewAppRules(appNames), func(key string) *base.AppThreshold {
+		return appThresholds[key]
+	})
+	if appThreshold == nil {
+		respondAppThreshold(w, http.StatusNotFound, "app has no threshold of its own", "", appName, nil)
+		return
+	}
+	respondAppThreshold(w, http.StatusOK, "OK", "", appName, appThreshold)
+}
+
+// DeleteAppThreshold removes the runtime threshold of an app or app rule on the store given by
+// `?storeType=&storeName=`. A configured threshold of the same app and store applies again.
+func (api *APIImpl) DeleteAppThreshold(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	appName, store, err := appStoreQuery(r, ps, true)
+	if err == nil && store.StoreName == "" {
+		err = fmt.Errorf("storeType and storeName must be given")
+	}
+	if err != nil {
+		respondAppThreshold(w, http.StatusBadRequest, err.Error(), "", appName, nil)
+		return
+	}
+	appliedBy, err := api.consensusService.RemoveAppThreshold(appName, store)
+	if err != nil {
+		respondAppThreshold(w, http.StatusInternalServerError, err.Error(), "", appName, nil)
+		return
+	}
+	respondAppThreshold(w, http.StatusOK, "OK", appliedBy, appName, nil)
+}
+
+// OpenAPI serves the OpenAPI document of the /api/v1 routes
+func (api *APIImpl) OpenAPI(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, openAPIDocument)
+}
+
+// configureV1Routes configures the versioned REST API routes
+func configureV1Routes(router *httprouter.Router, api API) {
+	register(router, apiV1Prefix+"/openapi.json", api.OpenAPI)
+	register(router, apiV1Prefix+"/throttled-apps", api.ThrottledApps)
+	// App rules may contain slashes, e.g. prefix:archiver/
+	register(router, apiV1Prefix+"/throttled-apps/*app", api.GetAppThrottle)
+	register(router, apiV1Prefix+"/throttle-audit", api.ThrottleAudit)
+	register(router, apiV1Prefix+"/throttle-schedules", api.ThrottleSchedules)
+	router.POST(apiV1Prefix+"/throttle-schedules", adminOnly(api.CreateThrottleSchedule))
+	router.DELETE(apiV1Prefix+"/throttle-schedules/:name", adminOnly(api.DeleteThrottleSchedule))
+	router.POST(apiV1Prefix+"/throttled-apps", adminOnly(api.CreateAppThrottle))
+	router.DELETE(apiV1Prefix+"/throttled-apps/*app", adminOnly(api.DeleteAppThrottle))
+	register(router, apiV1Prefix+"/app-thresholds", api.AppThresholds)
+	register(router, apiV1Prefix+"/app-thresholds/*app", api.GetAppThreshold)
+	router.POST(apiV1Prefix+"/app-thresholds", adminOnly(api.CreateAppThreshold))
+	router.DELETE(apiV1Prefix+"/app-thresholds/*app", adminOnly(api.DeleteAppThreshold))
+}
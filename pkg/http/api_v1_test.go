@@ -0,0 +1,388 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/group"
+	"github.com/github/freno/pkg/throttle"
+)
+
+// fakeConsensusService applies throttle requests directly onto the throttler, as a single node would
+type fakeConsensusService struct {
+	throttler *throttle.Throttler
+	audit     []base.ThrottleAuditEntry
+	schedules map[string]base.ThrottleSchedule
+}
+
+func (service *fakeConsensusService) ThrottleApp(appName string, store base.AppThrottleStore, ttlMinutes int64, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata, audit *base.AuditInfo) (string, error) {
+	service.throttler.ThrottleAppOnStore(appName, store, expireAt, ratio, metadata)
+	service.audit = append(service.audit, base.ThrottleAuditEntry{Time: time.Now(), Operation: "throttle", App: appName, TTLMinutes: ttlMinutes, Ratio: ratio, AuditInfo: *audit})
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottledAppsMap() map[string](*base.AppThrottle) {
+	return service.throttler.ThrottledAppsMap()
+}
+func (service *fakeConsensusService) UnthrottleApp(appName string, store base.AppThrottleStore, audit *base.AuditInfo) (string, error) {
+	service.throttler.UnthrottleAppOnStore(appName, store)
+	service.audit = append(service.audit, base.ThrottleAuditEntry{Time: time.Now(), Operation: "unthrottle", App: appName, AuditInfo: *audit})
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleAudit(appName string, since time.Time) ([]base.ThrottleAuditEntry, error) {
+	entries := []base.ThrottleAuditEntry{}
+	for _, entry := range service.audit {
+		if (appName == "" || entry.App == appName) && !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+func (service *fakeConsensusService) CreateThrottleSchedule(schedule *base.ThrottleSchedule) (string, error) {
+	if service.schedules == nil {
+		service.schedules = make(map[string]base.ThrottleSchedule)
+	}
+	service.schedules[schedule.Name] = *schedule
+	return "localhost", nil
+}
+func (service *fakeConsensusService) DeleteThrottleSchedule(name string) (string, error) {
+	delete(service.schedules, name)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) ThrottleSchedules() ([]base.ThrottleSchedule, error) {
+	schedules := []base.ThrottleSchedule{}
+	for _, schedule := range service.schedules {
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+func (service *fakeConsensusService) SetThrottleScheduleAppliedWindows(name string, appliedWindows map[string]time.Time) (string, error) {
+	return "localhost", nil
+}
+func (service *fakeConsensusService) SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) (string, error) {
+	service.throttler.SetAppThreshold(appName, store, threshold)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) RemoveAppThreshold(appName string, store base.AppThrottleStore) (string, error) {
+	service.throttler.RemoveAppThreshold(appName, store)
+	return "localhost", nil
+}
+func (service *fakeConsensusService) AppThresholds() []base.AppThreshold {
+	return service.throttler.AppThresholds()
+}
+func (service *fakeConsensusService) RecentAppsMap() map[string](*base.RecentApp) {
+	return service.throttler.RecentAppsMap()
+}
+func (service *fakeConsensusService) IsHealthy() bool             { return true }
+func (service *fakeConsensusService) IsLeader() bool              { return true }
+func (service *fakeConsensusService) GetLeader() string           { return "localhost" }
+func (service *fakeConsensusService) GetStateDescription() string { return "Leader" }
+func (service *fakeConsensusService) GetSharedDomainServices() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (service *fakeConsensusService) GetStatus() *group.ConsensusServiceStatus {
+	return &group.ConsensusServiceStatus{}
+}
+func (service *fakeConsensusService) Monitor() {}
+
+func serveV1(t *testing.T, router http.Handler, method string, path string, body string) (code int, response *AppThrottleResponse) {
+	r, _ := http.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	response = &AppThrottleResponse{}
+	if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+		t.Errorf("%s %s: cannot decode response: %+v", method, path, err)
+	}
+	return w.Code, response
+}
+
+func TestThrottledAppsV1(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+
+	code, response := serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "archiver", "ttl": "30m", "ratio": 0.5, "reason": "backfill", "owner": "data-team"}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected throttle to respond with %d status code, but responded with %d: %+v", http.StatusOK, code, response)
+	}
+	if response.AppliedBy != "localhost" || response.App != "archiver" || response.AppThrottle == nil {
+		t.Fatalf("Unexpected throttle response: %+v", response)
+	}
+	if response.AppThrottle.Ratio != 0.5 || response.AppThrottle.Reason != "backfill" || response.AppThrottle.Owner != "data-team" {
+		t.Errorf("Unexpected resulting throttle: %+v", response.AppThrottle)
+	}
+	if ttl := time.Until(response.AppThrottle.ExpireAt); ttl < 29*time.Minute || ttl > 30*time.Minute {
+		t.Errorf("Expected throttle to expire in 30m, got %+v", ttl)
+	}
+
+	code, response = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/archiver", "")
+	if code != http.StatusOK || response.AppThrottle == nil || response.AppThrottle.Reason != "backfill" {
+		t.Errorf("Unexpected get response: code=%d, %+v", code, response)
+	}
+
+	// Omitted fields take their defaults, replacing the existing throttle's
+	_, response = serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "archiver"}`)
+	if appThrottle := throttler.ThrottledAppsMap()["archiver"]; appThrottle.Ratio != throttle.DefaultThrottleRatio || appThrottle.Reason != "" {
+		t.Errorf("Expected throttle to be replaced, got %+v", appThrottle)
+	}
+
+	code, response = serveV1(t, router, http.MethodDelete, "/api/v1/throttled-apps/archiver", "")
+	if code != http.StatusOK || response.AppliedBy != "localhost" || response.AppThrottle != nil {
+		t.Errorf("Unexpected unthrottle response: code=%d, %+v", code, response)
+	}
+	if code, _ = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/archiver", ""); code != http.StatusNotFound {
+		t.Errorf("Expected unthrottled app to respond with %d status code, but responded with %d", http.StatusNotFound, code)
+	}
+
+	for _, body := range []string{
+		``,
+		`{"ttl": "1h"}`,
+		`{"app": "archiver", "ttl": "soon"}`,
+		`{"app": "archiver", "ttl": "-1h"}`,
+		`{"app": "archiver", "ratio": 1.5}`,
+		`{"app": "archiver", "ttlMinutes": 60}`,
+		`{"app": "regex:archiver("}`,
+	} {
+		if code, _ = serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", body); code != http.StatusBadRequest {
+			t.Errorf("Expected %s to respond with %d status code, but responded with %d", body, http.StatusBadRequest, code)
+		}
+	}
+}
+
+func TestAppRulesV1(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+
+	if code, response := serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "prefix:archiver/", "ratio": 0.5}`); code != http.StatusOK {
+		t.Fatalf("Expected rule to respond with %d status code, but responded with %d: %+v", http.StatusOK, code, response)
+	}
+	code, response := serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/archiver/daily", "")
+	if code != http.StatusOK || response.App != "archiver/daily" || response.Rule != "prefix:archiver/" || response.AppThrottle.Ratio != 0.5 {
+		t.Errorf("Expected app to be throttled by rule, got code=%d, %+v", code, response)
+	}
+	if code, response = serveV1(t, router, http.MethodDelete, "/api/v1/throttled-apps/prefix:archiver/", ""); code != http.StatusOK {
+		t.Errorf("Unexpected rule removal response: code=%d, %+v", code, response)
+	}
+	if code, _ = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/archiver/daily", ""); code != http.StatusNotFound {
+		t.Errorf("Expected app to be unthrottled once its rule is removed, but responded with %d", code)
+	}
+}
+
+func TestAppStoreThrottlesV1(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+
+	code, response := serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "gh-ost", "storeType": "mysql", "storeName": "main7"}`)
+	if code != http.StatusOK || response.AppThrottle == nil || response.AppThrottle.StoreName != "main7" {
+		t.Fatalf("Unexpected store scoped throttle response: code=%d, %+v", code, response)
+	}
+	if _, ok := throttler.ThrottledAppsMap()["gh-ost@mysql/main7"]; !ok {
+		t.Errorf("Expected store scoped throttle, got %+v", throttler.ThrottledAppsMap())
+	}
+	if code, _ = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/gh-ost", ""); code != http.StatusNotFound {
+		t.Errorf("Expected app to be unthrottled on other stores, but responded with %d", code)
+	}
+	if code, _ = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/gh-ost?storeType=mysql&storeName=main7", ""); code != http.StatusOK {
+		t.Errorf("Expected app to be throttled on its store, but responded with %d", code)
+	}
+	if code, _ = serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "gh-ost", "storeName": "main7"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected store name without store type to respond with %d, but responded with %d", http.StatusBadRequest, code)
+	}
+	if code, _ = serveV1(t, router, http.MethodDelete, "/api/v1/throttled-apps/gh-ost?storeType=mysql&storeName=main7", ""); code != http.StatusOK {
+		t.Errorf("Unexpected unthrottle response: code=%d", code)
+	}
+	if _, ok := throttler.ThrottledAppsMap()["gh-ost@mysql/main7"]; ok {
+		t.Errorf("Expected store scoped throttle to be removed")
+	}
+	// "@" separates an app from its store in throttle keys
+	if code, _ = serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "gh-ost@mysql/main7"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected app name with @ to respond with %d, but responded with %d", http.StatusBadRequest, code)
+	}
+	if code, _ = serveV1(t, router, http.MethodDelete, "/api/v1/throttled-apps/gh-ost@mysql", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected app name with @ to respond with %d, but responded with %d", http.StatusBadRequest, code)
+	}
+	// an app name with "@" may be checked, as a plain app
+	serveV1(t, router, http.MethodPost, "/api/v1/throttled-apps", `{"app": "gh-ost", "storeType": "mysql"}`)
+	if code, _ = serveV1(t, router, http.MethodGet, "/api/v1/throttled-apps/gh-ost@mysql", ""); code != http.StatusNotFound {
+		t.Errorf("Expected app name with @ to respond with %d, but responded with %d", http.StatusNotFound, code)
+	}
+}
+
+func TestAppThresholdsV1(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+	serve := func(method string, path string, body string) (code int, response *AppThresholdResponse) {
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		response = &AppThresholdResponse{}
+		if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+			t.Errorf("%s %s: cannot decode response: %+v", method, path, err)
+		}
+		return w.Code, response
+	}
+
+	code, response := serve(http.MethodPost, "/api/v1/app-thresholds", `{"app": "prefix:archiver/", "storeType": "mysql", "storeName": "main7", "threshold": 0.5}`)
+	if code != http.StatusOK || response.AppThreshold == nil || response.AppThreshold.Threshold != 0.5 {
+		t.Fatalf("Unexpected app threshold response: code=%d, %+v", code, response)
+	}
+	code, response = serve(http.MethodGet, "/api/v1/app-thresholds/archiver/daily?storeType=mysql&storeName=main7", "")
+	if code != http.StatusOK || response.AppThreshold == nil || response.AppThreshold.App != "prefix:archiver/" {
+		t.Errorf("Expected app threshold by rule, got code=%d, %+v", code, response)
+	}
+	if code, _ = serve(http.MethodGet, "/api/v1/app-thresholds/archiver/daily?storeType=mysql&storeName=main1", ""); code != http.StatusNotFound {
+		t.Errorf("Expected no app threshold on other stores, but responded with %d", code)
+	}
+	if code, _ = serve(http.MethodGet, "/api/v1/app-thresholds/archiver/daily", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected missing store to respond with %d, but responded with %d", http.StatusBadRequest, code)
+	}
+	for _, body := range []string{
+		`{"app": "archiver", "storeType": "mysql", "threshold": 0.5}`,
+		`{"app": "archiver", "storeType": "mysql", "storeName": "main7"}`,
+		`{"app": "glob:archiver[", "storeType": "mysql", "storeName": "main7", "threshold": 0.5}`,
+	} {
+		if code, _ = serve(http.MethodPost, "/api/v1/app-thresholds", body); code != http.StatusBadRequest {
+			t.Errorf("Expected %s to respond with %d status code, but responded with %d", body, http.StatusBadRequest, code)
+		}
+	}
+	if code, _ = serve(http.MethodDelete, "/api/v1/app-thresholds/prefix:archiver/?storeType=mysql&storeName=main7", ""); code != http.StatusOK {
+		t.Errorf("Unexpected app threshold removal response: code=%d", code)
+	}
+	if appThresholds := throttler.AppThresholds(); len(appThresholds) != 0 {
+		t.Errorf("Expected app threshold to be removed, got %+v", appThresholds)
+	}
+}
+
+// TestOpenAPI validates the OpenAPI document describes routed paths
+func TestOpenAPI(t *testing.T) {
+	router := ConfigureRoutes(new(APIImpl))
+
+	r, _ := http.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	var document struct {
+		Paths map[string]map[string]interface{}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&document); err != nil {
+		t.Fatalf("Cannot parse OpenAPI document: %+v", err)
+	}
+	if len(document.Paths) == 0 {
+		t.Fatalf("Expected OpenAPI document to describe paths")
+	}
+	for path, operations := range document.Paths {
+		routedPath := apiV1Prefix + strings.NewReplacer("{app}", "some-app", "{name}", "some-name").Replace(path)
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if handle, _, _ := router.Lookup(strings.ToUpper(method), routedPath); handle == nil {
+				t.Errorf("OpenAPI document describes %s %s, which is not routed", method, path)
+			}
+		}
+	}
+}
+
+func TestThrottleAudit(t *testing.T) {
+	settings := config.Settings()
+	settings.AdminTokens = []string{"s3cret"}
+	defer func() { settings.AdminTokens = nil }()
+
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+	for _, path := range []string{"/throttle-app/archiver/ttl/30?reason=backfill", "/throttle-app/gh-ost", "/unthrottle-app/archiver"} {
+		r, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer s3cret")
+		r.RemoteAddr = "192.0.2.10:54321"
+		r.Header.Set("X-Forwarded-For", "10.0.0.1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Route %s failed: code {expected=%d, actual=%d}", path, http.StatusOK, w.Code)
+		}
+	}
+
+	expectedEntries := map[string]int{
+		"/throttle-audit":                            3,
+		"/throttle-audit?app=archiver":               2,
+		"/throttle-audit?app=archiver&since=1h":      2,
+		"/throttle-audit?since=2030-01-01T00:00:00Z": 0,
+		"/api/v1/throttle-audit?app=gh-ost":          1,
+	}
+	for path, expected := range expectedEntries {
+		r, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		entries := []base.ThrottleAuditEntry{}
+		if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+			t.Fatalf("%s: cannot decode response: %+v", path, err)
+		}
+		if len(entries) != expected {
+			t.Errorf("%s: expected %d entries, got %d", path, expected, len(entries))
+		}
+		if path == "/throttle-audit?app=archiver" {
+			entry := entries[0]
+			if entry.Operation != "throttle" || entry.TTLMinutes != 30 || entry.Reason != "backfill" || entry.RemoteAddr != "192.0.2.10" || entry.ForwardedFor != "10.0.0.1" || !strings.HasPrefix(entry.Requester, "token:") {
+				t.Errorf("Unexpected audit entry: %+v", entry)
+			}
+			if strings.Contains(entry.Requester, "s3cret") {
+				t.Errorf("Expected token not to be recorded: %+v", entry)
+			}
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/throttle-audit?since=yesterday", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid since to respond with %d status code, but responded with %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestThrottleSchedules(t *testing.T) {
+	throttler := throttle.NewThrottler()
+	router := ConfigureRoutes(NewAPIImpl(throttle.NewThrottlerCheck(throttler), &fakeConsensusService{throttler: throttler}))
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/v1/throttle-schedules", `{"name": "nightly", "cron": "0 2 * * *", "duration": "2h", "apps": ["archiver", "gh-ost:*"], "reason": "backup"}`)
+	response := &ThrottleScheduleResponse{}
+	json.NewDecoder(w.Body).Decode(response)
+	if w.Code != http.StatusOK || response.AppliedBy != "localhost" || response.Schedule == nil {
+		t.Fatalf("Unexpected create response: code=%d, %+v", w.Code, response)
+	}
+	if response.Schedule.Ratio != throttle.DefaultThrottleRatio || response.Schedule.WindowEnd.Sub(response.Schedule.WindowStart) != 2*time.Hour {
+		t.Errorf("Unexpected schedule: %+v", response.Schedule)
+	}
+
+	for _, body := range []string{
+		`{"name": "nightly", "cron": "0 2 * * *", "duration": "2h"}`,
+		`{"name": "nightly", "cron": "nightly", "duration": "2h", "apps": ["archiver"]}`,
+		`{"name": "nightly", "cron": "0 2 * * *", "duration": "2h", "apps": ["archiver"], "ratio": 2}`,
+	} {
+		if w = serve(http.MethodPost, "/api/v1/throttle-schedules", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to respond with %d status code, but responded with %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	statuses := []map[string]interface{}{}
+	json.NewDecoder(serve(http.MethodGet, "/api/v1/throttle-schedules", "").Body).Decode(&statuses)
+	if len(statuses) != 1 || statuses[0]["Name"] != "nightly" {
+		t.Errorf("Unexpected schedules: %+v", statuses)
+	}
+
+	if w = serve(http.MethodDelete, "/api/v1/throttle-schedules/nightly", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected delete to respond with %d status code, but responded with %d", http.StatusOK, w.Code)
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/v1/throttle-schedules", "").Body).Decode(&statuses)
+	if len(statuses) != 0 {
+		t.Errorf("Expected no schedules, got %+v", statuses)
+	}
+}
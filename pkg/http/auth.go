@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/tlsutil"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// bearerToken returns the request's bearer token, if any
+func bearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return strings.TrimSpace(authorization[len(prefix):])
+	}
+	return ""
+}
+
+// clientIdentities returns the identities presented by the client's verified certificate, if any
+func clientIdentities(r *http.Request) []string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return tlsutil.Identities(r.TLS.VerifiedChains[0][0])
+}
+
+// authorizeAdmin checks whether a request may use admin routes; see ConfigurationSettings.AuthorizeAdmin
+func authorizeAdmin(r *http.Request) (authorized bool, credentials bool) {
+	return config.Settings().AuthorizeAdmin(bearerToken(r), clientIdentities(r))
+}
+
+// requesterIdentity identifies the requester for auditing; see ConfigurationSettings.AdminRequester
+func requesterIdentity(r *http.Request) string {
+	return config.Settings().AdminRequester(bearerToken(r), clientIdentities(r))
+}
+
+// adminOnly wraps an admin route's handler with authorization
+func adminOnly(f httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		authorized, credentials := authorizeAdmin(r)
+		if authorized {
+			f(w, r, ps)
+			return
+		}
+		statusCode := http.StatusForbidden
+		if !credentials {
+			statusCode = http.StatusUnauthorized
+			w.Header().Set("WWW-Authenticate", `Bearer realm="freno"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		if r.Method != http.MethodHead {
+			json.NewEncoder(w).Encode(NewGeneralResponse(statusCode, "admin route: unauthorized"))
+		}
+	}
+}
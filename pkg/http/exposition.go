@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/github/freno/pkg/exposition"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var metricLabelNames = []string{"store_type", "store_name"}
+var appLabelNames = []string{"app"}
+var stateLabelNames = []string{"state"}
+
+// splitMetricName splits a "<store-type>/<store-name>" metric name into label values
+func splitMetricName(metricName string) []string {
+	tokens := strings.SplitN(metricName, "/", 2)
+	if len(tokens) != 2 {
+		return []string{metricName, ""}
+	}
+	return tokens
+}
+
+// throttlerFamilies reports the throttler's current state: aggregated metrics and their health
+func (api *APIImpl) throttlerFamilies() (families []*exposition.Family) {
+	aggregatedValue := exposition.NewFamily("freno_aggregated_value", "Latest aggregated value of a metric.", exposition.GaugeType)
+	aggregatedError := exposition.NewFamily("freno_aggregated_error", "Whether the latest aggregation of a metric resulted in an error.", exposition.GaugeType)
+	for metricName, metricResult := range api.throttlerCheck.AggregatedMetrics() {
+		labelValues := splitMetricName(metricName)
+		if value, err := metricResult.Get(); err == nil {
+			aggregatedValue.Add(value, metricLabelNames, labelValues...)
+			aggregatedError.Add(0, metricLabelNames, labelValues...)
+		} else {
+			aggregatedError.Add(1, metricLabelNames, labelValues...)
+		}
+	}
+	secondsSinceHealthy := exposition.NewFamily("freno_metric_seconds_since_healthy", "Seconds since a metric was last checked to be OK.", exposition.GaugeType)
+	for metricName, metricHealth := range api.throttlerCheck.MetricsHealth() {
+		secondsSinceHealthy.Add(float64(metricHealth.SecondsSinceLastHealthy), metricLabelNames, splitMetricName(metricName)...)
+	}
+	return append(families, aggregatedValue, aggregatedError, secondsSinceHealthy)
+}
+
+// consensusFamilies reports throttled apps and consensus state, as seen by the consensus service
+func (api *APIImpl) consensusFamilies() (families []*exposition.Family) {
+	throttledAppRatio := exposition.NewFamily("freno_throttled_app_ratio", "Throttle ratio of an explicitly throttled app.", exposition.GaugeType)
+	throttledAppExpire := exposition.NewFamily("freno_throttled_app_expire_timestamp_seconds", "Expiry time of an explicitly throttled app.", exposition.GaugeType)
+	for appName, appThrottle := range api.consensusService.ThrottledAppsMap() {
+		throttledAppRatio.Add(appThrottle.Ratio, appLabelNames, appName)
+		throttledAppExpire.Add(float64(appThrottle.ExpireAt.Unix()), appLabelNames, appName)
+	}
+	isLeader := exposition.NewFamily("freno_consensus_is_leader", "Whether this node is the leader.", exposition.GaugeType)
+	isLeader.Add(boolToFloat64(api.consensusService.IsLeader()), nil)
+	isHealthy := exposition.NewFamily("freno_consensus_is_healthy", "Whether this node is healthy.", exposition.GaugeType)
+	isHealthy.Add(boolToFloat64(api.consensusService.IsHealthy()), nil)
+	state := exposition.NewFamily("freno_consensus_state", "Consensus state of this node.", exposition.GaugeType)
+	state.Add(1, stateLabelNames, api.consensusService.GetStateDescription())
+
+	return append(families, throttledAppRatio, throttledAppExpire, isLeader, isHealthy, state)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// PrometheusMetrics exposes freno's metrics in Prometheus text format
+func (api *APIImpl) PrometheusMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	families := exposition.DefaultRegistry.Families()
+	if api.throttlerCheck != nil {
+		families = append(families, api.throttlerFamilies()...)
+	}
+	if api.consensusService != nil {
+		families = append(families, api.consensusFamilies()...)
+	}
+	w.Header().Set("Content-Type", exposition.ContentType)
+	if r.Method == http.MethodGet {
+		exposition.Write(w, families...)
+	}
+}
@@ -0,0 +1,440 @@
+package http
+
+// openAPIDocument describes the /api/v1 routes. Keep it in sync with configureV1Routes and the request/response types.
+const openAPIDocument = `{
+  "openapi": "3.1.0",
+  "info": {
+    "title": "freno",
+    "description": "freno's versioned REST API. Legacy routes are documented in doc/http.md.",
+    "version": "v1"
+  },
+  "servers": [
+    {"url": "/api/v1"}
+  ],
+  "paths": {
+    "/throttled-apps": {
+      "get": {
+        "summary": "List throttled apps",
+        "operationId": "listThrottledApps",
+        "responses": {
+          "200": {
+            "description": "Throttled apps, keyed by app name",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "additionalProperties": {"$ref": "#/components/schemas/AppThrottle"}
+                }
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Throttle an app",
+        "description": "Throttles an app, replacing any existing throttle of that app. Applied via consensus.",
+        "operationId": "throttleApp",
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {"$ref": "#/components/schemas/ThrottleAppRequest"}
+            }
+          }
+        },
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThrottle"},
+          "400": {"$ref": "#/components/responses/General"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/AppThrottle"}
+        }
+      }
+    },
+    "/throttled-apps/{app}": {
+      "parameters": [
+        {"name": "app", "in": "path", "required": true, "schema": {"type": "string"}}
+      ],
+      "get": {
+        "summary": "Get the throttle in effect for an app",
+        "description": "The app's own throttle, or else that of the most specific app rule matching it, named by Rule. The app may contain slashes. Given a store, throttles scoped to that store are considered as well.",
+        "operationId": "getAppThrottle",
+        "parameters": [
+          {"name": "storeType", "in": "query", "schema": {"type": "string"}},
+          {"name": "storeName", "in": "query", "schema": {"type": "string"}, "description": "Requires storeType"}
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThrottle"},
+          "404": {"$ref": "#/components/responses/AppThrottle"}
+        }
+      },
+      "delete": {
+        "summary": "Unthrottle an app",
+        "description": "Unthrottles an app, or removes an app rule. Given a store, removes the throttle scoped to that store only. Unthrottling an app which is not throttled is not an error. Applied via consensus.",
+        "operationId": "unthrottleApp",
+        "parameters": [
+          {"name": "storeType", "in": "query", "schema": {"type": "string"}},
+          {"name": "storeName", "in": "query", "schema": {"type": "string"}, "description": "Requires storeType"},
+          {"name": "reason", "in": "query", "schema": {"type": "string"}, "description": "Recorded in the throttle audit"}
+        ],
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThrottle"},
+          "400": {"$ref": "#/components/responses/General"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/AppThrottle"}
+        }
+      }
+    },
+    "/throttle-audit": {
+      "get": {
+        "summary": "List audited throttle and unthrottle operations",
+        "operationId": "listThrottleAudit",
+        "parameters": [
+          {"name": "app", "in": "query", "schema": {"type": "string"}, "description": "Only list operations on this app"},
+          {"name": "since", "in": "query", "schema": {"type": "string"}, "description": "RFC3339 timestamp, or a duration back from now, e.g. \"24h\""}
+        ],
+        "responses": {
+          "200": {
+            "description": "Audited operations, oldest first",
+            "content": {
+              "application/json": {
+                "schema": {"type": "array", "items": {"$ref": "#/components/schemas/ThrottleAuditEntry"}}
+              }
+            }
+          },
+          "400": {"$ref": "#/components/responses/General"}
+        }
+      }
+    },
+    "/throttle-schedules": {
+      "get": {
+        "summary": "List throttle schedules",
+        "description": "Lists schedules along with their current window, if active, or else their next window.",
+        "operationId": "listThrottleSchedules",
+        "responses": {
+          "200": {
+            "description": "Schedules, sorted by name",
+            "content": {
+              "application/json": {
+                "schema": {"type": "array", "items": {"$ref": "#/components/schemas/ThrottleScheduleStatus"}}
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Create or replace a throttle schedule",
+        "description": "While a window is active, the leader throttles the schedule's apps until the window ends. Applied via consensus.",
+        "operationId": "createThrottleSchedule",
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {"$ref": "#/components/schemas/ThrottleScheduleRequest"}
+            }
+          }
+        },
+        "responses": {
+          "200": {"$ref": "#/components/responses/ThrottleSchedule"},
+          "400": {"$ref": "#/components/responses/General"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/ThrottleSchedule"}
+        }
+      }
+    },
+    "/throttle-schedules/{name}": {
+      "parameters": [
+        {"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}
+      ],
+      "delete": {
+        "summary": "Cancel a throttle schedule",
+        "description": "Deletes the schedule. Apps it throttled are unthrottled by the leader shortly after. Applied via consensus.",
+        "operationId": "deleteThrottleSchedule",
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/ThrottleSchedule"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/ThrottleSchedule"}
+        }
+      }
+    },
+    "/app-thresholds": {
+      "get": {
+        "summary": "List app thresholds",
+        "description": "Lists thresholds set at runtime along with those of the AppThresholds configuration, sorted by store then app. A configured threshold is listed even when a runtime threshold of the same app and store overrides it.",
+        "operationId": "listAppThresholds",
+        "responses": {
+          "200": {
+            "description": "App thresholds",
+            "content": {
+              "application/json": {
+                "schema": {"type": "array", "items": {"$ref": "#/components/schemas/AppThreshold"}}
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Set the threshold of an app on a store",
+        "description": "Checks of the app on the store use this threshold rather than the store's ThrottleThreshold. Beats a configured threshold of the same app and store. Applied via consensus.",
+        "operationId": "setAppThreshold",
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {"$ref": "#/components/schemas/AppThresholdRequest"}
+            }
+          }
+        },
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThreshold"},
+          "400": {"$ref": "#/components/responses/General"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/AppThreshold"}
+        }
+      }
+    },
+    "/app-thresholds/{app}": {
+      "parameters": [
+        {"name": "app", "in": "path", "required": true, "schema": {"type": "string"}},
+        {"name": "storeType", "in": "query", "required": true, "schema": {"type": "string"}},
+        {"name": "storeName", "in": "query", "required": true, "schema": {"type": "string"}}
+      ],
+      "get": {
+        "summary": "Get the threshold in effect for an app on a store",
+        "description": "The app's own threshold, or else that of the most specific app rule matching it, named by App. A runtime threshold beats a configured one. 404 when checks of the app use the store's threshold.",
+        "operationId": "getAppThreshold",
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThreshold"},
+          "400": {"$ref": "#/components/responses/General"},
+          "404": {"$ref": "#/components/responses/AppThreshold"}
+        }
+      },
+      "delete": {
+        "summary": "Remove the runtime threshold of an app on a store",
+        "description": "A configured threshold of the same app and store applies again. Removing a threshold which is not set is not an error. Applied via consensus.",
+        "operationId": "removeAppThreshold",
+        "security": [{"bearerAuth": []}, {"clientCertificate": []}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/AppThreshold"},
+          "400": {"$ref": "#/components/responses/General"},
+          "401": {"$ref": "#/components/responses/General"},
+          "403": {"$ref": "#/components/responses/General"},
+          "500": {"$ref": "#/components/responses/AppThreshold"}
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "operationId": "getOpenAPI",
+        "responses": {
+          "200": {
+            "description": "OpenAPI document",
+            "content": {"application/json": {}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearerAuth": {
+        "type": "http",
+        "scheme": "bearer",
+        "description": "One of AdminTokens. Only required when AdminTokens or AdminIdentities are configured."
+      },
+      "clientCertificate": {
+        "type": "mutualTLS",
+        "description": "A client certificate whose identity is in AdminIdentities."
+      }
+    },
+    "schemas": {
+      "ThrottleAppRequest": {
+        "type": "object",
+        "required": ["app"],
+        "additionalProperties": false,
+        "properties": {
+          "app": {"type": "string", "description": "App name, or app rule: prefix:<prefix>, glob:<pattern> or regex:<regexp>", "example": "glob:gh-ost:*"},
+          "storeType": {"type": "string", "description": "Scopes the throttle to the stores of this type. Omit to throttle on all stores", "example": "mysql"},
+          "storeName": {"type": "string", "description": "Scopes the throttle to a single store of storeType", "example": "main7"},
+          "ttl": {"type": "string", "description": "Go duration, e.g. \"30m\" or \"2h\". Defaults to 60m.", "example": "30m"},
+          "ratio": {"type": "number", "minimum": 0, "maximum": 1, "description": "Ratio of checks to reject. Defaults to 1."},
+          "reason": {"type": "string"},
+          "owner": {"type": "string"}
+        }
+      },
+      "AppThrottle": {
+        "type": "object",
+        "properties": {
+          "ExpireAt": {"type": "string", "format": "date-time"},
+          "Ratio": {"type": "number", "minimum": 0, "maximum": 1},
+          "StoreType": {"type": "string", "description": "Omitted when the throttle applies to all stores"},
+          "StoreName": {"type": "string", "description": "Omitted when the throttle applies to all stores of StoreType"},
+          "Reason": {"type": "string"},
+          "Owner": {"type": "string"},
+          "Origin": {"type": "string", "description": "What applied the throttle, e.g. schedule:<name>. Omitted when requested via API"}
+        }
+      },
+      "ThrottleAuditEntry": {
+        "type": "object",
+        "properties": {
+          "Time": {"type": "string", "format": "date-time"},
+          "Operation": {"type": "string", "enum": ["throttle", "unthrottle"]},
+          "App": {"type": "string"},
+          "StoreType": {"type": "string", "description": "Omitted when the operation was not scoped to a store"},
+          "StoreName": {"type": "string"},
+          "TTLMinutes": {"type": "integer", "description": "Omitted when the operation did not set a TTL"},
+          "Ratio": {"type": "number", "description": "Negative when the operation did not set a ratio"},
+          "Requester": {"type": "string", "description": "Client certificate identity, or token fingerprint"},
+          "RemoteAddr": {"type": "string", "description": "Address of the connecting peer"},
+          "ForwardedFor": {"type": "string", "description": "X-Forwarded-For header as sent by the client; unverified"},
+          "Reason": {"type": "string"}
+        }
+      },
+      "ThrottleScheduleRequest": {
+        "type": "object",
+        "required": ["name", "cron", "duration", "apps"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string"},
+          "cron": {"type": "string", "description": "Standard cron expression, evaluated in UTC unless prefixed with CRON_TZ=<zone>, or a descriptor such as @daily", "example": "0 2 * * *"},
+          "duration": {"type": "string", "description": "Go duration of each window", "example": "2h"},
+          "apps": {"type": "array", "items": {"type": "string"}, "description": "App names, or glob patterns matching apps which recently checked"},
+          "ratio": {"type": "number", "minimum": 0, "maximum": 1, "description": "Defaults to 1"},
+          "reason": {"type": "string"},
+          "owner": {"type": "string"}
+        }
+      },
+      "ThrottleScheduleStatus": {
+        "type": "object",
+        "properties": {
+          "Name": {"type": "string"},
+          "Cron": {"type": "string"},
+          "Duration": {"type": "string"},
+          "Apps": {"type": "array", "items": {"type": "string"}},
+          "Ratio": {"type": "number"},
+          "Reason": {"type": "string"},
+          "Owner": {"type": "string"},
+          "CreatedAt": {"type": "string", "format": "date-time"},
+          "Active": {"type": "boolean", "description": "Whether a window is in effect"},
+          "WindowStart": {"type": "string", "format": "date-time", "description": "Start of the current window if active, or else of the next window"},
+          "WindowEnd": {"type": "string", "format": "date-time"}
+        }
+      },
+      "ThrottleScheduleResponse": {
+        "allOf": [
+          {"$ref": "#/components/schemas/GeneralResponse"},
+          {
+            "type": "object",
+            "properties": {
+              "Name": {"type": "string"},
+              "Schedule": {"$ref": "#/components/schemas/ThrottleScheduleStatus"}
+            }
+          }
+        ]
+      },
+      "AppThresholdRequest": {
+        "type": "object",
+        "required": ["app", "storeType", "storeName", "threshold"],
+        "additionalProperties": false,
+        "properties": {
+          "app": {"type": "string", "description": "App name, or app rule: prefix:<prefix>, glob:<pattern> or regex:<regexp>", "example": "prefix:archiver"},
+          "storeType": {"type": "string", "example": "mysql"},
+          "storeName": {"type": "string", "example": "main7"},
+          "threshold": {"type": "number", "exclusiveMinimum": 0, "example": 0.5}
+        }
+      },
+      "AppThreshold": {
+        "type": "object",
+        "properties": {
+          "App": {"type": "string", "description": "App name or app rule"},
+          "Threshold": {"type": "number"},
+          "StoreType": {"type": "string"},
+          "StoreName": {"type": "string"},
+          "Configured": {"type": "boolean", "description": "Read from the AppThresholds configuration. Omitted for thresholds set at runtime"}
+        }
+      },
+      "AppThresholdResponse": {
+        "allOf": [
+          {"$ref": "#/components/schemas/GeneralResponse"},
+          {
+            "type": "object",
+            "properties": {
+              "App": {"type": "string"},
+              "AppThreshold": {"$ref": "#/components/schemas/AppThreshold"}
+            }
+          }
+        ]
+      },
+      "GeneralResponse": {
+        "type": "object",
+        "properties": {
+          "StatusCode": {"type": "integer"},
+          "Message": {"type": "string"},
+          "AppliedBy": {"type": "string", "description": "For consensus writes: the node which applied the change"}
+        }
+      },
+      "AppThrottleResponse": {
+        "allOf": [
+          {"$ref": "#/components/schemas/GeneralResponse"},
+          {
+            "type": "object",
+            "properties": {
+              "App": {"type": "string"},
+              "AppThrottle": {"$ref": "#/components/schemas/AppThrottle"},
+              "Rule": {"type": "string", "description": "The app rule the throttle comes from, when the app is not throttled by name"}
+            }
+          }
+        ]
+      }
+    },
+    "responses": {
+      "General": {
+        "description": "Status and message",
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/GeneralResponse"}}
+        }
+      },
+      "AppThreshold": {
+        "description": "The resulting threshold of the app, if any",
+        "headers": {
+          "X-Freno-Applied-By": {
+            "description": "For consensus writes: the node which applied the change",
+            "schema": {"type": "string"}
+          }
+        },
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/AppThresholdResponse"}}
+        }
+      },
+      "ThrottleSchedule": {
+        "description": "The resulting schedule, if any",
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/ThrottleScheduleResponse"}}
+        }
+      },
+      "AppThrottle": {
+        "description": "The resulting throttle of the app, if any",
+        "headers": {
+          "X-Freno-Applied-By": {
+            "description": "For consensus writes: the node which applied the change",
+            "schema": {"type": "string"}
+          }
+        },
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/AppThrottleResponse"}}
+        }
+      }
+    }
+  }
+}
+`
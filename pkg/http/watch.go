@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/github/freno/pkg/throttle"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const maxCheckWait = 5 * time.Minute
+const checkStreamKeepaliveInterval = 15 * time.Second
+
+// checkPassed returns true when a check is considered OK by the requesting app
+func checkPassed(checkResult *throttle.CheckResult, flags *throttle.CheckFlags) bool {
+	if checkResult.StatusCode == http.StatusNotFound && flags.OKIfNotExists {
+		return true
+	}
+	return checkResult.StatusCode == http.StatusOK
+}
+
+// waitCheck is a long-poll check: it blocks until the check passes, or until `wait` elapses, and responds with the latest check result
+func (api *APIImpl) waitCheck(w http.ResponseWriter, r *http.Request, appName string, storeType string, storeName string, remoteAddr string, flags *throttle.CheckFlags, wait time.Duration) {
+	if wait > maxCheckWait {
+		wait = maxCheckWait
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	var checkResult *throttle.CheckResult
+	api.throttlerCheck.WatchCheck(ctx, appName, storeType, storeName, remoteAddr, flags, func(latestCheckResult *throttle.CheckResult) bool {
+		checkResult = latestCheckResult
+		return !checkPassed(checkResult, flags)
+	})
+	if checkResult.StatusCode == http.StatusNotFound && flags.OKIfNotExists {
+		checkResult = throttle.NewCheckResult(http.StatusOK, checkResult.Value, checkResult.Threshold, checkResult.Error)
+	}
+	api.respondToCheckRequest(w, r, checkResult)
+}
+
+// CheckStream streams check results as server-sent events: the first check result, and then a new one whenever
+// the status code changes. The stream continues until the client disconnects.
+func (api *APIImpl) CheckStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	flusher, ok := w.(http.Flusher)
+	if !ok || r.Method != http.MethodGet {
+		api.check(w, r, ps, &throttle.CheckFlags{})
+		return
+	}
+	flags := &throttle.CheckFlags{LowPriority: (r.URL.Query().Get("p") == "low")}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	var writeMutex sync.Mutex
+	write := func(event string) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		fmt.Fprint(w, event)
+		flusher.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	wg.Add(1)
+	go func() {
+		// keep idle connections from being dropped by proxies
+		defer wg.Done()
+		keepaliveTick := time.NewTicker(checkStreamKeepaliveInterval)
+		defer keepaliveTick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-keepaliveTick.C:
+				write(": keepalive\n\n")
+			}
+		}
+	}()
+	api.throttlerCheck.WatchCheck(ctx, ps.ByName("app"), ps.ByName("storeType"), ps.ByName("storeName"), requestRemoteAddr(r), flags, func(checkResult *throttle.CheckResult) bool {
+		data, err := json.Marshal(checkResult)
+		if err != nil {
+			return false
+		}
+		write(fmt.Sprintf("event: check\ndata: %s\n\n", data))
+		return true
+	})
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package httpmetric
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathToken is a single step in a JSON path: either an object key or an array index
+type pathToken struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses a simple JSONPath-style expression such as `$.queue.depth` or `data.items[0].value`.
+// Supported are object keys (dot separated, or quoted in brackets: `["some.key"]`) and array indexes.
+func parseJSONPath(path string) (tokens []pathToken, err error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return tokens, fmt.Errorf("unterminated bracket in JSON path")
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				tokens = append(tokens, pathToken{key: unquoted})
+				continue
+			}
+			if strings.HasPrefix(inner, "'") && strings.HasSuffix(inner, "'") && len(inner) >= 2 {
+				tokens = append(tokens, pathToken{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return tokens, fmt.Errorf("invalid array index in JSON path: %s", inner)
+			}
+			tokens = append(tokens, pathToken{index: index, isIndex: true})
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			tokens = append(tokens, pathToken{key: path[:end]})
+			path = path[end:]
+		}
+	}
+	return tokens, nil
+}
+
+// toFloat64 interprets a decoded JSON value as a number. Numeric strings are accepted.
+func toFloat64(value interface{}) (float64, error) {
+	switch value := value.(type) {
+	case json.Number:
+		return value.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("value is not a number: %+v", value)
+}
+
+// ExtractValue extracts a numeric value from an HTTP response body. When `jsonPath` is empty,
+// the body is expected to be a plain-text number. Otherwise the body is parsed as JSON, and
+// the value found at `jsonPath` is returned.
+func ExtractValue(body []byte, jsonPath string) (float64, error) {
+	if strings.TrimSpace(jsonPath) == "" {
+		return strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+	}
+	tokens, err := parseJSONPath(jsonPath)
+	if err != nil {
+		return 0, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return 0, err
+	}
+	for _, token := range tokens {
+		if token.isIndex {
+			array, ok := value.([]interface{})
+			if !ok {
+				return 0, fmt.Errorf("JSON path %s: expected array at [%d]", jsonPath, token.index)
+			}
+			if token.index >= len(array) {
+				return 0, fmt.Errorf("JSON path %s: index %d out of range", jsonPath, token.index)
+			}
+			value = array[token.index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("JSON path %s: expected object at %s", jsonPath, token.key)
+		}
+		if value, ok = object[token.key]; !ok {
+			return 0, fmt.Errorf("JSON path %s: key not found: %s", jsonPath, token.key)
+		}
+	}
+	return toFloat64(value)
+}
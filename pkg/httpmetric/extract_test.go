@@ -0,0 +1,59 @@
+package httpmetric
+
+import (
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestExtractValuePlainText(t *testing.T) {
+	{
+		value, err := ExtractValue([]byte("1.25\n"), "")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 1.25)
+	}
+	{
+		_, err := ExtractValue([]byte("ok"), "")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestExtractValueJSON(t *testing.T) {
+	body := []byte(`{"replication": {"lag": 0.7, "hosts": [{"lag": "3"}, {"lag": 4}]}, "dotted.key": {"v": 12}}`)
+	{
+		value, err := ExtractValue(body, "$.replication.lag")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 0.7)
+	}
+	{
+		value, err := ExtractValue(body, "replication.hosts[0].lag")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 3.0)
+	}
+	{
+		value, err := ExtractValue(body, "$.replication.hosts[1].lag")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 4.0)
+	}
+	{
+		value, err := ExtractValue(body, `$["dotted.key"].v`)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 12.0)
+	}
+	{
+		_, err := ExtractValue(body, "$.replication.hosts[2].lag")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ExtractValue(body, "$.replication.missing")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ExtractValue(body, "$.replication")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ExtractValue([]byte("not json"), "$.lag")
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package httpmetric
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/exposition"
+
+	"github.com/patrickmn/go-cache"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+const maxResponseBytes = 1024 * 1024
+
+var httpMetricCache = cache.New(cache.NoExpiration, 10*time.Millisecond)
+
+func getHTTPMetricCacheKey(probe *Probe) string {
+	return fmt.Sprintf("%s:%s", probe.URL, probe.JSONPath)
+}
+
+func cacheHTTPThrottleMetric(probe *Probe, httpThrottleMetric *HTTPThrottleMetric) *HTTPThrottleMetric {
+	if httpThrottleMetric.Err != nil {
+		return httpThrottleMetric
+	}
+	if probe.CacheMillis > 0 {
+		httpMetricCache.Set(getHTTPMetricCacheKey(probe), httpThrottleMetric, time.Duration(probe.CacheMillis)*time.Millisecond)
+	}
+	return httpThrottleMetric
+}
+
+func getCachedHTTPThrottleMetric(probe *Probe) *HTTPThrottleMetric {
+	if probe.CacheMillis == 0 {
+		return nil
+	}
+	if metric, found := httpMetricCache.Get(getHTTPMetricCacheKey(probe)); found {
+		httpThrottleMetric, _ := metric.(*HTTPThrottleMetric)
+		return httpThrottleMetric
+	}
+	return nil
+}
+
+type HTTPThrottleMetric struct {
+	ClusterName string
+	URL         string
+	Value       float64
+	Err         error
+	Timestamp   time.Time // when probed
+}
+
+func NewHTTPThrottleMetric() *HTTPThrottleMetric {
+	return &HTTPThrottleMetric{Value: 0}
+}
+
+func (metric *HTTPThrottleMetric) GetClusterProbeKey() ClusterProbeKey {
+	return GetClusterProbeKey(metric.ClusterName, metric.URL)
+}
+
+func (metric *HTTPThrottleMetric) Get() (float64, error) {
+	return metric.Value, metric.Err
+}
+
+// ReadThrottleMetric issues a GET request to the probe's URL and extracts a numeric value off the response
+func ReadThrottleMetric(probe *Probe, clusterName string) (httpThrottleMetric *HTTPThrottleMetric) {
+	if httpThrottleMetric := getCachedHTTPThrottleMetric(probe); httpThrottleMetric != nil {
+		return httpThrottleMetric
+		// On cached results we avoid taking latency metrics
+	}
+
+	started := time.Now()
+	httpThrottleMetric = NewHTTPThrottleMetric()
+	httpThrottleMetric.Timestamp = started
+	httpThrottleMetric.ClusterName = clusterName
+	httpThrottleMetric.URL = probe.URL
+
+	defer func(metric *HTTPThrottleMetric, started time.Time) {
+		go func() {
+			metrics.GetOrRegisterTimer("probes.http.latency", nil).Update(time.Since(started))
+			exposition.ObserveProbe("http", metric.ClusterName, metric.URL, time.Since(started).Seconds(), metric.Err)
+			metrics.GetOrRegisterCounter("probes.http.total", nil).Inc(1)
+			if metric.Err != nil {
+				metrics.GetOrRegisterCounter("probes.http.error", nil).Inc(1)
+			}
+		}()
+	}(httpThrottleMetric, started)
+
+	httpClient := base.SharedHttpClient(time.Duration(probe.TimeoutMillis) * time.Millisecond)
+	resp, err := httpClient.Get(probe.URL)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// unwrap, so that e.g. "dial tcp" errors are identified as such
+			err = urlErr.Err
+		}
+		httpThrottleMetric.Err = err
+		return httpThrottleMetric
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		httpThrottleMetric.Err = fmt.Errorf("%s returned HTTP %d", probe.URL, resp.StatusCode)
+		return httpThrottleMetric
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		httpThrottleMetric.Err = err
+		return httpThrottleMetric
+	}
+	if httpThrottleMetric.Value, httpThrottleMetric.Err = ExtractValue(body, probe.JSONPath); httpThrottleMetric.Err != nil {
+		return httpThrottleMetric
+	}
+	return cacheHTTPThrottleMetric(probe, httpThrottleMetric)
+}
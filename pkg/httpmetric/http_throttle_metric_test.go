@@ -0,0 +1,74 @@
+package httpmetric
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestReadThrottleMetric(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			fmt.Fprint(w, `{"lag": 2.5}`)
+		case "/text":
+			fmt.Fprint(w, "7")
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	{
+		metric := ReadThrottleMetric(&Probe{URL: server.URL + "/json", JSONPath: "$.lag", TimeoutMillis: 1000}, "c0")
+		value, err := metric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 2.5)
+		test.S(t).ExpectEquals(metric.GetClusterProbeKey(), GetClusterProbeKey("c0", server.URL+"/json"))
+	}
+	{
+		metric := ReadThrottleMetric(&Probe{URL: server.URL + "/text", TimeoutMillis: 1000}, "c0")
+		value, err := metric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 7.0)
+	}
+	{
+		metric := ReadThrottleMetric(&Probe{URL: server.URL + "/missing", TimeoutMillis: 1000}, "c0")
+		_, err := metric.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReadThrottleMetricDialError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	metric := ReadThrottleMetric(&Probe{URL: url, TimeoutMillis: 1000}, "c0")
+	_, err := metric.Get()
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(base.IsDialTcpError(err))
+	test.S(t).ExpectTrue(strings.HasPrefix(err.Error(), "dial tcp"))
+}
+
+func TestReadThrottleMetricCache(t *testing.T) {
+	value := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, value)
+	}))
+	defer server.Close()
+
+	probe := &Probe{URL: server.URL, TimeoutMillis: 1000, CacheMillis: 60000}
+	metric := ReadThrottleMetric(probe, "c0")
+	test.S(t).ExpectEquals(metric.Value, 1.0)
+
+	value = 2
+	metric = ReadThrottleMetric(probe, "c0")
+	test.S(t).ExpectEquals(metric.Value, 1.0)
+}
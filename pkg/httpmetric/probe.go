@@ -0,0 +1,73 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package httpmetric
+
+import (
+	"fmt"
+
+	"github.com/github/freno/pkg/base"
+)
+
+// Probe is the minimal configuration required to read a metric off an HTTP endpoint
+type Probe struct {
+	URL             string
+	JSONPath        string
+	TimeoutMillis   int
+	CacheMillis     int
+	QueryInProgress int64
+}
+
+type Probes map[string](*Probe) // URL -> probe
+
+type ClusterProbes struct {
+	ClusterName          string
+	IgnoreHostsCount     int
+	IgnoreHostsThreshold float64
+	AggregationMode      base.AggregationMode
+	InstanceProbes       *Probes
+}
+
+func NewProbes() *Probes {
+	return &Probes{}
+}
+
+func (this *Probe) String() string {
+	return fmt.Sprintf("%s, path=%s", this.URL, this.JSONPath)
+}
+
+type ClusterProbeKey struct {
+	ClusterName string
+	URL         string
+}
+
+func GetClusterProbeKey(clusterName string, url string) ClusterProbeKey {
+	return ClusterProbeKey{ClusterName: clusterName, URL: url}
+}
+
+func (c ClusterProbeKey) HashCode() string {
+	return fmt.Sprintf("%s:%s", c.ClusterName, c.URL)
+}
+
+type ProbeMetricResultMap map[ClusterProbeKey]base.MetricResult
+
+type HTTPInventory struct {
+	ClustersProbes       map[string](*Probes)
+	IgnoreHostsCount     map[string]int
+	IgnoreHostsThreshold map[string]float64
+	AggregationModes     map[string]base.AggregationMode
+	ProbeMetrics         ProbeMetricResultMap
+}
+
+func NewHTTPInventory() *HTTPInventory {
+	inventory := &HTTPInventory{
+		ClustersProbes:       make(map[string](*Probes)),
+		IgnoreHostsCount:     make(map[string]int),
+		IgnoreHostsThreshold: make(map[string]float64),
+		AggregationModes:     make(map[string]base.AggregationMode),
+		ProbeMetrics:         make(map[ClusterProbeKey]base.MetricResult),
+	}
+	return inventory
+}
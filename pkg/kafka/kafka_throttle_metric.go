@@ -0,0 +1,113 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/exposition"
+
+	"github.com/patrickmn/go-cache"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+var kafkaMetricCache = cache.New(cache.NoExpiration, 10*time.Millisecond)
+
+func getKafkaMetricCacheKey(probe *Probe) string {
+	return fmt.Sprintf("%s:%s:%s:%s", strings.Join(probe.Brokers, ","), probe.GroupID, strings.Join(probe.Topics, ","), probe.Aggregation)
+}
+
+func cacheKafkaThrottleMetric(probe *Probe, kafkaThrottleMetric *KafkaThrottleMetric) *KafkaThrottleMetric {
+	if kafkaThrottleMetric.Err != nil {
+		return kafkaThrottleMetric
+	}
+	if probe.CacheMillis > 0 {
+		kafkaMetricCache.Set(getKafkaMetricCacheKey(probe), kafkaThrottleMetric, time.Duration(probe.CacheMillis)*time.Millisecond)
+	}
+	return kafkaThrottleMetric
+}
+
+func getCachedKafkaThrottleMetric(probe *Probe) *KafkaThrottleMetric {
+	if probe.CacheMillis == 0 {
+		return nil
+	}
+	if metric, found := kafkaMetricCache.Get(getKafkaMetricCacheKey(probe)); found {
+		kafkaThrottleMetric, _ := metric.(*KafkaThrottleMetric)
+		return kafkaThrottleMetric
+	}
+	return nil
+}
+
+type KafkaThrottleMetric struct {
+	GroupName string
+	Value     float64
+	Err       error
+}
+
+func NewKafkaThrottleMetric() *KafkaThrottleMetric {
+	return &KafkaThrottleMetric{Value: 0}
+}
+
+func (metric *KafkaThrottleMetric) Get() (float64, error) {
+	return metric.Value, metric.Err
+}
+
+// AggregateLag reduces per-partition lag into a single value, by given aggregation
+func AggregateLag(offsets []PartitionOffsets, aggregation string) (float64, error) {
+	var aggregated int64
+	for _, partitionOffsets := range offsets {
+		lag := partitionOffsets.Lag()
+		switch aggregation {
+		case AggregationMax, "":
+			if lag > aggregated {
+				aggregated = lag
+			}
+		case AggregationSum:
+			aggregated += lag
+		default:
+			return 0, fmt.Errorf("unknown Aggregation: %s", aggregation)
+		}
+	}
+	return float64(aggregated), nil
+}
+
+// ReadThrottleMetric reads a consumer group's lag via given reader
+func ReadThrottleMetric(probe *Probe, groupName string, reader OffsetsReader) (kafkaThrottleMetric *KafkaThrottleMetric) {
+	if kafkaThrottleMetric := getCachedKafkaThrottleMetric(probe); kafkaThrottleMetric != nil {
+		return kafkaThrottleMetric
+		// On cached results we avoid taking latency metrics
+	}
+
+	started := time.Now()
+	kafkaThrottleMetric = NewKafkaThrottleMetric()
+	kafkaThrottleMetric.GroupName = groupName
+
+	defer func(metric *KafkaThrottleMetric, started time.Time) {
+		go func() {
+			metrics.GetOrRegisterTimer("probes.kafka.latency", nil).Update(time.Since(started))
+			exposition.ObserveProbe("kafka", metric.GroupName, "", time.Since(started).Seconds(), metric.Err)
+			metrics.GetOrRegisterCounter("probes.kafka.total", nil).Inc(1)
+			if metric.Err != nil {
+				metrics.GetOrRegisterCounter("probes.kafka.error", nil).Inc(1)
+			}
+		}()
+	}(kafkaThrottleMetric, started)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(probe.TimeoutMillis)*time.Millisecond)
+	defer cancel()
+	offsets, err := reader.ReadOffsets(ctx, probe.GroupID, probe.Topics)
+	if err != nil {
+		kafkaThrottleMetric.Err = err
+		return kafkaThrottleMetric
+	}
+	if kafkaThrottleMetric.Value, kafkaThrottleMetric.Err = AggregateLag(offsets, probe.Aggregation); kafkaThrottleMetric.Err != nil {
+		return kafkaThrottleMetric
+	}
+	return cacheKafkaThrottleMetric(probe, kafkaThrottleMetric)
+}
@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+type fakeOffsetsReader struct {
+	offsets []PartitionOffsets
+	err     error
+	reads   int
+}
+
+func (reader *fakeOffsetsReader) ReadOffsets(ctx context.Context, groupID string, topics []string) ([]PartitionOffsets, error) {
+	reader.reads++
+	return reader.offsets, reader.err
+}
+
+var testOffsets = []PartitionOffsets{
+	{Topic: "events", Partition: 0, Committed: 100, HighWatermark: 150},
+	{Topic: "events", Partition: 1, Committed: 200, HighWatermark: 230},
+	{Topic: "events", Partition: 2, Committed: -1, LogStart: 880, HighWatermark: 900},
+	{Topic: "audit", Partition: 0, Committed: 10, HighWatermark: 10},
+}
+
+func TestPartitionOffsetsLag(t *testing.T) {
+	test.S(t).ExpectEquals(testOffsets[0].Lag(), int64(50))
+	test.S(t).ExpectEquals(testOffsets[3].Lag(), int64(0))
+	// no committed offset: lagging by the entire log
+	test.S(t).ExpectEquals(testOffsets[2].Lag(), int64(20))
+	uncommitted := PartitionOffsets{Committed: -1, LogStart: 0, HighWatermark: 300}
+	test.S(t).ExpectEquals(uncommitted.Lag(), int64(300))
+	unknownLogStart := PartitionOffsets{Committed: -1, LogStart: -1, HighWatermark: 300}
+	test.S(t).ExpectEquals(unknownLogStart.Lag(), int64(300))
+	ahead := PartitionOffsets{Committed: 12, HighWatermark: 10}
+	test.S(t).ExpectEquals(ahead.Lag(), int64(0))
+}
+
+func TestAggregateLag(t *testing.T) {
+	{
+		lag, err := AggregateLag(testOffsets, AggregationMax)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(lag, 50.0)
+	}
+	{
+		lag, err := AggregateLag(testOffsets, AggregationSum)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(lag, 100.0)
+	}
+	{
+		lag, err := AggregateLag(nil, AggregationSum)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(lag, 0.0)
+	}
+	{
+		_, err := AggregateLag(testOffsets, "avg")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReadThrottleMetric(t *testing.T) {
+	{
+		reader := &fakeOffsetsReader{offsets: testOffsets}
+		metric := ReadThrottleMetric(&Probe{GroupID: "backfill", Topics: []string{"events"}, Aggregation: AggregationSum, TimeoutMillis: 1000}, "backfill", reader)
+		value, err := metric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 100.0)
+	}
+	{
+		reader := &fakeOffsetsReader{err: errors.New("coordinator not available")}
+		metric := ReadThrottleMetric(&Probe{GroupID: "backfill", Topics: []string{"events"}, TimeoutMillis: 1000}, "backfill", reader)
+		_, err := metric.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReadThrottleMetricCache(t *testing.T) {
+	reader := &fakeOffsetsReader{offsets: testOffsets}
+	probe := &Probe{GroupID: "cached", Topics: []string{"events"}, Aggregation: AggregationMax, TimeoutMillis: 1000, CacheMillis: 60000}
+	{
+		metric := ReadThrottleMetric(probe, "cached", reader)
+		test.S(t).ExpectEquals(metric.Value, 50.0)
+	}
+	reader.offsets = nil
+	{
+		metric := ReadThrottleMetric(probe, "cached", reader)
+		test.S(t).ExpectEquals(metric.Value, 50.0)
+	}
+	test.S(t).ExpectEquals(reader.reads, 1)
+}
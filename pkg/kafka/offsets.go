@@ -0,0 +1,144 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// PartitionOffsets describes a consumer group's progress on a single partition
+type PartitionOffsets struct {
+	Topic         string
+	Partition     int
+	Committed     int64 // negative when the group has not committed an offset on this partition
+	LogStart      int64 // the partition's first available offset
+	HighWatermark int64
+}
+
+// Lag returns the number of messages the group has yet to consume on this partition. On a partition with no
+// committed offset the group has consumed nothing, hence lags by the entire log, from its start.
+func (offsets *PartitionOffsets) Lag() int64 {
+	committed := offsets.Committed
+	if committed < 0 {
+		committed = offsets.LogStart
+	}
+	if committed < 0 {
+		committed = 0
+	}
+	if lag := offsets.HighWatermark - committed; lag > 0 {
+		return lag
+	}
+	return 0
+}
+
+// OffsetsReader reads committed offsets and high watermarks of a consumer group's topics
+type OffsetsReader interface {
+	ReadOffsets(ctx context.Context, groupID string, topics []string) ([]PartitionOffsets, error)
+}
+
+// clientOffsetsReader reads offsets off a Kafka cluster via the kafka-go client
+type clientOffsetsReader struct {
+	client *kafkago.Client
+}
+
+var offsetsReaders = make(map[string]OffsetsReader)
+var offsetsReadersMutex sync.Mutex
+
+// GetOffsetsReader returns a shared reader per brokers list & timeout
+func GetOffsetsReader(brokers []string, timeout time.Duration) OffsetsReader {
+	offsetsReadersMutex.Lock()
+	defer offsetsReadersMutex.Unlock()
+
+	key := fmt.Sprintf("%s:%s", strings.Join(brokers, ","), timeout)
+	if reader, ok := offsetsReaders[key]; ok {
+		return reader
+	}
+	reader := &clientOffsetsReader{
+		client: &kafkago.Client{
+			Addr:    kafkago.TCP(brokers...),
+			Timeout: timeout,
+		},
+	}
+	offsetsReaders[key] = reader
+	return reader
+}
+
+func (reader *clientOffsetsReader) ReadOffsets(ctx context.Context, groupID string, topics []string) (offsets []PartitionOffsets, err error) {
+	metadata, err := reader.client.Metadata(ctx, &kafkago.MetadataRequest{Topics: topics})
+	if err != nil {
+		return offsets, err
+	}
+	topicPartitions := make(map[string][]int)
+	listOffsetsTopics := make(map[string][]kafkago.OffsetRequest)
+	for _, topic := range metadata.Topics {
+		if topic.Error != nil {
+			return offsets, fmt.Errorf("topic %s: %+v", topic.Name, topic.Error)
+		}
+		for _, partition := range topic.Partitions {
+			topicPartitions[topic.Name] = append(topicPartitions[topic.Name], partition.ID)
+			listOffsetsTopics[topic.Name] = append(listOffsetsTopics[topic.Name], kafkago.FirstOffsetOf(partition.ID), kafkago.LastOffsetOf(partition.ID))
+		}
+	}
+	if len(topicPartitions) == 0 {
+		return offsets, fmt.Errorf("no partitions found for topics %s", strings.Join(topics, ","))
+	}
+
+	// Committed offsets are read first, and high watermarks next, so that we err on the side of greater lag
+	committed, err := reader.client.OffsetFetch(ctx, &kafkago.OffsetFetchRequest{GroupID: groupID, Topics: topicPartitions})
+	if err != nil {
+		return offsets, err
+	}
+	if committed.Error != nil {
+		return offsets, fmt.Errorf("group %s: %+v", groupID, committed.Error)
+	}
+	logOffsets, err := reader.client.ListOffsets(ctx, &kafkago.ListOffsetsRequest{Topics: listOffsetsTopics})
+	if err != nil {
+		return offsets, err
+	}
+
+	committedOffsets := make(map[string]map[int]int64)
+	for topic, partitions := range committed.Topics {
+		committedOffsets[topic] = make(map[int]int64)
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				return offsets, fmt.Errorf("group %s, topic %s, partition %d: %+v", groupID, topic, partition.Partition, partition.Error)
+			}
+			committedOffsets[topic][partition.Partition] = partition.CommittedOffset
+		}
+	}
+	for topic, partitions := range logOffsets.Topics {
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				return offsets, fmt.Errorf("topic %s, partition %d: %+v", topic, partition.Partition, partition.Error)
+			}
+			partitionOffsets := PartitionOffsets{
+				Topic:         topic,
+				Partition:     partition.Partition,
+				Committed:     -1,
+				LogStart:      partition.FirstOffset,
+				HighWatermark: partition.LastOffset,
+			}
+			if committedOffset, ok := committedOffsets[topic][partition.Partition]; ok {
+				partitionOffsets.Committed = committedOffset
+			}
+			offsets = append(offsets, partitionOffsets)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		if offsets[i].Topic != offsets[j].Topic {
+			return offsets[i].Topic < offsets[j].Topic
+		}
+		return offsets[i].Partition < offsets[j].Partition
+	})
+	return offsets, nil
+}
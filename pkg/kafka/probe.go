@@ -0,0 +1,46 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// AggregationMax takes the greatest lag across the group's partitions
+	AggregationMax = "max"
+	// AggregationSum sums the lag across the group's partitions
+	AggregationSum = "sum"
+)
+
+// Probe is the minimal configuration required to read a consumer group's lag
+type Probe struct {
+	GroupID         string
+	Topics          []string
+	Brokers         []string
+	Aggregation     string
+	TimeoutMillis   int
+	CacheMillis     int
+	QueryInProgress int64
+}
+
+func (this *Probe) String() string {
+	return fmt.Sprintf("%s, topics=%s", this.GroupID, strings.Join(this.Topics, ","))
+}
+
+type KafkaInventory struct {
+	GroupsProbes  map[string](*Probe)
+	GroupsMetrics map[string](*KafkaThrottleMetric)
+}
+
+func NewKafkaInventory() *KafkaInventory {
+	inventory := &KafkaInventory{
+		GroupsProbes:  make(map[string](*Probe)),
+		GroupsMetrics: make(map[string](*KafkaThrottleMetric)),
+	}
+	return inventory
+}
@@ -30,6 +30,7 @@ type MySQLInventory struct {
 	ClustersProbes            map[string](*Probes)
 	IgnoreHostsCount          map[string]int
 	IgnoreHostsThreshold      map[string]float64
+	AggregationModes          map[string]base.AggregationMode
 	InstanceKeyMetrics        InstanceMetricResultMap
 	ClusterInstanceHttpChecks ClusterInstanceHttpCheckResultMap
 }
@@ -39,6 +40,7 @@ func NewMySQLInventory() *MySQLInventory {
 		ClustersProbes:            make(map[string](*Probes)),
 		IgnoreHostsCount:          make(map[string]int),
 		IgnoreHostsThreshold:      make(map[string]float64),
+		AggregationModes:          make(map[string]base.AggregationMode),
 		InstanceKeyMetrics:        make(map[ClusterInstanceKey]base.MetricResult),
 		ClusterInstanceHttpChecks: make(map[string]int),
 	}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/github/freno/pkg/exposition"
+
 	"github.com/outbrain/golib/sqlutils"
 	"github.com/patrickmn/go-cache"
 	metrics "github.com/rcrowley/go-metrics"
@@ -47,6 +49,7 @@ type MySQLThrottleMetric struct {
 	Key         InstanceKey
 	Value       float64
 	Err         error
+	Timestamp   time.Time // when probed
 }
 
 func NewMySQLThrottleMetric() *MySQLThrottleMetric {
@@ -75,12 +78,14 @@ func ReadThrottleMetric(probe *Probe, clusterName string) (mySQLThrottleMetric *
 
 	started := time.Now()
 	mySQLThrottleMetric = NewMySQLThrottleMetric()
+	mySQLThrottleMetric.Timestamp = started
 	mySQLThrottleMetric.ClusterName = clusterName
 	mySQLThrottleMetric.Key = probe.Key
 
 	defer func(metric *MySQLThrottleMetric, started time.Time) {
 		go func() {
 			metrics.GetOrRegisterTimer("probes.latency", nil).Update(time.Since(started))
+			exposition.ObserveProbe("mysql", metric.ClusterName, metric.Key.StringCode(), time.Since(started).Seconds(), metric.Err)
 			metrics.GetOrRegisterCounter("probes.total", nil).Inc(1)
 			if metric.Err != nil {
 				metrics.GetOrRegisterCounter("probes.error", nil).Inc(1)
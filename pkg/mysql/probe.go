@@ -8,6 +8,8 @@ package mysql
 import (
 	"fmt"
 	"net"
+
+	"github.com/github/freno/pkg/base"
 )
 
 const maxPoolConnections = 3
@@ -33,6 +35,7 @@ type ClusterProbes struct {
 	ClusterName          string
 	IgnoreHostsCount     int
 	IgnoreHostsThreshold float64
+	AggregationMode      base.AggregationMode
 	InstanceProbes       *Probes
 }
 
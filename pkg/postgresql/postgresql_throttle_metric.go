@@ -0,0 +1,70 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	_ "github.com/lib/pq"
+)
+
+// DefaultMetricQuery reports replication lag, in seconds, on a streaming replica. A replica which has
+// replayed all WAL it received is considered to have no lag, so that an idle primary does not
+// cause lag to grow. On a primary (not in recovery) the lag is 0.
+const DefaultMetricQuery = `select
+	case
+		when not pg_is_in_recovery() then 0
+		when pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() then 0
+		else coalesce(extract(epoch from (now() - pg_last_xact_replay_timestamp())), 0)
+	end as lag`
+
+var knownDBs = make(map[string]*sql.DB)
+var knownDBsMutex sync.Mutex
+
+// getDB returns a (cached) connection pool for given uri
+func getDB(dbUri string) (*sql.DB, error) {
+	knownDBsMutex.Lock()
+	defer knownDBsMutex.Unlock()
+
+	if db, ok := knownDBs[dbUri]; ok {
+		return db, nil
+	}
+	db, err := sql.Open("postgres", dbUri)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxPoolConnections)
+	db.SetMaxIdleConns(maxIdleConnections)
+	knownDBs[dbUri] = db
+	return db, nil
+}
+
+// ReadMetric returns replication lag off the probed server; either by explicit query or via DefaultMetricQuery
+func (this *Probe) ReadMetric() (float64, error) {
+	db, err := getDB(this.GetDBUri())
+	if err != nil {
+		return 0, err
+	}
+
+	metricQuery := this.MetricQuery
+	if metricQuery == "" {
+		metricQuery = DefaultMetricQuery
+	}
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(metricQuery)), "select") {
+		return 0, fmt.Errorf("Unsupported metrics query type: %s", metricQuery)
+	}
+	var value sql.NullFloat64
+	if err := db.QueryRow(metricQuery).Scan(&value); err != nil {
+		return 0, err
+	}
+	if !value.Valid {
+		return 0, fmt.Errorf("metric query returned NULL on %s", this.Key.StringCode())
+	}
+	return value.Float64, nil
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package postgresql
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/github/freno/pkg/hostprobe"
+)
+
+const (
+	DefaultPostgreSQLPort = 5432
+)
+
+const maxPoolConnections = 3
+const maxIdleConnections = 3
+const timeoutSeconds = 1
+
+// Probe is the minimal configuration required to connect to a PostgreSQL server
+type Probe struct {
+	hostprobe.Probe
+	User        string
+	Password    string
+	Database    string
+	SSLMode     string
+	MetricQuery string
+}
+
+func NewProbe() *Probe {
+	config := &Probe{
+		Probe: hostprobe.Probe{Key: hostprobe.InstanceKey{}},
+	}
+	return config
+}
+
+func (this *Probe) String() string {
+	return fmt.Sprintf("%s, user=%s", this.Key.StringCode(), this.User)
+}
+
+func (this *Probe) CacheKey() string {
+	return fmt.Sprintf("%s:%s", this.Key, this.MetricQuery)
+}
+
+func (this *Probe) Equals(other *Probe) bool {
+	return this.Key.Equals(&other.Key)
+}
+
+// GetDBUri returns a lib/pq compatible connection URL for this probe
+func (this *Probe) GetDBUri() string {
+	hostname := this.Key.Hostname
+	var ip = net.ParseIP(hostname)
+	if (ip != nil) && (ip.To4() == nil) {
+		// Wrap IPv6 literals in square brackets
+		hostname = fmt.Sprintf("[%s]", hostname)
+	}
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(this.User, this.Password),
+		Host:   fmt.Sprintf("%s:%d", hostname, this.Key.Port),
+		Path:   "/" + this.Database,
+	}
+	query := url.Values{}
+	query.Set("connect_timeout", fmt.Sprintf("%d", timeoutSeconds))
+	query.Set("application_name", "freno")
+	if this.SSLMode != "" {
+		query.Set("sslmode", this.SSLMode)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package prometheus
+
+import (
+	"fmt"
+
+	"github.com/github/freno/pkg/base"
+)
+
+// Probe is the minimal configuration required to run a PromQL query and break it down into per-host values
+type Probe struct {
+	Address         string // Prometheus HTTP API base URL, e.g. http://prometheus.mydomain.com:9090
+	Query           string
+	HostLabel       string
+	TimeoutMillis   int
+	CacheMillis     int
+	QueryInProgress int64
+}
+
+type ClusterProbe struct {
+	ClusterName          string
+	IgnoreHostsCount     int
+	IgnoreHostsThreshold float64
+	AggregationMode      base.AggregationMode
+	Probe                *Probe
+}
+
+func (this *Probe) String() string {
+	return fmt.Sprintf("%s, query=%s", this.Address, this.Query)
+}
+
+type PrometheusInventory struct {
+	ClustersProbes       map[string](*Probe)
+	IgnoreHostsCount     map[string]int
+	IgnoreHostsThreshold map[string]float64
+	AggregationModes     map[string]base.AggregationMode
+	ClustersMetrics      map[string](*PrometheusThrottleMetric)
+}
+
+func NewPrometheusInventory() *PrometheusInventory {
+	inventory := &PrometheusInventory{
+		ClustersProbes:       make(map[string](*Probe)),
+		IgnoreHostsCount:     make(map[string]int),
+		IgnoreHostsThreshold: make(map[string]float64),
+		AggregationModes:     make(map[string]base.AggregationMode),
+		ClustersMetrics:      make(map[string](*PrometheusThrottleMetric)),
+	}
+	return inventory
+}
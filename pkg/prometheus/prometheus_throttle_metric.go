@@ -0,0 +1,113 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/exposition"
+
+	"github.com/patrickmn/go-cache"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+var prometheusMetricCache = cache.New(cache.NoExpiration, 10*time.Millisecond)
+
+var notANumberError = errors.New("Metric value is NaN")
+
+func getPrometheusMetricCacheKey(probe *Probe) string {
+	return fmt.Sprintf("%s:%s", probe.Address, probe.Query)
+}
+
+func cachePrometheusThrottleMetric(probe *Probe, prometheusThrottleMetric *PrometheusThrottleMetric) *PrometheusThrottleMetric {
+	if prometheusThrottleMetric.Err != nil {
+		return prometheusThrottleMetric
+	}
+	if probe.CacheMillis > 0 {
+		prometheusMetricCache.Set(getPrometheusMetricCacheKey(probe), prometheusThrottleMetric, time.Duration(probe.CacheMillis)*time.Millisecond)
+	}
+	return prometheusThrottleMetric
+}
+
+func getCachedPrometheusThrottleMetric(probe *Probe) *PrometheusThrottleMetric {
+	if probe.CacheMillis == 0 {
+		return nil
+	}
+	if metric, found := prometheusMetricCache.Get(getPrometheusMetricCacheKey(probe)); found {
+		prometheusThrottleMetric, _ := metric.(*PrometheusThrottleMetric)
+		return prometheusThrottleMetric
+	}
+	return nil
+}
+
+// PrometheusThrottleMetric is the outcome of a single query: either a query error, or a set of per-host values
+type PrometheusThrottleMetric struct {
+	ClusterName string
+	HostMetrics map[string]base.MetricResult // host -> value
+	Err         error
+	Timestamp   time.Time // when probed
+}
+
+func NewPrometheusThrottleMetric() *PrometheusThrottleMetric {
+	return &PrometheusThrottleMetric{HostMetrics: make(map[string]base.MetricResult)}
+}
+
+// hostMetricResult is a single host's value in a query result
+type hostMetricResult struct {
+	Value float64
+	Err   error
+}
+
+func (metric *hostMetricResult) Get() (float64, error) {
+	return metric.Value, metric.Err
+}
+
+// ReadThrottleMetric runs the probe's query and breaks down the resulting vector into per-host values
+func ReadThrottleMetric(probe *Probe, clusterName string) (prometheusThrottleMetric *PrometheusThrottleMetric) {
+	if prometheusThrottleMetric := getCachedPrometheusThrottleMetric(probe); prometheusThrottleMetric != nil {
+		return prometheusThrottleMetric
+		// On cached results we avoid taking latency metrics
+	}
+
+	started := time.Now()
+	prometheusThrottleMetric = NewPrometheusThrottleMetric()
+	prometheusThrottleMetric.Timestamp = started
+	prometheusThrottleMetric.ClusterName = clusterName
+
+	defer func(metric *PrometheusThrottleMetric, started time.Time) {
+		go func() {
+			metrics.GetOrRegisterTimer("probes.prometheus.latency", nil).Update(time.Since(started))
+			exposition.ObserveProbe("prometheus", metric.ClusterName, probe.Address, time.Since(started).Seconds(), metric.Err)
+			metrics.GetOrRegisterCounter("probes.prometheus.total", nil).Inc(1)
+			if metric.Err != nil {
+				metrics.GetOrRegisterCounter("probes.prometheus.error", nil).Inc(1)
+			}
+		}()
+	}(prometheusThrottleMetric, started)
+
+	samples, err := Query(probe.Address, probe.Query, time.Duration(probe.TimeoutMillis)*time.Millisecond)
+	if err != nil {
+		prometheusThrottleMetric.Err = err
+		return prometheusThrottleMetric
+	}
+	for _, sample := range samples {
+		hostMetric := &hostMetricResult{Value: sample.Value}
+		if math.IsNaN(sample.Value) {
+			hostMetric.Err = notANumberError
+		}
+		prometheusThrottleMetric.HostMetrics[sample.HostName(probe.HostLabel)] = hostMetric
+	}
+	return cachePrometheusThrottleMetric(probe, prometheusThrottleMetric)
+}
+
+// Get returns the query error, if any. Per-host values are found in HostMetrics
+func (metric *PrometheusThrottleMetric) Get() (float64, error) {
+	return 0, metric.Err
+}
@@ -0,0 +1,130 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+)
+
+const maxResponseBytes = 16 * 1024 * 1024
+
+// Sample is a single value in a query result, along with its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// queryResponse follows the Prometheus HTTP API response format, see
+// https://prometheus.io/docs/prometheus/latest/querying/api/#format-overview
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+func parseSampleValue(value [2]interface{}) (float64, error) {
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value: %+v", value[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseQueryResponse parses an instant query response body into samples. Both `vector` and `scalar` result types are supported.
+func parseQueryResponse(body []byte) (samples []Sample, err error) {
+	response := queryResponse{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return samples, err
+	}
+	if response.Status != "success" {
+		return samples, fmt.Errorf("prometheus query failed: %s: %s", response.ErrorType, response.Error)
+	}
+	switch response.Data.ResultType {
+	case "vector":
+		vector := []vectorSample{}
+		if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+			return samples, err
+		}
+		for _, v := range vector {
+			value, err := parseSampleValue(v.Value)
+			if err != nil {
+				return samples, err
+			}
+			samples = append(samples, Sample{Labels: v.Metric, Value: value})
+		}
+	case "scalar":
+		var scalar [2]interface{}
+		if err := json.Unmarshal(response.Data.Result, &scalar); err != nil {
+			return samples, err
+		}
+		value, err := parseSampleValue(scalar)
+		if err != nil {
+			return samples, err
+		}
+		samples = append(samples, Sample{Labels: map[string]string{}, Value: value})
+	default:
+		return samples, fmt.Errorf("unsupported prometheus result type: %s", response.Data.ResultType)
+	}
+	return samples, nil
+}
+
+// Query runs an instant query against the Prometheus HTTP API
+func Query(address string, query string, timeout time.Duration) (samples []Sample, err error) {
+	queryURL := fmt.Sprintf("%s/api/v1/query?%s", strings.TrimRight(address, "/"), neturl.Values{"query": []string{query}}.Encode())
+	resp, err := base.SharedHttpClient(timeout).Get(queryURL)
+	if err != nil {
+		if urlErr, ok := err.(*neturl.Error); ok {
+			// unwrap, so that e.g. "dial tcp" errors are identified as such
+			err = urlErr.Err
+		}
+		return samples, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return samples, err
+	}
+	// Prometheus returns a JSON error body on 400/422/503; try to make the most of it
+	samples, err = parseQueryResponse(body)
+	if err != nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		return samples, fmt.Errorf("%s returned HTTP %d: %s", address, resp.StatusCode, err.Error())
+	}
+	return samples, err
+}
+
+// HostName returns the identifier of the host the sample refers to: the value of `hostLabel`, if present,
+// or else the full label set
+func (sample *Sample) HostName(hostLabel string) string {
+	if host, ok := sample.Labels[hostLabel]; ok {
+		return host
+	}
+	labels := []string{}
+	for name, value := range sample.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("{%s}", strings.Join(labels, ","))
+}
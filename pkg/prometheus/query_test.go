@@ -0,0 +1,105 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+// newFakePrometheus returns a server mimicking the Prometheus instant query API, answering with
+// the response registered for the given query
+func newFakePrometheus(responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		response, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+}
+
+const lagVectorResponse = `{"status":"success","data":{"resultType":"vector","result":[
+	{"metric":{"instance":"db1:9104","job":"mysql"},"value":[1600000000.1,"0.5"]},
+	{"metric":{"instance":"db2:9104","job":"mysql"},"value":[1600000000.1,"2.25"]},
+	{"metric":{"job":"mysql"},"value":[1600000000.1,"NaN"]}
+]}}`
+
+func TestQuery(t *testing.T) {
+	server := newFakePrometheus(map[string]string{
+		"mysql_slave_lag_seconds": lagVectorResponse,
+		"scalar(1)":               `{"status":"success","data":{"resultType":"scalar","result":[1600000000.1,"1"]}}`,
+		"matrix":                  `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+	})
+	defer server.Close()
+
+	{
+		samples, err := Query(server.URL, "mysql_slave_lag_seconds", time.Second)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(samples), 3)
+		test.S(t).ExpectEquals(samples[0].HostName("instance"), "db1:9104")
+		test.S(t).ExpectEquals(samples[0].Value, 0.5)
+		test.S(t).ExpectEquals(samples[1].Value, 2.25)
+		test.S(t).ExpectEquals(samples[2].HostName("instance"), `{job="mysql"}`)
+	}
+	{
+		samples, err := Query(server.URL+"/", "scalar(1)", time.Second)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(samples), 1)
+		test.S(t).ExpectEquals(samples[0].Value, 1.0)
+	}
+	{
+		_, err := Query(server.URL, "matrix", time.Second)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := Query(server.URL, "no such query", time.Second)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReadThrottleMetric(t *testing.T) {
+	server := newFakePrometheus(map[string]string{
+		"mysql_slave_lag_seconds": lagVectorResponse,
+	})
+	defer server.Close()
+
+	{
+		metric := ReadThrottleMetric(&Probe{Address: server.URL, Query: "mysql_slave_lag_seconds", HostLabel: "instance", TimeoutMillis: 1000}, "c0")
+		_, err := metric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(metric.HostMetrics), 3)
+
+		value, err := metric.HostMetrics["db2:9104"].Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 2.25)
+
+		_, err = metric.HostMetrics[`{job="mysql"}`].Get()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		metric := ReadThrottleMetric(&Probe{Address: server.URL, Query: "up", HostLabel: "instance", TimeoutMillis: 1000}, "c0")
+		_, err := metric.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		server := httptest.NewServer(http.NotFoundHandler())
+		address := server.URL
+		server.Close()
+
+		metric := ReadThrottleMetric(&Probe{Address: address, Query: "up", TimeoutMillis: 1000}, "c0")
+		_, err := metric.Get()
+		test.S(t).ExpectTrue(base.IsDialTcpError(err))
+	}
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/hostprobe"
+)
+
+// This is a minimal client of the Redis serialization protocol (RESP), sufficient for issuing
+// AUTH and INFO commands. See https://redis.io/topics/protocol
+
+var nilReplyError = errors.New("nil reply")
+
+// writeCommand writes a command as a RESP array of bulk strings
+func writeCommand(w *bufio.Writer, args ...string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return w.Flush()
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return line, err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readReply reads a single reply. Simple strings, integers and bulk strings are returned as strings.
+// Arrays are not supported as we have no use for them.
+func readReply(r *bufio.Reader) (string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 {
+		return "", fmt.Errorf("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid bulk length: %s", line[1:])
+		}
+		if length < 0 {
+			return "", nilReplyError
+		}
+		buf := make([]byte, length+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:length]), nil
+	}
+	return "", fmt.Errorf("unsupported reply type: %q", line[0])
+}
+
+// Info connects to given instance, authenticates if needed, and returns the output of `INFO <section>`
+func Info(key *hostprobe.InstanceKey, user string, password string, section string, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("tcp", key.StringCode(), timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if err := writeCommand(w, args...); err != nil {
+			return "", err
+		}
+		if _, err := readReply(r); err != nil {
+			return "", err
+		}
+	}
+	if err := writeCommand(w, "INFO", section); err != nil {
+		return "", err
+	}
+	return readReply(r)
+}
@@ -0,0 +1,39 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package redis
+
+import (
+	"fmt"
+
+	"github.com/github/freno/pkg/hostprobe"
+)
+
+const (
+	DefaultRedisPort = 6379
+
+	// LagMetricOffset computes lag as the primary's master_repl_offset minus the replica's offset, in bytes
+	LagMetricOffset = "offset"
+	// LagMetricIOSeconds reads the replica's master_last_io_seconds_ago
+	LagMetricIOSeconds = "io-seconds"
+)
+
+// Probe is the minimal configuration required to read replication lag off a replica
+type Probe struct {
+	hostprobe.Probe
+	PrimaryKey    *hostprobe.InstanceKey // required by LagMetricOffset
+	User          string
+	Password      string
+	LagMetric     string
+	TimeoutMillis int
+}
+
+func (this *Probe) String() string {
+	return fmt.Sprintf("%s, lag=%s", this.Key.StringCode(), this.LagMetric)
+}
+
+func (this *Probe) CacheKey() string {
+	return fmt.Sprintf("%s:%s", this.Key, this.LagMetric)
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReadMetric computes a replica's lag according to the probe's LagMetric
+func (this *Probe) ReadMetric() (float64, error) {
+	timeout := time.Duration(this.TimeoutMillis) * time.Millisecond
+	replicaInfo, err := ReadReplicationInfo(&this.Key, this.User, this.Password, timeout)
+	if err != nil {
+		return 0, err
+	}
+	if role := replicaInfo["role"]; role != "slave" && role != "replica" {
+		return 0, fmt.Errorf("%s is not a replica: role=%s", this.Key.StringCode(), role)
+	}
+	if status := replicaInfo["master_link_status"]; status != "up" {
+		return 0, fmt.Errorf("%s replication link is %s", this.Key.StringCode(), status)
+	}
+	switch this.LagMetric {
+	case LagMetricIOSeconds:
+		ioSecondsAgo, err := replicaInfo.Int64("master_last_io_seconds_ago")
+		if err != nil {
+			return 0, err
+		}
+		return float64(ioSecondsAgo), nil
+	case LagMetricOffset, "":
+		if this.PrimaryKey == nil {
+			return 0, fmt.Errorf("%s: no primary configured; cannot compute offset lag", this.Key.StringCode())
+		}
+		// replica offset is read first, and primary offset is read next, so that we err on the side of greater lag
+		replicaOffset, err := replicaInfo.Int64("slave_repl_offset")
+		if err != nil {
+			return 0, err
+		}
+		primaryInfo, err := ReadReplicationInfo(this.PrimaryKey, this.User, this.Password, timeout)
+		if err != nil {
+			return 0, err
+		}
+		primaryOffset, err := primaryInfo.Int64("master_repl_offset")
+		if err != nil {
+			return 0, err
+		}
+		if lag := primaryOffset - replicaOffset; lag > 0 {
+			return float64(lag), nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unknown LagMetric: %s", this.LagMetric)
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2017 GitHub Inc.
+	 See https://github.com/github/freno/blob/master/LICENSE
+*/
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/hostprobe"
+)
+
+// ReplicationInfo is the parsed output of `INFO replication`
+type ReplicationInfo map[string]string
+
+// ParseReplicationInfo parses `INFO` output into key/value pairs, skipping section headers
+func ParseReplicationInfo(info string) ReplicationInfo {
+	replicationInfo := make(ReplicationInfo)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens := strings.SplitN(line, ":", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+		replicationInfo[tokens[0]] = tokens[1]
+	}
+	return replicationInfo
+}
+
+// ReadReplicationInfo reads and parses `INFO replication` off given instance
+func ReadReplicationInfo(key *hostprobe.InstanceKey, user string, password string, timeout time.Duration) (ReplicationInfo, error) {
+	info, err := Info(key, user, password, "replication", timeout)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReplicationInfo(info), nil
+}
+
+// Int64 returns the integer value of given field
+func (info ReplicationInfo) Int64(name string) (int64, error) {
+	value, ok := info[name]
+	if !ok {
+		return 0, fmt.Errorf("%s not found in replication info", name)
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Replicas returns the replicas listed on a primary, via the `slave<N>:ip=...,port=...` fields
+func (info ReplicationInfo) Replicas() (replicas []hostprobe.InstanceKey) {
+	for name, value := range info {
+		if !strings.HasPrefix(name, "slave") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, "slave")); err != nil {
+			// e.g. slave_repl_offset
+			continue
+		}
+		key := hostprobe.InstanceKey{}
+		for _, field := range strings.Split(value, ",") {
+			tokens := strings.SplitN(field, "=", 2)
+			if len(tokens) != 2 {
+				continue
+			}
+			switch tokens[0] {
+			case "ip":
+				key.Hostname = tokens[1]
+			case "port":
+				key.Port, _ = strconv.Atoi(tokens[1])
+			}
+		}
+		if key.IsValid() {
+			replicas = append(replicas, key)
+		}
+	}
+	return replicas
+}
@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"bufio"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/hostprobe"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+const primaryInfo = "# Replication\r\n" +
+	"role:master\r\n" +
+	"connected_slaves:2\r\n" +
+	"slave0:ip=10.0.0.2,port=6379,state=online,offset=1000,lag=0\r\n" +
+	"slave1:ip=10.0.0.3,port=6380,state=online,offset=900,lag=1\r\n" +
+	"master_replid:8e1f0b6d0b2f4d33a3c2f6f1e3a7b1d2c3e4f5a6\r\n" +
+	"master_repl_offset:1200\r\n"
+
+const replicaInfo = "# Replication\r\n" +
+	"role:slave\r\n" +
+	"master_host:10.0.0.1\r\n" +
+	"master_port:6379\r\n" +
+	"master_link_status:up\r\n" +
+	"master_last_io_seconds_ago:3\r\n" +
+	"slave_repl_offset:1150\r\n" +
+	"master_repl_offset:1150\r\n"
+
+// newFakeRedis listens on a local port and answers AUTH and INFO commands
+func newFakeRedis(t *testing.T, password string, info string) (key *hostprobe.InstanceKey, closer func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	test.S(t).ExpectNil(err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authenticated := password == ""
+				for {
+					line, err := readLine(r)
+					if err != nil || !strings.HasPrefix(line, "*") {
+						return
+					}
+					count, err := strconv.Atoi(line[1:])
+					if err != nil {
+						return
+					}
+					args := []string{}
+					for i := 0; i < count; i++ {
+						arg, err := readReply(r)
+						if err != nil {
+							return
+						}
+						args = append(args, arg)
+					}
+					switch {
+					case args[0] == "AUTH" && args[len(args)-1] == password:
+						authenticated = true
+						conn.Write([]byte("+OK\r\n"))
+					case args[0] == "AUTH":
+						conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+					case !authenticated:
+						conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+					case args[0] == "INFO":
+						conn.Write([]byte("$" + strconv.Itoa(len(info)) + "\r\n" + info + "\r\n"))
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+	key, err = hostprobe.ParseInstanceKey(listener.Addr().String(), DefaultRedisPort)
+	test.S(t).ExpectNil(err)
+	return key, func() { listener.Close() }
+}
+
+func TestParseReplicationInfo(t *testing.T) {
+	info := ParseReplicationInfo(primaryInfo)
+	test.S(t).ExpectEquals(info["role"], "master")
+	offset, err := info.Int64("master_repl_offset")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(offset, int64(1200))
+	_, err = info.Int64("slave_repl_offset")
+	test.S(t).ExpectNotNil(err)
+
+	replicas := info.Replicas()
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Hostname < replicas[j].Hostname })
+	test.S(t).ExpectEquals(len(replicas), 2)
+	test.S(t).ExpectEquals(replicas[0], hostprobe.InstanceKey{Hostname: "10.0.0.2", Port: 6379})
+	test.S(t).ExpectEquals(replicas[1], hostprobe.InstanceKey{Hostname: "10.0.0.3", Port: 6380})
+
+	test.S(t).ExpectEquals(len(ParseReplicationInfo(replicaInfo).Replicas()), 0)
+}
+
+func TestReadReplicationInfoAuth(t *testing.T) {
+	key, closer := newFakeRedis(t, "secret", replicaInfo)
+	defer closer()
+
+	{
+		info, err := ReadReplicationInfo(key, "", "secret", time.Second)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(info["master_link_status"], "up")
+	}
+	{
+		_, err := ReadReplicationInfo(key, "", "wrong", time.Second)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ReadReplicationInfo(key, "", "", time.Second)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestReadMetric(t *testing.T) {
+	primaryKey, closePrimary := newFakeRedis(t, "", primaryInfo)
+	defer closePrimary()
+	replicaKey, closeReplica := newFakeRedis(t, "", replicaInfo)
+	defer closeReplica()
+
+	{
+		probe := &Probe{Probe: hostprobe.Probe{Key: *replicaKey}, PrimaryKey: primaryKey, LagMetric: LagMetricOffset, TimeoutMillis: 1000}
+		value, err := probe.ReadMetric()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 50.0)
+	}
+	{
+		probe := &Probe{Probe: hostprobe.Probe{Key: *replicaKey}, LagMetric: LagMetricIOSeconds, TimeoutMillis: 1000}
+		value, err := probe.ReadMetric()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 3.0)
+	}
+	{
+		probe := &Probe{Probe: hostprobe.Probe{Key: *replicaKey}, LagMetric: LagMetricOffset, TimeoutMillis: 1000}
+		_, err := probe.ReadMetric()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		// primary is not a replica
+		probe := &Probe{Probe: hostprobe.Probe{Key: *primaryKey}, LagMetric: LagMetricIOSeconds, TimeoutMillis: 1000}
+		_, err := probe.ReadMetric()
+		test.S(t).ExpectNotNil(err)
+	}
+}
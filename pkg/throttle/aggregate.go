@@ -0,0 +1,187 @@
+package throttle
+
+import (
+	"math"
+	"sort"
+
+	"github.com/github/freno/pkg/base"
+
+	"github.com/outbrain/golib/log"
+)
+
+// clusterAggregationMode parses a cluster's configured aggregation mode. Configuration is validated
+// upon loading, hence a parsing error is unexpected; we fall back to the default mode.
+func clusterAggregationMode(clusterName string, mode string) base.AggregationMode {
+	aggregationMode, err := base.ParseAggregationMode(mode)
+	if err != nil {
+		log.Errorf("cluster %s: %+v; using %s", clusterName, err, base.AggregationMax)
+		return base.AggregationMode{Type: base.AggregationMax}
+	}
+	return aggregationMode
+}
+
+// aggregateMetricResults reduces a cluster's per-host metric results into a single metric.
+// Up to `ignoreHostsCount` hosts, either erroring or exceeding `ignoreHostsThreshold`, are skipped/ignored.
+// The remaining values are then reduced according to `aggregationMode`; by default this returns
+// the worst (highest) value.
+func aggregateMetricResults(
+	metricResults []base.MetricResult,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (worstMetric base.MetricResult) {
+	worstMetric, _ = aggregateMetricResultsIgnoring(metricResults, ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	return worstMetric
+}
+
+// aggregateMetricResultsIgnoring is the same as aggregateMetricResults, and further reports which results were
+// ignored during aggregation, mapping an index in `metricResults` to the reason it was ignored.
+func aggregateMetricResultsIgnoring(
+	metricResults []base.MetricResult,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (worstMetric base.MetricResult, ignored map[int]string) {
+	ignored = make(map[int]string)
+	if aggregationMode.Type == base.AggregationQuorum {
+		worstMetric = aggregateQuorum(metricResults, ignoreDialTcpErrors, aggregationMode.Value, ignored)
+		return worstMetric, ignored
+	}
+	type probeValue struct {
+		index int
+		value float64
+	}
+	probeValues := []probeValue{}
+	for i, metricResult := range metricResults {
+		value, err := metricResult.Get()
+		if err != nil {
+			if ignoreDialTcpErrors && base.IsDialTcpError(err) {
+				ignored[i] = "dial tcp error ignored (IgnoreDialTcpErrors)"
+				continue
+			}
+			if ignoreHostsCount > 0 {
+				// ok to skip this error
+				ignoreHostsCount = ignoreHostsCount - 1
+				ignored[i] = "error ignored (IgnoreHostsCount)"
+				continue
+			}
+			return metricResult, ignored
+		}
+
+		// No error
+		probeValues = append(probeValues, probeValue{index: i, value: value})
+	}
+	if len(probeValues) == 0 {
+		return base.NoHostsMetricResult, ignored
+	}
+
+	// If we got here, that means no errors (or good-to-skip errors)
+	sort.SliceStable(probeValues, func(i, j int) bool { return probeValues[i].value < probeValues[j].value })
+	// probeValues sorted ascending (from best, ie smallest, to worst, ie largest)
+	for ignoreHostsCount > 0 {
+		goodToIgnore := func() bool {
+			// Note that these hosts don't have errors
+			numProbeValues := len(probeValues)
+			if numProbeValues <= 1 {
+				// We wish to retain at least one host
+				return false
+			}
+			if ignoreHostsThreshold <= 0 {
+				// No threshold conditional (or implicitly "any value exceeds the threshold")
+				return true
+			}
+			if worstValue := probeValues[numProbeValues-1].value; worstValue > ignoreHostsThreshold {
+				return true
+			}
+			return false
+		}()
+		if goodToIgnore {
+			ignored[probeValues[len(probeValues)-1].index] = "highest value ignored (IgnoreHostsCount)"
+			probeValues = probeValues[0 : len(probeValues)-1]
+		}
+		// And, whether ignored or not, we are reducing our tokens
+		ignoreHostsCount = ignoreHostsCount - 1
+	}
+	values := make([]float64, len(probeValues))
+	for i := range probeValues {
+		values[i] = probeValues[i].value
+	}
+	return base.NewSimpleMetricResult(reduceProbeValues(values, aggregationMode)), ignored
+}
+
+// reduceProbeValues reduces non-empty, ascending sorted values into a single value
+func reduceProbeValues(probeValues []float64, aggregationMode base.AggregationMode) float64 {
+	numProbeValues := len(probeValues)
+	switch aggregationMode.Type {
+	case base.AggregationMin:
+		return probeValues[0]
+	case base.AggregationAvg:
+		sum := 0.0
+		for _, value := range probeValues {
+			sum += value
+		}
+		return sum / float64(numProbeValues)
+	case base.AggregationMedian:
+		if numProbeValues%2 == 1 {
+			return probeValues[numProbeValues/2]
+		}
+		return (probeValues[numProbeValues/2-1] + probeValues[numProbeValues/2]) / 2
+	case base.AggregationPercentile:
+		return probeValues[nearestRank(numProbeValues, aggregationMode.Value)-1]
+	}
+	return probeValues[numProbeValues-1]
+}
+
+// nearestRank returns the 1-based rank of the given percentile among numValues values
+func nearestRank(numValues int, percentile float64) int {
+	rank := int(math.Ceil(percentile / 100 * float64(numValues)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > numValues {
+		rank = numValues
+	}
+	return rank
+}
+
+// aggregateQuorum returns the lowest value that at least `quorumPercent` percent of the hosts are at or under.
+// Erroring hosts count against the quorum: they are never "under". Hence, IgnoreHostsCount does not apply.
+func aggregateQuorum(
+	metricResults []base.MetricResult,
+	ignoreDialTcpErrors bool,
+	quorumPercent float64,
+	ignored map[int]string,
+) base.MetricResult {
+	probeValues := []float64{}
+	var firstError base.MetricResult
+	numHosts := 0
+	for i, metricResult := range metricResults {
+		value, err := metricResult.Get()
+		if err != nil {
+			if ignoreDialTcpErrors && base.IsDialTcpError(err) {
+				ignored[i] = "dial tcp error ignored (IgnoreDialTcpErrors)"
+				continue
+			}
+			numHosts++
+			if firstError == nil {
+				firstError = metricResult
+			}
+			ignored[i] = "error counted against quorum"
+			continue
+		}
+		numHosts++
+		probeValues = append(probeValues, value)
+	}
+	if numHosts == 0 {
+		return base.NoHostsMetricResult
+	}
+	quorum := nearestRank(numHosts, quorumPercent)
+	if len(probeValues) < quorum {
+		// Not enough healthy hosts to form a quorum
+		return firstError
+	}
+	sort.Float64s(probeValues)
+	return base.NewSimpleMetricResult(probeValues[quorum-1])
+}
@@ -0,0 +1,91 @@
+package throttle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func newTestMetricResults(values ...float64) []base.MetricResult {
+	metricResults := []base.MetricResult{}
+	for _, value := range values {
+		metricResults = append(metricResults, base.NewSimpleMetricResult(value))
+	}
+	return metricResults
+}
+
+func expectAggregatedValue(t *testing.T, metricResult base.MetricResult, expected float64) {
+	value, err := metricResult.Get()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(value, expected)
+}
+
+func TestAggregateMetricResultsModes(t *testing.T) {
+	// 10 hosts, values 1..10
+	metricResults := newTestMetricResults(7, 3, 10, 1, 5, 2, 9, 4, 8, 6)
+	aggregate := func(mode string, ignoreHostsCount int) base.MetricResult {
+		aggregationMode, err := base.ParseAggregationMode(mode)
+		test.S(t).ExpectNil(err)
+		return aggregateMetricResults(metricResults, ignoreHostsCount, false, 0, aggregationMode)
+	}
+	expectAggregatedValue(t, aggregate("", 0), 10)
+	expectAggregatedValue(t, aggregate("max", 0), 10)
+	expectAggregatedValue(t, aggregate("max", 2), 8)
+	expectAggregatedValue(t, aggregate("min", 0), 1)
+	expectAggregatedValue(t, aggregate("avg", 0), 5.5)
+	expectAggregatedValue(t, aggregate("avg", 1), 5)
+	expectAggregatedValue(t, aggregate("median", 0), 5.5)
+	expectAggregatedValue(t, aggregate("median", 1), 5)
+	expectAggregatedValue(t, aggregate("percentile:90", 0), 9)
+	expectAggregatedValue(t, aggregate("percentile:95", 0), 10)
+	expectAggregatedValue(t, aggregate("percentile:50", 0), 5)
+	expectAggregatedValue(t, aggregate("percentile:1", 0), 1)
+	expectAggregatedValue(t, aggregate("quorum:75%", 0), 8)
+	expectAggregatedValue(t, aggregate("quorum:100%", 0), 10)
+	// IgnoreHostsCount does not apply to quorum
+	expectAggregatedValue(t, aggregate("quorum:100%", 3), 10)
+}
+
+type testErrorMetricResult struct {
+	err error
+}
+
+func (metricResult *testErrorMetricResult) Get() (float64, error) {
+	return 0, metricResult.err
+}
+
+func TestAggregateMetricResultsModesErrors(t *testing.T) {
+	hostError := &testErrorMetricResult{err: errors.New("Error 1045: Access denied")}
+	dialError := &testErrorMetricResult{err: errors.New("dial tcp 10.0.0.1:3306: connect: connection refused")}
+	// 4 healthy hosts, one erroring host
+	metricResults := append(newTestMetricResults(1, 2, 3, 4), hostError)
+	median, _ := base.ParseAggregationMode("median")
+	quorum, _ := base.ParseAggregationMode("quorum:80%")
+	{
+		metricResult := aggregateMetricResults(metricResults, 0, false, 0, median)
+		_, err := metricResult.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+	expectAggregatedValue(t, aggregateMetricResults(metricResults, 1, false, 0, median), 2.5)
+	// 4 of 5 hosts are healthy, which suffices for an 80% quorum
+	expectAggregatedValue(t, aggregateMetricResults(metricResults, 0, false, 0, quorum), 4)
+	{
+		metricResults := append(metricResults, hostError)
+		// 4 of 6 hosts are healthy; no quorum
+		metricResult := aggregateMetricResults(metricResults, 0, false, 0, quorum)
+		_, err := metricResult.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		metricResults := append(metricResults, dialError)
+		// dial errors are skipped altogether
+		expectAggregatedValue(t, aggregateMetricResults(metricResults, 0, true, 0, quorum), 4)
+	}
+	{
+		metricResult := aggregateMetricResults(nil, 0, false, 0, quorum)
+		test.S(t).ExpectEquals(metricResult, base.NoHostsMetricResult)
+	}
+}
@@ -2,12 +2,14 @@ package throttle
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"fmt"
 
 	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/exposition"
 	metrics "github.com/rcrowley/go-metrics"
 )
 
@@ -35,8 +37,8 @@ func NewThrottlerCheck(throttler *Throttler) *ThrottlerCheck {
 	}
 }
 
-// checkAppMetricResult allows an app to check on a metric
-func (check *ThrottlerCheck) checkAppMetricResult(appName string, storeType string, storeName string, metricResultFunc base.MetricResultFunc, flags *CheckFlags) (checkResult *CheckResult) {
+// checkAppMetricResult allows an app to check on a metric. When non-nil, `explanation` is populated with the decision trace.
+func (check *ThrottlerCheck) checkAppMetricResult(appName string, storeType string, storeName string, metricResultFunc base.MetricResultFunc, flags *CheckFlags, explanation *CheckExplanation) (checkResult *CheckResult) {
 	// Handle deprioritized app logic
 	denyApp := false
 	metricName := fmt.Sprintf("%s/%s", storeType, storeName)
@@ -46,14 +48,18 @@ func (check *ThrottlerCheck) checkAppMetricResult(appName string, storeType stri
 			// This is now a deprioritized app. Deny access to this request.
 			denyApp = true
 		}
+		explanation.explainLowPriority(denyApp)
 	}
 	//
-	metricResult, threshold := check.throttler.AppRequestMetricResult(appName, metricResultFunc, denyApp)
+	metricResult, threshold := check.throttler.appRequestMetricResult(appName, storeType, storeName, metricResultFunc, denyApp, explanation)
 	if flags.OverrideThreshold > 0 {
+		// an explicit threshold of a read check beats any app threshold
 		threshold = flags.OverrideThreshold
+		explanation.tracef("check overrides threshold with %f", threshold)
 	}
 	value, err := metricResult.Get()
 	if appName == "" {
+		explanation.decide(CheckRuleNoApp, "no app indicated")
 		return NewCheckResult(http.StatusExpectationFailed, value, threshold, fmt.Errorf("no app indicated"))
 	}
 
@@ -62,20 +68,29 @@ func (check *ThrottlerCheck) checkAppMetricResult(appName string, storeType stri
 	if err == base.AppDeniedError {
 		// app specifically not allowed to get metrics
 		statusCode = http.StatusExpectationFailed // 417
+		if denyApp {
+			explanation.decide(CheckRuleLowPriority, "low priority app denied: a normal priority app has recently been throttled on %s", metricName)
+		} else {
+			explanation.decide(CheckRuleAppThrottled, "app is explicitly throttled")
+		}
 	} else if err == base.NoSuchMetricError {
 		// not collected yet, or metric does not exist
 		statusCode = http.StatusNotFound // 404
+		explanation.decide(CheckRuleNoSuchMetric, "metric %s not collected yet, or does not exist", metricName)
 	} else if err != nil {
 		// any error
 		statusCode = http.StatusInternalServerError // 500
+		explanation.decide(CheckRuleMetricError, "metric %s error: %+v", metricName, err)
 	} else if value > threshold {
 		// casual throttling
 		statusCode = http.StatusTooManyRequests // 429
 		err = base.ThresholdExceededError
+		explanation.decide(CheckRuleThresholdExceeded, "metric %s value %f exceeds threshold %f", metricName, value, threshold)
 
 		if !flags.LowPriority && !flags.ReadCheck && appName != frenoAppName {
 			// low priority requests will henceforth be denied
 			go check.throttler.nonLowPriorityAppRequestsThrottled.SetDefault(metricName, true)
+			explanation.tracef("low priority apps will be denied on %s for the next %v", metricName, nonDeprioritizedAppMapExpiration)
 		}
 	} else if appName != frenoAppName && check.throttler.getShareDomainSecondsSinceHealth(metricName) >= 1 {
 		// throttling based on shared domain metric.
@@ -87,31 +102,60 @@ func (check *ThrottlerCheck) checkAppMetricResult(appName string, storeType stri
 
 		statusCode = http.StatusTooManyRequests // 429
 		err = base.ThresholdExceededError
+		explanation.decide(CheckRuleSharedDomain, "metric %s is unhealthy in the shared domain", metricName)
 	} else {
 		// all good!
 		statusCode = http.StatusOK // 200
+		explanation.decide(CheckRuleOK, "metric %s value %f is within threshold %f", metricName, value, threshold)
 	}
 	return NewCheckResult(statusCode, value, threshold, err)
 }
 
 // CheckAppStoreMetric
 func (check *ThrottlerCheck) Check(appName string, storeType string, storeName string, remoteAddr string, flags *CheckFlags) (checkResult *CheckResult) {
-	var metricResultFunc base.MetricResultFunc
-	switch storeType {
-	case "mysql":
-		{
-			metricResultFunc = func() (metricResult base.MetricResult, threshold float64) {
-				return check.throttler.getMySQLClusterMetrics(storeName)
-			}
-		}
+	return check.check(appName, storeType, storeName, remoteAddr, flags, nil)
+}
+
+// CheckExplain checks just like Check does, and further explains how the result was decided
+func (check *ThrottlerCheck) CheckExplain(appName string, storeType string, storeName string, remoteAddr string, flags *CheckFlags) (explanation *CheckExplanation) {
+	explanation = newCheckExplanation(appName, storeType, storeName, flags)
+	explanation.CheckResult = check.check(appName, storeType, storeName, remoteAddr, flags, explanation)
+	if explanation.Rule == "" {
+		explanation.decide(CheckRuleNoSuchMetric, "unknown store type %s", storeType)
 	}
-	if metricResultFunc == nil {
+	if hostMetrics, err := check.throttler.getStoreHostMetrics(storeType, storeName); err == nil {
+		explanation.Hosts = hostMetrics
+	}
+	if appName != frenoAppName {
+		explanation.SharedDomain = check.throttler.explainShareDomain(fmt.Sprintf("%s/%s", storeType, storeName))
+	}
+	return explanation
+}
+
+// recheck checks on a store metric without accounting for the check, as is the case when watching a check
+func (check *ThrottlerCheck) recheck(appName string, storeType string, storeName string, flags *CheckFlags) (checkResult *CheckResult) {
+	return check.checkStoreMetric(appName, storeType, storeName, flags, nil)
+}
+
+func (check *ThrottlerCheck) checkStoreMetric(appName string, storeType string, storeName string, flags *CheckFlags, explanation *CheckExplanation) (checkResult *CheckResult) {
+	if !check.throttler.hasStoreType(storeType) {
 		return NoSuchMetricCheckResult
 	}
+	metricResultFunc := func() (metricResult base.MetricResult, threshold float64) {
+		return check.throttler.getStoreMetrics(storeType, storeName)
+	}
+	return check.checkAppMetricResult(appName, storeType, storeName, metricResultFunc, flags, explanation)
+}
 
-	checkResult = check.checkAppMetricResult(appName, storeType, storeName, metricResultFunc, flags)
+func (check *ThrottlerCheck) check(appName string, storeType string, storeName string, remoteAddr string, flags *CheckFlags, explanation *CheckExplanation) (checkResult *CheckResult) {
+	if !check.throttler.hasStoreType(storeType) {
+		return NoSuchMetricCheckResult
+	}
+	checkResult = check.checkStoreMetric(appName, storeType, storeName, flags, explanation)
 
 	go func(statusCode int) {
+		exposition.CheckTotal.Inc(appName, storeType, storeName, strconv.Itoa(statusCode))
+
 		metrics.GetOrRegisterCounter("check.any.total", nil).Inc(1)
 		metrics.GetOrRegisterCounter(fmt.Sprintf("check.%s.total", appName), nil).Inc(1)
 
@@ -126,7 +170,9 @@ func (check *ThrottlerCheck) Check(appName string, storeType string, storeName s
 			metrics.GetOrRegisterCounter(fmt.Sprintf("check.%s.%s.%s.error", appName, storeType, storeName), nil).Inc(1)
 		}
 
-		check.throttler.markRecentApp(appName, remoteAddr)
+		if statusCode != http.StatusBadRequest {
+			check.throttler.markRecentApp(appName, remoteAddr)
+		}
 	}(checkResult.StatusCode)
 
 	return checkResult
@@ -177,6 +223,11 @@ func (check *ThrottlerCheck) AggregatedMetrics() map[string]base.MetricResult {
 	return check.throttler.aggregatedMetricsSnapshot()
 }
 
+// HostMetrics is a convenience access method into throttler's `getStoreHostMetrics`
+func (check *ThrottlerCheck) HostMetrics(storeType string, storeName string) ([]*HostMetric, error) {
+	return check.throttler.getStoreHostMetrics(storeType, storeName)
+}
+
 // MetricsHealth is a convenience acces method into throttler's `metricsHealthSnapshot`
 func (check *ThrottlerCheck) MetricsHealth() map[string](*base.MetricHealth) {
 	return check.throttler.metricsHealthSnapshot()
@@ -0,0 +1,122 @@
+package throttle
+
+import (
+	"fmt"
+
+	"github.com/github/freno/pkg/base"
+)
+
+// Rules by which a check is decided, as reported by CheckExplanation
+const (
+	CheckRuleNoApp             = "no-app"
+	CheckRuleLowPriority       = "low-priority-deprioritized"
+	CheckRuleAppThrottled      = "app-throttled"
+	CheckRuleNoSuchMetric      = "no-such-metric"
+	CheckRuleMetricError       = "metric-error"
+	CheckRuleThresholdExceeded = "threshold-exceeded"
+	CheckRuleSharedDomain      = "shared-domain-unhealthy"
+	CheckRuleOK                = "ok"
+)
+
+// AppThrottleExplanation describes an explicit app throttle found while checking, and the ratio roll made against it
+type AppThrottleExplanation struct {
+	AppThrottle *base.AppThrottle
+	Rule        string  `json:",omitempty"` // the rule the throttle comes from; empty when the app is throttled by name
+	Roll        float64 // the request is throttled when Roll < Ratio
+	Throttled   bool
+}
+
+// SharedDomainExplanation describes the shared domain health of a metric, as collected from the shared domain services
+type SharedDomainExplanation struct {
+	Services     map[string]string  `json:",omitempty"`
+	ServicesErr  string             `json:",omitempty"`
+	MetricHealth *base.MetricHealth `json:",omitempty"` // nil when no service reports on the metric
+}
+
+// CheckExplanation is the decision trace of a check. It also exports as JSON via the API
+type CheckExplanation struct {
+	CheckResult       *CheckResult
+	App               string
+	StoreType         string
+	StoreName         string
+	Flags             CheckFlags
+	Rule              string // the rule which decided the check
+	Trace             []string
+	LowPriorityDenied bool
+	AppThrottle       *AppThrottleExplanation  `json:",omitempty"`
+	AppThreshold      *base.AppThreshold       `json:",omitempty"` // the app's threshold override of the store's threshold, if any
+	Hosts             []*HostMetric            `json:",omitempty"` // nil for stores which do not report hosts
+	SharedDomain      *SharedDomainExplanation `json:",omitempty"` // nil for the "freno" app, which does not participate in the shared domain
+}
+
+func newCheckExplanation(appName string, storeType string, storeName string, flags *CheckFlags) *CheckExplanation {
+	return &CheckExplanation{
+		App:       appName,
+		StoreType: storeType,
+		StoreName: storeName,
+		Flags:     *flags,
+		Trace:     []string{},
+	}
+}
+
+// The following methods are all safe to call on a nil explanation, which is the case for unexplained checks.
+
+func (explanation *CheckExplanation) tracef(format string, args ...interface{}) {
+	if explanation == nil {
+		return
+	}
+	explanation.Trace = append(explanation.Trace, fmt.Sprintf(format, args...))
+}
+
+func (explanation *CheckExplanation) decide(rule string, format string, args ...interface{}) {
+	if explanation == nil {
+		return
+	}
+	explanation.Rule = rule
+	explanation.tracef(format, args...)
+}
+
+func (explanation *CheckExplanation) explainLowPriority(denied bool) {
+	if explanation == nil {
+		return
+	}
+	explanation.LowPriorityDenied = denied
+	if denied {
+		explanation.tracef("low priority: a normal priority app has recently been throttled")
+	} else {
+		explanation.tracef("low priority: no normal priority app has recently been throttled")
+	}
+}
+
+func (explanation *CheckExplanation) explainAppThrottle(appThrottle *base.AppThrottle, ruleName string, roll float64, throttled bool) {
+	if explanation == nil {
+		return
+	}
+	if appThrottle == nil {
+		explanation.tracef("app is not explicitly throttled")
+		return
+	}
+	explanation.AppThrottle = &AppThrottleExplanation{AppThrottle: appThrottle, Rule: ruleName, Roll: roll, Throttled: throttled}
+	if ruleName != "" {
+		explanation.tracef("app matches rule %s", ruleName)
+	}
+	if scope := appThrottle.AppThrottleStore.String(); scope != "" {
+		explanation.tracef("app throttle is scoped to %s", scope)
+	}
+	explanation.tracef("app is explicitly throttled until %s with ratio %f; rolled %f, throttled: %t", appThrottle.ExpireAt, appThrottle.Ratio, roll, throttled)
+}
+
+func (explanation *CheckExplanation) explainAppThreshold(appThreshold *base.AppThreshold, storeThreshold float64) {
+	if explanation == nil {
+		return
+	}
+	explanation.AppThreshold = appThreshold
+	if appThreshold.App != explanation.App {
+		explanation.tracef("app matches threshold rule %s", appThreshold.App)
+	}
+	source := "runtime"
+	if appThreshold.Configured {
+		source = "configured"
+	}
+	explanation.tracef("%s app threshold %f overrides store threshold %f", source, appThreshold.Threshold, storeThreshold)
+}
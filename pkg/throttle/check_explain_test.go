@@ -0,0 +1,188 @@
+package throttle
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func newExplainTestThrottlerCheck() *ThrottlerCheck {
+	throttler := NewThrottler()
+	throttler.stores = map[string]Store{
+		"fake": &fakeStore{
+			thresholds: map[string]float64{"c0": 2.5, "c1": 2.5},
+			metrics: map[string]base.MetricResult{
+				"c0": base.NewSimpleMetricResult(1.5),
+				"c1": base.NewSimpleMetricResult(3.5),
+			},
+		},
+	}
+	for storeName, metricResult := range throttler.stores["fake"].Aggregate() {
+		throttler.aggregatedMetrics.SetDefault("fake/"+storeName, metricResult)
+	}
+	return NewThrottlerCheck(throttler)
+}
+
+func TestCheckExplain(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	{
+		explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusOK)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectTrue(explanation.AppThrottle == nil)
+		test.S(t).ExpectTrue(explanation.Hosts == nil)
+		test.S(t).ExpectNotNil(explanation.SharedDomain)
+	}
+	{
+		explanation := check.CheckExplain("app", "fake", "c1", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusTooManyRequests)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleThresholdExceeded)
+	}
+	{
+		explanation := check.CheckExplain("app", "fake", "c9", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusNotFound)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleNoSuchMetric)
+	}
+	{
+		explanation := check.CheckExplain("app", "nosuchstore", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusNotFound)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleNoSuchMetric)
+	}
+	{
+		explanation := check.CheckExplain(frenoAppName, "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectTrue(explanation.SharedDomain == nil)
+	}
+}
+
+func TestCheckExplainAppThrottled(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	{
+		check.throttler.ThrottleApp("app", time.Now().Add(time.Hour), 1)
+		explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusExpectationFailed)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleAppThrottled)
+		test.S(t).ExpectNotNil(explanation.AppThrottle)
+		test.S(t).ExpectTrue(explanation.AppThrottle.Throttled)
+		test.S(t).ExpectEquals(explanation.AppThrottle.AppThrottle.Ratio, 1.0)
+	}
+	{
+		check.throttler.ThrottleApp("app", time.Now().Add(time.Hour), 0)
+		explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusOK)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectNotNil(explanation.AppThrottle)
+		test.S(t).ExpectFalse(explanation.AppThrottle.Throttled)
+	}
+}
+
+func TestCheckExplainAppRule(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	check.throttler.ThrottleApp("glob:gh-ost:*", time.Now().Add(time.Hour), 1)
+	check.throttler.ThrottleApp("prefix:gh-ost:main", time.Now().Add(time.Hour), 0)
+	{
+		explanation := check.CheckExplain("gh-ost:ci1", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleAppThrottled)
+		test.S(t).ExpectEquals(explanation.AppThrottle.Rule, "glob:gh-ost:*")
+	}
+	{
+		// the most specific rule wins
+		explanation := check.CheckExplain("gh-ost:main1", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectEquals(explanation.AppThrottle.Rule, "prefix:gh-ost:main")
+	}
+	{
+		// an app's own throttle beats rules
+		check.throttler.ThrottleApp("gh-ost:ci1", time.Now().Add(time.Hour), 0)
+		explanation := check.CheckExplain("gh-ost:ci1", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectEquals(explanation.AppThrottle.Rule, "")
+	}
+	check.throttler.UnthrottleApp("glob:gh-ost:*")
+	test.S(t).ExpectFalse(check.throttler.IsAppThrottled("gh-ost:ci2"))
+}
+
+func TestCheckExplainAppStore(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	check.throttler.ThrottleAppOnStore("app", base.AppThrottleStore{StoreType: "fake", StoreName: "c1"}, time.Now().Add(time.Hour), 1, nil)
+	{
+		explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectTrue(explanation.AppThrottle == nil)
+	}
+	check.throttler.ThrottleAppOnStore("app", base.AppThrottleStore{StoreType: "fake"}, time.Now().Add(time.Hour), 1, nil)
+	{
+		explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleAppThrottled)
+		test.S(t).ExpectEquals(explanation.AppThrottle.AppThrottle.StoreType, "fake")
+	}
+	test.S(t).ExpectFalse(check.throttler.IsAppThrottled("app"))
+	{
+		// "app@fake" would read as the app's store scoped throttle key, yet is checked as a plain app
+		explanation := check.CheckExplain("app@fake", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusOK)
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleOK)
+		test.S(t).ExpectTrue(explanation.AppThrottle == nil)
+	}
+	check.throttler.ThrottleApp("prefix:app", time.Now().Add(time.Hour), 1)
+	{
+		explanation := check.CheckExplain("app@fake", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleAppThrottled)
+		test.S(t).ExpectEquals(explanation.AppThrottle.Rule, "prefix:app")
+	}
+}
+
+func TestCheckExplainAppThreshold(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	c0 := base.AppThrottleStore{StoreType: "fake", StoreName: "c0"}
+	check.throttler.configuredAppThresholds = map[string]*base.AppThreshold{
+		"archiver@fake/c0": {App: "archiver", Threshold: 0.5, AppThrottleStore: c0, Configured: true},
+	}
+	check.throttler.SetAppThreshold("prefix:gh-ost", c0, 1.2)
+	{
+		explanation := check.CheckExplain("archiver", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleThresholdExceeded)
+		test.S(t).ExpectEquals(explanation.CheckResult.Threshold, 0.5)
+		test.S(t).ExpectTrue(explanation.AppThreshold.Configured)
+	}
+	{
+		explanation := check.CheckExplain("gh-ost:main1", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.Rule, CheckRuleThresholdExceeded)
+		test.S(t).ExpectEquals(explanation.CheckResult.Threshold, 1.2)
+		test.S(t).ExpectEquals(explanation.AppThreshold.App, "prefix:gh-ost")
+	}
+	{
+		// other stores, and freno itself, keep the store's threshold
+		explanation := check.CheckExplain("archiver", "fake", "c1", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(explanation.CheckResult.Threshold, 2.5)
+		test.S(t).ExpectTrue(explanation.AppThreshold == nil)
+		checkResult := check.Check(frenoAppName, "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(checkResult.Threshold, 2.5)
+	}
+	{
+		// a runtime threshold beats a configured one, and an explicit read check threshold beats both
+		check.throttler.SetAppThreshold("archiver", c0, 2)
+		checkResult := check.Check("archiver", "fake", "c0", "local", &CheckFlags{})
+		test.S(t).ExpectEquals(checkResult.StatusCode, http.StatusOK)
+		test.S(t).ExpectEquals(checkResult.Threshold, 2.0)
+		checkResult = check.Check("archiver", "fake", "c0", "local", &CheckFlags{ReadCheck: true, OverrideThreshold: 1})
+		test.S(t).ExpectEquals(checkResult.Threshold, 1.0)
+	}
+	check.throttler.RemoveAppThreshold("archiver", c0)
+	test.S(t).ExpectEquals(check.throttler.ResolveAppThreshold("archiver", c0).Threshold, 0.5)
+	test.S(t).ExpectEquals(len(check.throttler.AppThresholds()), 2)
+}
+
+func TestCheckExplainLowPriority(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	check.throttler.nonLowPriorityAppRequestsThrottled.SetDefault("fake/c0", true)
+
+	explanation := check.CheckExplain("app", "fake", "c0", "local", &CheckFlags{LowPriority: true})
+	test.S(t).ExpectEquals(explanation.CheckResult.StatusCode, http.StatusExpectationFailed)
+	test.S(t).ExpectEquals(explanation.Rule, CheckRuleLowPriority)
+	test.S(t).ExpectTrue(explanation.LowPriorityDenied)
+}
@@ -0,0 +1,75 @@
+package throttle
+
+import (
+	"sort"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+)
+
+// HostMetric describes a single host's latest probe result, and its part in its cluster's aggregated metric.
+// It also exports as JSON via the API
+type HostMetric struct {
+	Host            string
+	Value           float64
+	Error           string    `json:",omitempty"`
+	LastProbeTime   time.Time // zero if never probed
+	HttpCheckStatus int       `json:",omitempty"` // 0 where not applicable
+	Ignored         bool      // probed, but ignored during aggregation
+	Excluded        bool      // not taking part in aggregation at all
+	Reason          string    `json:",omitempty"` // why ignored or excluded
+}
+
+// HostsReporter is implemented by stores which are able to break down an aggregated metric by hosts
+type HostsReporter interface {
+	// HostMetrics returns the per-host breakdown for a store name, as of latest collected data
+	HostMetrics(storeName string) (hostMetrics []*HostMetric, found bool)
+}
+
+// hostsAggregation collects a cluster's per-host metric results, and reports each host's part in their aggregation
+type hostsAggregation struct {
+	hostMetrics   []*HostMetric
+	metricResults []base.MetricResult
+	included      []*HostMetric // parallel to metricResults
+}
+
+// exclude adds a host which does not take part in aggregation
+func (aggregation *hostsAggregation) exclude(host string, reason string) *HostMetric {
+	hostMetric := &HostMetric{Host: host, Excluded: true, Reason: reason}
+	aggregation.hostMetrics = append(aggregation.hostMetrics, hostMetric)
+	return hostMetric
+}
+
+// add adds a host's latest metric result; a nil result indicates the host has not been probed yet
+func (aggregation *hostsAggregation) add(host string, metricResult base.MetricResult, lastProbeTime time.Time) *HostMetric {
+	if metricResult == nil {
+		metricResult = base.NoMetricResultYet
+	}
+	hostMetric := &HostMetric{Host: host, LastProbeTime: lastProbeTime}
+	if value, err := metricResult.Get(); err == nil {
+		hostMetric.Value = value
+	} else {
+		hostMetric.Error = err.Error()
+	}
+	aggregation.hostMetrics = append(aggregation.hostMetrics, hostMetric)
+	aggregation.metricResults = append(aggregation.metricResults, metricResult)
+	aggregation.included = append(aggregation.included, hostMetric)
+	return hostMetric
+}
+
+// aggregate aggregates collected results just as aggregateMetricResults does, marking ignored hosts
+func (aggregation *hostsAggregation) aggregate(
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (metricResult base.MetricResult, hostMetrics []*HostMetric) {
+	metricResult, ignored := aggregateMetricResultsIgnoring(aggregation.metricResults, ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	for i, reason := range ignored {
+		aggregation.included[i].Ignored = true
+		aggregation.included[i].Reason = reason
+	}
+	hostMetrics = aggregation.hostMetrics
+	sort.SliceStable(hostMetrics, func(i, j int) bool { return hostMetrics[i].Host < hostMetrics[j].Host })
+	return metricResult, hostMetrics
+}
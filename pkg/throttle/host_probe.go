@@ -0,0 +1,168 @@
+package throttle
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/hostprobe"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+func aggregateHostProbes(
+	probes *hostprobe.Probes,
+	clusterName string,
+	instanceResultsMap hostprobe.InstanceMetricResultMap,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (worstMetric base.MetricResult) {
+	// probes is known not to change. It can be *replaced*, but not changed.
+	// so it's safe to iterate it
+	metricResults := []base.MetricResult{}
+	for key := range *probes {
+		instanceMetricResult, ok := instanceResultsMap[hostprobe.GetClusterInstanceKey(clusterName, &key)]
+		if !ok {
+			return base.NoMetricResultYet
+		}
+		metricResults = append(metricResults, instanceMetricResult)
+	}
+	return aggregateMetricResults(metricResults, ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+}
+
+// hostProbesHostMetrics breaks down a cluster's metric by hosts, following the same logic as aggregateHostProbes
+func hostProbesHostMetrics(
+	probes *hostprobe.Probes,
+	clusterName string,
+	instanceResultsMap hostprobe.InstanceMetricResultMap,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (hostMetrics []*HostMetric) {
+	aggregation := &hostsAggregation{}
+	for key := range *probes {
+		var lastProbeTime time.Time
+		metricResult := instanceResultsMap[hostprobe.GetClusterInstanceKey(clusterName, &key)]
+		if metric, ok := metricResult.(*hostprobe.ThrottleMetric); ok {
+			lastProbeTime = metric.Timestamp
+		}
+		aggregation.add(key.StringCode(), metricResult, lastProbeTime)
+	}
+	_, hostMetrics = aggregation.aggregate(ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	return hostMetrics
+}
+
+// hostProbeStore collects, aggregates and breaks down a metric read off each host of its clusters, via
+// hostprobe probes. Store types which probe their hosts embed it, and implement Refresh, which discovers
+// their hosts and creates the probes.
+type hostProbeStore struct {
+	storeType           string
+	ignoreDialTcpErrors func() bool // read from config upon each aggregation
+	inventory           *hostprobe.Inventory
+	inventoryMutex      sync.RWMutex
+	clusterThresholds   *cache.Cache
+}
+
+func newHostProbeStore(storeType string, ignoreDialTcpErrors func() bool) *hostProbeStore {
+	return &hostProbeStore{
+		storeType:           storeType,
+		ignoreDialTcpErrors: ignoreDialTcpErrors,
+		inventory:           hostprobe.NewInventory(),
+		clusterThresholds:   cache.New(cache.NoExpiration, 0),
+	}
+}
+
+// clustersProbes returns a snapshot of the inventory's probes. Each cluster's probes are known not to change;
+// they can be *replaced*, but not changed. So it's safe to iterate them.
+func (store *hostProbeStore) clustersProbes() map[string](*hostprobe.Probes) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	clustersProbes := make(map[string](*hostprobe.Probes))
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		clustersProbes[clusterName] = probes
+	}
+	return clustersProbes
+}
+
+func (store *hostProbeStore) Collect() error {
+	for clusterName, probes := range store.clustersProbes() {
+		clusterName := clusterName
+		probes := probes
+		go func() {
+			for _, hostProbe := range *probes {
+				hostProbe := hostProbe
+				go func() {
+					// Avoid querying the same server twice at the same time. If previous read is still there,
+					// we avoid re-reading it.
+					probe := hostProbe.GetProbe()
+					if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
+						return
+					}
+					defer atomic.StoreInt64(&probe.QueryInProgress, 0)
+					throttleMetric := hostprobe.ReadThrottleMetric(store.storeType, hostProbe, clusterName)
+
+					store.inventoryMutex.Lock()
+					defer store.inventoryMutex.Unlock()
+					store.inventory.InstanceKeyMetrics[throttleMetric.GetClusterInstanceKey()] = throttleMetric
+				}()
+			}
+		}()
+	}
+	return nil
+}
+
+// synchronous update of inventory
+func (store *hostProbeStore) updateClusterProbes(clusterProbes *hostprobe.ClusterProbes) {
+	store.inventoryMutex.Lock()
+	defer store.inventoryMutex.Unlock()
+
+	log.Debugf("on %s cluster probes: %s", store.storeType, clusterProbes.ClusterName)
+	store.inventory.ClustersProbes[clusterProbes.ClusterName] = clusterProbes.InstanceProbes
+	store.inventory.IgnoreHostsCount[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsCount
+	store.inventory.IgnoreHostsThreshold[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsThreshold
+	store.inventory.AggregationModes[clusterProbes.ClusterName] = clusterProbes.AggregationMode
+}
+
+// Aggregate aggregates collected data per cluster
+func (store *hostProbeStore) Aggregate() map[string]base.MetricResult {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	aggregatedMetrics := make(map[string]base.MetricResult)
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+		ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+		aggregationMode := store.inventory.AggregationModes[clusterName]
+		aggregatedMetrics[clusterName] = aggregateHostProbes(probes, clusterName, store.inventory.InstanceKeyMetrics, ignoreHostsCount, store.ignoreDialTcpErrors(), ignoreHostsThreshold, aggregationMode)
+	}
+	return aggregatedMetrics
+}
+
+// HostMetrics breaks down a cluster's metric by hosts
+func (store *hostProbeStore) HostMetrics(clusterName string) (hostMetrics []*HostMetric, found bool) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	probes, found := store.inventory.ClustersProbes[clusterName]
+	if !found {
+		return hostMetrics, false
+	}
+	ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+	ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+	aggregationMode := store.inventory.AggregationModes[clusterName]
+	return hostProbesHostMetrics(probes, clusterName, store.inventory.InstanceKeyMetrics, ignoreHostsCount, store.ignoreDialTcpErrors(), ignoreHostsThreshold, aggregationMode), true
+}
+
+func (store *hostProbeStore) Threshold(clusterName string) (float64, bool) {
+	if thresholdVal, found := store.clusterThresholds.Get(clusterName); found {
+		threshold, _ := thresholdVal.(float64)
+		return threshold, true
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package throttle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/hostprobe"
+	"github.com/github/freno/pkg/postgresql"
+	"github.com/github/freno/pkg/redis"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+// hostProbeStoreTypes creates, per store type which probes its hosts, a probe of given host
+var hostProbeStoreTypes = map[string]func(key hostprobe.InstanceKey) hostprobe.HostProbe{
+	redisStoreType: func(key hostprobe.InstanceKey) hostprobe.HostProbe {
+		return &redis.Probe{Probe: hostprobe.Probe{Key: key}}
+	},
+	postgresqlStoreType: func(key hostprobe.InstanceKey) hostprobe.HostProbe {
+		return &postgresql.Probe{Probe: hostprobe.Probe{Key: key}}
+	},
+}
+
+func TestAggregateHostProbes(t *testing.T) {
+	clusterName := "cluster0"
+	hostKey1 := hostprobe.InstanceKey{Hostname: "10.0.0.1", Port: 3000}
+	hostKey2 := hostprobe.InstanceKey{Hostname: "10.0.0.2", Port: 3000}
+	hostKey3 := hostprobe.InstanceKey{Hostname: "10.0.0.3", Port: 3000}
+	dialError := &hostprobe.ThrottleMetric{Err: errors.New("dial tcp 10.0.0.2:3000: connect: connection refused")}
+
+	cases := []struct {
+		name                 string
+		results              map[hostprobe.InstanceKey]base.MetricResult
+		ignoreHostsCount     int
+		ignoreDialTcpErrors  bool
+		ignoreHostsThreshold float64
+		expectValue          float64
+		expectErr            bool
+		expectNoResultYet    bool
+	}{
+		{
+			name:        "worst",
+			results:     map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: base.NewSimpleMetricResult(1.3), hostKey3: base.NewSimpleMetricResult(0.9)},
+			expectValue: 1.3,
+		},
+		{
+			name:             "ignore-hosts-count",
+			results:          map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: base.NewSimpleMetricResult(1.3), hostKey3: base.NewSimpleMetricResult(0.9)},
+			ignoreHostsCount: 1,
+			expectValue:      0.9,
+		},
+		{
+			name:                 "ignore-hosts-threshold",
+			results:              map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: base.NewSimpleMetricResult(1.3), hostKey3: base.NewSimpleMetricResult(0.9)},
+			ignoreHostsCount:     1,
+			ignoreHostsThreshold: 1.5,
+			expectValue:          1.3,
+		},
+		{
+			name:      "dial-error",
+			results:   map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: dialError, hostKey3: base.NewSimpleMetricResult(0.9)},
+			expectErr: true,
+		},
+		{
+			name:                "ignore-dial-error",
+			results:             map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: dialError, hostKey3: base.NewSimpleMetricResult(0.9)},
+			ignoreDialTcpErrors: true,
+			expectValue:         0.9,
+		},
+		{
+			name:              "not-yet-probed",
+			results:           map[hostprobe.InstanceKey]base.MetricResult{hostKey1: base.NewSimpleMetricResult(0.4), hostKey2: base.NewSimpleMetricResult(1.3)},
+			expectNoResultYet: true,
+		},
+	}
+	for storeType, newProbe := range hostProbeStoreTypes {
+		probes := hostprobe.Probes{}
+		for _, key := range []hostprobe.InstanceKey{hostKey1, hostKey2, hostKey3} {
+			probes[key] = newProbe(key)
+		}
+		for _, c := range cases {
+			t.Run(storeType+"/"+c.name, func(t *testing.T) {
+				instanceResultsMap := hostprobe.InstanceMetricResultMap{}
+				for key, result := range c.results {
+					key := key
+					instanceResultsMap[hostprobe.GetClusterInstanceKey(clusterName, &key)] = result
+				}
+				worstMetric := aggregateHostProbes(&probes, clusterName, instanceResultsMap, c.ignoreHostsCount, c.ignoreDialTcpErrors, c.ignoreHostsThreshold, base.AggregationMode{})
+				if c.expectNoResultYet {
+					test.S(t).ExpectEquals(worstMetric, base.NoMetricResultYet)
+					return
+				}
+				value, err := worstMetric.Get()
+				if c.expectErr {
+					test.S(t).ExpectNotNil(err)
+					return
+				}
+				test.S(t).ExpectNil(err)
+				test.S(t).ExpectEquals(value, c.expectValue)
+
+				hostMetrics := hostProbesHostMetrics(&probes, clusterName, instanceResultsMap, c.ignoreHostsCount, c.ignoreDialTcpErrors, c.ignoreHostsThreshold, base.AggregationMode{})
+				test.S(t).ExpectEquals(len(hostMetrics), len(probes))
+			})
+		}
+	}
+}
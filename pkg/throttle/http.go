@@ -0,0 +1,53 @@
+package throttle
+
+import (
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/httpmetric"
+)
+
+func aggregateHTTPProbes(
+	probes *httpmetric.Probes,
+	clusterName string,
+	probeResultsMap httpmetric.ProbeMetricResultMap,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (worstMetric base.MetricResult) {
+	// probes is known not to change. It can be *replaced*, but not changed.
+	// so it's safe to iterate it
+	metricResults := []base.MetricResult{}
+	for _, probe := range *probes {
+		probeMetricResult, ok := probeResultsMap[httpmetric.GetClusterProbeKey(clusterName, probe.URL)]
+		if !ok {
+			return base.NoMetricResultYet
+		}
+		metricResults = append(metricResults, probeMetricResult)
+	}
+	return aggregateMetricResults(metricResults, ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+}
+
+// httpHostMetrics breaks down a cluster's metric by hosts, following the same logic as aggregateHTTPProbes
+func httpHostMetrics(
+	probes *httpmetric.Probes,
+	clusterName string,
+	probeResultsMap httpmetric.ProbeMetricResultMap,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (hostMetrics []*HostMetric) {
+	aggregation := &hostsAggregation{}
+	for _, probe := range *probes {
+		var lastProbeTime time.Time
+		metricResult := probeResultsMap[httpmetric.GetClusterProbeKey(clusterName, probe.URL)]
+		if metric, ok := metricResult.(*httpmetric.HTTPThrottleMetric); ok {
+			lastProbeTime = metric.Timestamp
+		}
+		aggregation.add(probe.URL, metricResult, lastProbeTime)
+	}
+	_, hostMetrics = aggregation.aggregate(ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	return hostMetrics
+}
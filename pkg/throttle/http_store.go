@@ -0,0 +1,155 @@
+package throttle
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/httpmetric"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const httpStoreType = "http"
+
+func init() {
+	RegisterStore(httpStoreType, newHTTPStore)
+}
+
+// httpStore collects metrics from HTTP endpoints, as configured in Stores.HTTP
+type httpStore struct {
+	inventory         *httpmetric.HTTPInventory
+	inventoryMutex    sync.RWMutex
+	clusterThresholds *cache.Cache
+}
+
+func newHTTPStore() Store {
+	return &httpStore{
+		inventory:         httpmetric.NewHTTPInventory(),
+		clusterThresholds: cache.New(cache.NoExpiration, 0),
+	}
+}
+
+// clustersProbes returns a snapshot of the inventory's probes. Each cluster's probes are known not to change;
+// they can be *replaced*, but not changed. So it's safe to iterate them.
+func (store *httpStore) clustersProbes() map[string](*httpmetric.Probes) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	clustersProbes := make(map[string](*httpmetric.Probes))
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		clustersProbes[clusterName] = probes
+	}
+	return clustersProbes
+}
+
+func (store *httpStore) Collect() error {
+	for clusterName, probes := range store.clustersProbes() {
+		clusterName := clusterName
+		probes := probes
+		go func() {
+			for _, probe := range *probes {
+				probe := probe
+				go func() {
+					// Avoid querying the same endpoint twice at the same time. If previous read is still there,
+					// we avoid re-reading it.
+					if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
+						return
+					}
+					defer atomic.StoreInt64(&probe.QueryInProgress, 0)
+					throttleMetric := httpmetric.ReadThrottleMetric(probe, clusterName)
+
+					store.inventoryMutex.Lock()
+					defer store.inventoryMutex.Unlock()
+					store.inventory.ProbeMetrics[throttleMetric.GetClusterProbeKey()] = throttleMetric
+				}()
+			}
+		}()
+	}
+	return nil
+}
+
+// Refresh will re-structure the inventory based on reading config settings
+func (store *httpStore) Refresh() error {
+	log.Debugf("refreshing HTTP inventory")
+
+	for clusterName, clusterSettings := range config.Settings().Stores.HTTP.Clusters {
+		store.clusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
+		clusterProbes := &httpmetric.ClusterProbes{
+			ClusterName:          clusterName,
+			IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
+			IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
+			AggregationMode:      clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+			InstanceProbes:       httpmetric.NewProbes(),
+		}
+		for _, url := range clusterSettings.URLs {
+			if url == "" {
+				continue
+			}
+			(*clusterProbes.InstanceProbes)[url] = &httpmetric.Probe{
+				URL:           url,
+				JSONPath:      clusterSettings.JSONPath,
+				TimeoutMillis: clusterSettings.TimeoutMillis,
+				CacheMillis:   clusterSettings.CacheMillis,
+			}
+		}
+		if len(*clusterProbes.InstanceProbes) == 0 {
+			log.Errorf("Could not find any URLs for HTTP cluster %s", clusterName)
+			continue
+		}
+		store.updateClusterProbes(clusterProbes)
+	}
+	return nil
+}
+
+// synchronous update of inventory
+func (store *httpStore) updateClusterProbes(clusterProbes *httpmetric.ClusterProbes) {
+	store.inventoryMutex.Lock()
+	defer store.inventoryMutex.Unlock()
+
+	log.Debugf("onHTTPClusterProbes: %s", clusterProbes.ClusterName)
+	store.inventory.ClustersProbes[clusterProbes.ClusterName] = clusterProbes.InstanceProbes
+	store.inventory.IgnoreHostsCount[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsCount
+	store.inventory.IgnoreHostsThreshold[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsThreshold
+	store.inventory.AggregationModes[clusterProbes.ClusterName] = clusterProbes.AggregationMode
+}
+
+// Aggregate aggregates collected data per cluster
+func (store *httpStore) Aggregate() map[string]base.MetricResult {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	aggregatedMetrics := make(map[string]base.MetricResult)
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+		ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+		aggregationMode := store.inventory.AggregationModes[clusterName]
+		aggregatedMetrics[clusterName] = aggregateHTTPProbes(probes, clusterName, store.inventory.ProbeMetrics, ignoreHostsCount, config.Settings().Stores.HTTP.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	}
+	return aggregatedMetrics
+}
+
+// HostMetrics breaks down a cluster's metric by hosts
+func (store *httpStore) HostMetrics(clusterName string) (hostMetrics []*HostMetric, found bool) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	probes, found := store.inventory.ClustersProbes[clusterName]
+	if !found {
+		return hostMetrics, false
+	}
+	ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+	ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+	aggregationMode := store.inventory.AggregationModes[clusterName]
+	return httpHostMetrics(probes, clusterName, store.inventory.ProbeMetrics, ignoreHostsCount, config.Settings().Stores.HTTP.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode), true
+}
+
+func (store *httpStore) Threshold(clusterName string) (float64, bool) {
+	if thresholdVal, found := store.clusterThresholds.Get(clusterName); found {
+		threshold, _ := thresholdVal.(float64)
+		return threshold, true
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+package throttle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/httpmetric"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestAggregateHTTPProbes(t *testing.T) {
+	clusterName := "queue"
+	url1, url2, url3 := "http://10.0.0.1/lag", "http://10.0.0.2/lag", "http://10.0.0.3/lag"
+	probeResultsMap := httpmetric.ProbeMetricResultMap{
+		httpmetric.GetClusterProbeKey(clusterName, url1): base.NewSimpleMetricResult(0.4),
+		httpmetric.GetClusterProbeKey(clusterName, url2): base.NewSimpleMetricResult(1.3),
+		httpmetric.GetClusterProbeKey(clusterName, url3): base.NewSimpleMetricResult(0.9),
+	}
+	var probes httpmetric.Probes = map[string](*httpmetric.Probe){}
+	for clusterKey := range probeResultsMap {
+		probes[clusterKey.URL] = &httpmetric.Probe{URL: clusterKey.URL}
+	}
+	{
+		worstMetric := aggregateHTTPProbes(&probes, clusterName, probeResultsMap, 0, false, 0, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 1.3)
+	}
+	{
+		worstMetric := aggregateHTTPProbes(&probes, clusterName, probeResultsMap, 1, false, 0, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 0.9)
+	}
+	{
+		probeResultsMap[httpmetric.GetClusterProbeKey(clusterName, url2)] = &httpmetric.HTTPThrottleMetric{Err: errors.New("dial tcp 10.0.0.2:80: connect: connection refused")}
+		worstMetric := aggregateHTTPProbes(&probes, clusterName, probeResultsMap, 0, false, 0, base.AggregationMode{})
+		_, err := worstMetric.Get()
+		test.S(t).ExpectNotNil(err)
+
+		worstMetric = aggregateHTTPProbes(&probes, clusterName, probeResultsMap, 0, true, 0, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 0.9)
+	}
+	{
+		delete(probeResultsMap, httpmetric.GetClusterProbeKey(clusterName, url3))
+		worstMetric := aggregateHTTPProbes(&probes, clusterName, probeResultsMap, 0, false, 0, base.AggregationMode{})
+		test.S(t).ExpectEquals(worstMetric, base.NoMetricResultYet)
+	}
+}
@@ -0,0 +1,124 @@
+package throttle
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/kafka"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const kafkaStoreType = "kafka"
+
+func init() {
+	RegisterStore(kafkaStoreType, newKafkaStore)
+}
+
+// kafkaStore collects consumer group lag, as configured in Stores.Kafka
+type kafkaStore struct {
+	inventory       *kafka.KafkaInventory
+	inventoryMutex  sync.RWMutex
+	groupThresholds *cache.Cache
+}
+
+func newKafkaStore() Store {
+	return &kafkaStore{
+		inventory:       kafka.NewKafkaInventory(),
+		groupThresholds: cache.New(cache.NoExpiration, 0),
+	}
+}
+
+// groupsProbes returns a snapshot of the inventory's probes. Probes are known not to change;
+// they can be *replaced*, but not changed.
+func (store *kafkaStore) groupsProbes() map[string](*kafka.Probe) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	groupsProbes := make(map[string](*kafka.Probe))
+	for groupName, probe := range store.inventory.GroupsProbes {
+		groupsProbes[groupName] = probe
+	}
+	return groupsProbes
+}
+
+func (store *kafkaStore) Collect() error {
+	for groupName, probe := range store.groupsProbes() {
+		groupName := groupName
+		probe := probe
+		go func() {
+			// Avoid reading the same group twice at the same time. If previous read is still there,
+			// we avoid re-reading it.
+			if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
+				return
+			}
+			defer atomic.StoreInt64(&probe.QueryInProgress, 0)
+			reader := kafka.GetOffsetsReader(probe.Brokers, time.Duration(probe.TimeoutMillis)*time.Millisecond)
+			throttleMetric := kafka.ReadThrottleMetric(probe, groupName, reader)
+
+			store.inventoryMutex.Lock()
+			defer store.inventoryMutex.Unlock()
+			store.inventory.GroupsMetrics[groupName] = throttleMetric
+		}()
+	}
+	return nil
+}
+
+// Refresh will re-structure the inventory based on reading config settings
+func (store *kafkaStore) Refresh() error {
+	log.Debugf("refreshing Kafka inventory")
+
+	for groupName, groupSettings := range config.Settings().Stores.Kafka.Groups {
+		store.groupThresholds.Set(groupName, groupSettings.ThrottleThreshold, cache.DefaultExpiration)
+		if len(groupSettings.Brokers) == 0 {
+			log.Errorf("No Brokers found for Kafka group %s", groupName)
+			continue
+		}
+		store.updateGroupProbe(groupName, &kafka.Probe{
+			GroupID:       groupSettings.GroupID,
+			Topics:        groupSettings.Topics,
+			Brokers:       groupSettings.Brokers,
+			Aggregation:   groupSettings.Aggregation,
+			TimeoutMillis: groupSettings.TimeoutMillis,
+			CacheMillis:   groupSettings.CacheMillis,
+		})
+	}
+	return nil
+}
+
+// synchronous update of inventory
+func (store *kafkaStore) updateGroupProbe(groupName string, probe *kafka.Probe) {
+	store.inventoryMutex.Lock()
+	defer store.inventoryMutex.Unlock()
+
+	log.Debugf("onKafkaGroupProbe: %s", groupName)
+	store.inventory.GroupsProbes[groupName] = probe
+}
+
+// Aggregate returns the collected lag per group. Lag is already aggregated across partitions when read.
+func (store *kafkaStore) Aggregate() map[string]base.MetricResult {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	aggregatedMetrics := make(map[string]base.MetricResult)
+	for groupName := range store.inventory.GroupsProbes {
+		if metric, ok := store.inventory.GroupsMetrics[groupName]; ok {
+			aggregatedMetrics[groupName] = metric
+		} else {
+			aggregatedMetrics[groupName] = base.NoMetricResultYet
+		}
+	}
+	return aggregatedMetrics
+}
+
+func (store *kafkaStore) Threshold(groupName string) (float64, bool) {
+	if thresholdVal, found := store.groupThresholds.Get(groupName); found {
+		threshold, _ := thresholdVal.(float64)
+		return threshold, true
+	}
+	return 0, false
+}
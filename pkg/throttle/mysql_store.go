@@ -0,0 +1,262 @@
+package throttle
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/mysql"
+	"github.com/github/freno/pkg/vitess"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const mysqlStoreType = "mysql"
+const mysqlHttpCheckInterval = 5 * time.Second
+
+func init() {
+	RegisterStore(mysqlStoreType, newMySQLStore)
+}
+
+// mysqlStore collects metrics from MySQL clusters, as configured in Stores.MySQL
+type mysqlStore struct {
+	inventory         *mysql.MySQLInventory
+	inventoryMutex    sync.RWMutex
+	clusterThresholds *cache.Cache
+
+	lastHttpCheck time.Time
+}
+
+func newMySQLStore() Store {
+	return &mysqlStore{
+		inventory:         mysql.NewMySQLInventory(),
+		clusterThresholds: cache.New(cache.NoExpiration, 0),
+	}
+}
+
+// clustersProbes returns a snapshot of the inventory's probes. Each cluster's probes are known not to change;
+// they can be *replaced*, but not changed. So it's safe to iterate them.
+func (store *mysqlStore) clustersProbes() map[string](*mysql.Probes) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	clustersProbes := make(map[string](*mysql.Probes))
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		clustersProbes[clusterName] = probes
+	}
+	return clustersProbes
+}
+
+func (store *mysqlStore) Collect() error {
+	if time.Since(store.lastHttpCheck) >= mysqlHttpCheckInterval {
+		store.lastHttpCheck = time.Now()
+		store.collectHttpChecks()
+	}
+	for clusterName, probes := range store.clustersProbes() {
+		clusterName := clusterName
+		probes := probes
+		go func() {
+			for _, probe := range *probes {
+				probe := probe
+				go func() {
+					// Avoid querying the same server twice at the same time. If previous read is still there,
+					// we avoid re-reading it.
+					if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
+						return
+					}
+					defer atomic.StoreInt64(&probe.QueryInProgress, 0)
+					throttleMetric := mysql.ReadThrottleMetric(probe, clusterName)
+
+					store.inventoryMutex.Lock()
+					defer store.inventoryMutex.Unlock()
+					store.inventory.InstanceKeyMetrics[throttleMetric.GetClusterInstanceKey()] = throttleMetric
+				}()
+			}
+		}()
+	}
+	return nil
+}
+
+func (store *mysqlStore) collectHttpChecks() {
+	for clusterName, probes := range store.clustersProbes() {
+		clusterName := clusterName
+		probes := probes
+		go func() {
+			for _, probe := range *probes {
+				probe := probe
+				go func() {
+					// Avoid querying the same server twice at the same time. If previous read is still there,
+					// we avoid re-reading it.
+					if !atomic.CompareAndSwapInt64(&probe.HttpCheckInProgress, 0, 1) {
+						return
+					}
+					defer atomic.StoreInt64(&probe.HttpCheckInProgress, 0)
+					httpCheckResult := mysql.CheckHttp(clusterName, probe)
+
+					store.inventoryMutex.Lock()
+					defer store.inventoryMutex.Unlock()
+					store.inventory.ClusterInstanceHttpChecks[httpCheckResult.HashKey()] = httpCheckResult.CheckResult
+				}()
+			}
+		}()
+	}
+}
+
+// Refresh will re-structure the inventory based on reading config settings, and potentially
+// re-querying dynamic data such as HAProxy list of hosts
+func (store *mysqlStore) Refresh() error {
+	log.Debugf("refreshing MySQL inventory")
+
+	addInstanceKey := func(key *mysql.InstanceKey, clusterName string, clusterSettings *config.MySQLClusterConfigurationSettings, probes *mysql.Probes) {
+		for _, ignore := range clusterSettings.IgnoreHosts {
+			if strings.Contains(key.StringCode(), ignore) {
+				log.Debugf("instance key ignored: %+v", key)
+				return
+			}
+		}
+		if !key.IsValid() {
+			log.Debugf("read invalid instance key: [%+v] for cluster %+v", key, clusterName)
+			return
+		}
+		log.Debugf("read instance key: %+v", key)
+
+		probe := &mysql.Probe{
+			Key:           *key,
+			User:          clusterSettings.User,
+			Password:      clusterSettings.Password,
+			MetricQuery:   clusterSettings.MetricQuery,
+			CacheMillis:   clusterSettings.CacheMillis,
+			HttpCheckPath: clusterSettings.HttpCheckPath,
+			HttpCheckPort: clusterSettings.HttpCheckPort,
+		}
+		(*probes)[*key] = probe
+	}
+
+	for clusterName, clusterSettings := range config.Settings().Stores.MySQL.Clusters {
+		clusterName := clusterName
+		clusterSettings := clusterSettings
+		// config may dynamically change, but internal structure (config.Settings().Stores.MySQL.Clusters in our case)
+		// is immutable and can only be _replaced_. Hence, it's safe to read in a goroutine:
+		go func() error {
+			store.clusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
+			if !clusterSettings.HAProxySettings.IsEmpty() {
+				totalHosts, err := readHAProxyHosts(&clusterSettings.HAProxySettings)
+				if err != nil {
+					return err
+				}
+
+				clusterProbes := &mysql.ClusterProbes{
+					ClusterName:          clusterName,
+					IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
+					IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
+					AggregationMode:      clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+					InstanceProbes:       mysql.NewProbes(),
+				}
+				for _, host := range totalHosts {
+					key := mysql.InstanceKey{Hostname: host, Port: clusterSettings.Port}
+					addInstanceKey(&key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+
+			if !clusterSettings.VitessSettings.IsEmpty() {
+				log.Debugf("getting vitess data from %s", clusterSettings.VitessSettings.API)
+				keyspace := clusterSettings.VitessSettings.Keyspace
+				shard := clusterSettings.VitessSettings.Shard
+				tablets, err := vitess.ParseTablets(clusterSettings.VitessSettings)
+				if err != nil {
+					return log.Errorf("Unable to get vitess hosts from %s, %s/%s: %+v", clusterSettings.VitessSettings.API, keyspace, shard, err)
+				}
+				log.Debugf("Read %+v hosts from vitess %s, %s/%s, cells=%s", len(tablets), clusterSettings.VitessSettings.API,
+					keyspace, shard, strings.Join(vitess.ParseCells(clusterSettings.VitessSettings), ","),
+				)
+				clusterProbes := &mysql.ClusterProbes{
+					ClusterName:      clusterName,
+					IgnoreHostsCount: clusterSettings.IgnoreHostsCount,
+					AggregationMode:  clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+					InstanceProbes:   mysql.NewProbes(),
+				}
+				for _, tablet := range tablets {
+					key := mysql.InstanceKey{Hostname: tablet.MysqlHostname, Port: int(tablet.MysqlPort)}
+					addInstanceKey(&key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+
+			if !clusterSettings.StaticHostsSettings.IsEmpty() {
+				clusterProbes := &mysql.ClusterProbes{
+					ClusterName:     clusterName,
+					AggregationMode: clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+					InstanceProbes:  mysql.NewProbes(),
+				}
+				for _, host := range clusterSettings.StaticHostsSettings.Hosts {
+					key, err := mysql.ParseInstanceKey(host, clusterSettings.Port)
+					if err != nil {
+						return log.Errore(err)
+					}
+					addInstanceKey(key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+			return log.Errorf("Could not find any hosts definition for cluster %s", clusterName)
+		}()
+	}
+	return nil
+}
+
+// synchronous update of inventory
+func (store *mysqlStore) updateClusterProbes(clusterProbes *mysql.ClusterProbes) {
+	store.inventoryMutex.Lock()
+	defer store.inventoryMutex.Unlock()
+
+	log.Debugf("onMySQLClusterProbes: %s", clusterProbes.ClusterName)
+	store.inventory.ClustersProbes[clusterProbes.ClusterName] = clusterProbes.InstanceProbes
+	store.inventory.IgnoreHostsCount[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsCount
+	store.inventory.IgnoreHostsThreshold[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsThreshold
+	store.inventory.AggregationModes[clusterProbes.ClusterName] = clusterProbes.AggregationMode
+}
+
+// Aggregate aggregates collected data per cluster
+func (store *mysqlStore) Aggregate() map[string]base.MetricResult {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	aggregatedMetrics := make(map[string]base.MetricResult)
+	for clusterName, probes := range store.inventory.ClustersProbes {
+		ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+		ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+		aggregationMode := store.inventory.AggregationModes[clusterName]
+		aggregatedMetrics[clusterName] = aggregateMySQLProbes(probes, clusterName, store.inventory.InstanceKeyMetrics, store.inventory.ClusterInstanceHttpChecks, ignoreHostsCount, config.Settings().Stores.MySQL.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	}
+	return aggregatedMetrics
+}
+
+// HostMetrics breaks down a cluster's metric by hosts
+func (store *mysqlStore) HostMetrics(clusterName string) (hostMetrics []*HostMetric, found bool) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	probes, found := store.inventory.ClustersProbes[clusterName]
+	if !found {
+		return hostMetrics, false
+	}
+	ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+	ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+	aggregationMode := store.inventory.AggregationModes[clusterName]
+	return mysqlHostMetrics(probes, clusterName, store.inventory.InstanceKeyMetrics, store.inventory.ClusterInstanceHttpChecks, ignoreHostsCount, config.Settings().Stores.MySQL.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode), true
+}
+
+func (store *mysqlStore) Threshold(clusterName string) (float64, bool) {
+	if thresholdVal, found := store.clusterThresholds.Get(clusterName); found {
+		threshold, _ := thresholdVal.(float64)
+		return threshold, true
+	}
+	return 0, false
+}
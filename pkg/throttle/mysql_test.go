@@ -54,37 +54,37 @@ func TestAggregateMySQLProbesNoErrors(t *testing.T) {
 		probes[clusterKey.Key] = &mysql.Probe{Key: clusterKey.Key}
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.7)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.2)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.1)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 3, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 3, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.6)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 4, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 4, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.3)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 5, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 5, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.3)
@@ -117,37 +117,37 @@ func TestAggregateMySQLProbesNoErrorsIgnoreHostsThreshold(t *testing.T) {
 		probes[clusterKey.Key] = &mysql.Probe{Key: clusterKey.Key}
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.7)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.2)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.1)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 3, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 3, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.6)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 4, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 4, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.6)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 5, false, 1.0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 5, false, 1.0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 0.6)
@@ -180,19 +180,19 @@ func TestAggregateMySQLProbesWithErrors(t *testing.T) {
 		probes[clusterKey.Key] = &mysql.Probe{Key: clusterKey.Key}
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		_, err := worstMetric.Get()
 		test.S(t).ExpectNotNil(err)
 		test.S(t).ExpectEquals(err, base.NoSuchMetricError)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.7)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.2)
@@ -200,19 +200,19 @@ func TestAggregateMySQLProbesWithErrors(t *testing.T) {
 
 	instanceResultsMap[key1cluster] = base.NoSuchMetric
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		_, err := worstMetric.Get()
 		test.S(t).ExpectNotNil(err)
 		test.S(t).ExpectEquals(err, base.NoSuchMetricError)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 1, false, 0, base.AggregationMode{})
 		_, err := worstMetric.Get()
 		test.S(t).ExpectNotNil(err)
 		test.S(t).ExpectEquals(err, base.NoSuchMetricError)
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.7)
@@ -245,13 +245,13 @@ func TestAggregateMySQLProbesWithHttpChecks(t *testing.T) {
 		probes[clusterKey.Key] = &mysql.Probe{Key: clusterKey.Key}
 	}
 	{
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		_, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 	}
 	{
 		clusterInstanceHttpCheckResultMap[mysql.MySQLHttpCheckHashKey(clusterName, &key2)] = http.StatusNotFound
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		value, err := worstMetric.Get()
 		test.S(t).ExpectNil(err)
 		test.S(t).ExpectEquals(value, 1.2)
@@ -260,8 +260,59 @@ func TestAggregateMySQLProbesWithHttpChecks(t *testing.T) {
 		for hashKey := range clusterInstanceHttpCheckResultMap {
 			clusterInstanceHttpCheckResultMap[hashKey] = http.StatusNotFound
 		}
-		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0)
+		worstMetric := aggregateMySQLProbes(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
 		_, err := worstMetric.Get()
 		test.S(t).ExpectNotNil(err)
 	}
 }
+
+func TestMySQLHostMetrics(t *testing.T) {
+	clusterName := "c0"
+	instanceResultsMap := mysql.InstanceMetricResultMap{
+		mysql.GetClusterInstanceKey(clusterName, &key1): base.NewSimpleMetricResult(1.2),
+		mysql.GetClusterInstanceKey(clusterName, &key2): base.NewSimpleMetricResult(1.7),
+		mysql.GetClusterInstanceKey(clusterName, &key4): base.NewSimpleMetricResult(0.6),
+	}
+	clusterInstanceHttpCheckResultMap := mysql.ClusterInstanceHttpCheckResultMap{
+		mysql.MySQLHttpCheckHashKey(clusterName, &key1): http.StatusNotFound,
+		mysql.MySQLHttpCheckHashKey(clusterName, &key2): http.StatusOK,
+		mysql.MySQLHttpCheckHashKey(clusterName, &key4): http.StatusOK,
+	}
+	var probes mysql.Probes = map[mysql.InstanceKey](*mysql.Probe){}
+	for _, key := range []mysql.InstanceKey{key1, key2, key3, key4} {
+		probes[key] = &mysql.Probe{Key: key}
+	}
+	{
+		hostMetrics := mysqlHostMetrics(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 0, false, 0, base.AggregationMode{})
+		test.S(t).ExpectEquals(len(hostMetrics), 4)
+
+		test.S(t).ExpectEquals(hostMetrics[0].Host, "10.0.0.1:3306")
+		test.S(t).ExpectTrue(hostMetrics[0].Excluded)
+		test.S(t).ExpectEquals(hostMetrics[0].HttpCheckStatus, http.StatusNotFound)
+
+		test.S(t).ExpectEquals(hostMetrics[1].Host, "10.0.0.2:3306")
+		test.S(t).ExpectEquals(hostMetrics[1].Value, 1.7)
+		test.S(t).ExpectFalse(hostMetrics[1].Ignored)
+		test.S(t).ExpectEquals(hostMetrics[1].HttpCheckStatus, http.StatusOK)
+
+		test.S(t).ExpectEquals(hostMetrics[2].Host, "10.0.0.3:3306")
+		test.S(t).ExpectTrue(hostMetrics[2].Error != "")
+		test.S(t).ExpectFalse(hostMetrics[2].Ignored)
+	}
+	{
+		hostMetrics := mysqlHostMetrics(&probes, clusterName, instanceResultsMap, clusterInstanceHttpCheckResultMap, 2, false, 0, base.AggregationMode{})
+		test.S(t).ExpectEquals(len(hostMetrics), 4)
+
+		test.S(t).ExpectTrue(hostMetrics[0].Excluded)
+		test.S(t).ExpectFalse(hostMetrics[0].Ignored)
+
+		test.S(t).ExpectTrue(hostMetrics[1].Ignored)
+		test.S(t).ExpectEquals(hostMetrics[1].Reason, "highest value ignored (IgnoreHostsCount)")
+
+		test.S(t).ExpectTrue(hostMetrics[2].Ignored)
+		test.S(t).ExpectEquals(hostMetrics[2].Reason, "error ignored (IgnoreHostsCount)")
+
+		test.S(t).ExpectFalse(hostMetrics[3].Ignored)
+		test.S(t).ExpectEquals(hostMetrics[3].Value, 0.6)
+	}
+}
@@ -0,0 +1,102 @@
+package throttle
+
+import (
+	"strings"
+
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/hostprobe"
+	"github.com/github/freno/pkg/postgresql"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const postgresqlStoreType = "postgresql"
+
+func init() {
+	RegisterStore(postgresqlStoreType, newPostgreSQLStore)
+}
+
+// postgresqlStore collects metrics from PostgreSQL clusters, as configured in Stores.PostgreSQL
+type postgresqlStore struct {
+	*hostProbeStore
+}
+
+func newPostgreSQLStore() Store {
+	return &postgresqlStore{
+		hostProbeStore: newHostProbeStore(postgresqlStoreType, func() bool { return config.Settings().Stores.PostgreSQL.IgnoreDialTcpErrors }),
+	}
+}
+
+// Refresh will re-structure the inventory based on reading config settings, and potentially
+// re-querying dynamic data such as HAProxy list of hosts
+func (store *postgresqlStore) Refresh() error {
+	log.Debugf("refreshing PostgreSQL inventory")
+
+	addInstanceKey := func(key *hostprobe.InstanceKey, clusterName string, clusterSettings *config.PostgreSQLClusterConfigurationSettings, probes *hostprobe.Probes) {
+		for _, ignore := range clusterSettings.IgnoreHosts {
+			if strings.Contains(key.StringCode(), ignore) {
+				log.Debugf("instance key ignored: %+v", key)
+				return
+			}
+		}
+		if !key.IsValid() {
+			log.Debugf("read invalid instance key: [%+v] for cluster %+v", key, clusterName)
+			return
+		}
+		log.Debugf("read instance key: %+v", key)
+
+		probe := &postgresql.Probe{
+			Probe:       hostprobe.Probe{Key: *key, CacheMillis: clusterSettings.CacheMillis},
+			User:        clusterSettings.User,
+			Password:    clusterSettings.Password,
+			Database:    clusterSettings.Database,
+			SSLMode:     clusterSettings.SSLMode,
+			MetricQuery: clusterSettings.MetricQuery,
+		}
+		(*probes)[*key] = probe
+	}
+
+	for clusterName, clusterSettings := range config.Settings().Stores.PostgreSQL.Clusters {
+		clusterName := clusterName
+		clusterSettings := clusterSettings
+		// config may dynamically change, but internal structure (config.Settings().Stores.PostgreSQL.Clusters in our case)
+		// is immutable and can only be _replaced_. Hence, it's safe to read in a goroutine:
+		go func() error {
+			store.clusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
+			clusterProbes := &hostprobe.ClusterProbes{
+				ClusterName:          clusterName,
+				IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
+				IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
+				AggregationMode:      clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+				InstanceProbes:       hostprobe.NewProbes(),
+			}
+			if !clusterSettings.HAProxySettings.IsEmpty() {
+				totalHosts, err := readHAProxyHosts(&clusterSettings.HAProxySettings)
+				if err != nil {
+					return err
+				}
+				for _, host := range totalHosts {
+					key := hostprobe.InstanceKey{Hostname: host, Port: clusterSettings.Port}
+					addInstanceKey(&key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+
+			if !clusterSettings.StaticHostsSettings.IsEmpty() {
+				for _, host := range clusterSettings.StaticHostsSettings.Hosts {
+					key, err := hostprobe.ParseInstanceKey(host, clusterSettings.Port)
+					if err != nil {
+						return log.Errore(err)
+					}
+					addInstanceKey(key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+			return log.Errorf("Could not find any hosts definition for PostgreSQL cluster %s", clusterName)
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package throttle
+
+import (
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/prometheus"
+)
+
+func aggregatePrometheusMetric(
+	prometheusMetric *prometheus.PrometheusThrottleMetric,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (worstMetric base.MetricResult) {
+	if prometheusMetric == nil {
+		return base.NoMetricResultYet
+	}
+	if _, err := prometheusMetric.Get(); err != nil {
+		// The query itself failed; there are no hosts to ignore
+		return prometheusMetric
+	}
+	metricResults := []base.MetricResult{}
+	for _, hostMetricResult := range prometheusMetric.HostMetrics {
+		metricResults = append(metricResults, hostMetricResult)
+	}
+	return aggregateMetricResults(metricResults, ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+}
+
+// prometheusHostMetrics breaks down a query's metric by hosts, following the same logic as aggregatePrometheusMetric
+func prometheusHostMetrics(
+	prometheusMetric *prometheus.PrometheusThrottleMetric,
+	ignoreHostsCount int,
+	ignoreDialTcpErrors bool,
+	ignoreHostsThreshold float64,
+	aggregationMode base.AggregationMode,
+) (hostMetrics []*HostMetric) {
+	aggregation := &hostsAggregation{}
+	if prometheusMetric == nil {
+		return hostMetrics
+	}
+	for host, hostMetricResult := range prometheusMetric.HostMetrics {
+		aggregation.add(host, hostMetricResult, prometheusMetric.Timestamp)
+	}
+	_, hostMetrics = aggregation.aggregate(ignoreHostsCount, ignoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	return hostMetrics
+}
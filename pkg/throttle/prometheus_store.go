@@ -0,0 +1,147 @@
+package throttle
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/prometheus"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const prometheusStoreType = "prometheus"
+
+func init() {
+	RegisterStore(prometheusStoreType, newPrometheusStore)
+}
+
+// prometheusStore collects metrics by running PromQL queries, as configured in Stores.Prometheus
+type prometheusStore struct {
+	inventory         *prometheus.PrometheusInventory
+	inventoryMutex    sync.RWMutex
+	clusterThresholds *cache.Cache
+}
+
+func newPrometheusStore() Store {
+	return &prometheusStore{
+		inventory:         prometheus.NewPrometheusInventory(),
+		clusterThresholds: cache.New(cache.NoExpiration, 0),
+	}
+}
+
+// clustersProbes returns a snapshot of the inventory's probes. Probes are known not to change;
+// they can be *replaced*, but not changed.
+func (store *prometheusStore) clustersProbes() map[string](*prometheus.Probe) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	clustersProbes := make(map[string](*prometheus.Probe))
+	for clusterName, probe := range store.inventory.ClustersProbes {
+		clustersProbes[clusterName] = probe
+	}
+	return clustersProbes
+}
+
+func (store *prometheusStore) Collect() error {
+	for clusterName, probe := range store.clustersProbes() {
+		clusterName := clusterName
+		probe := probe
+		go func() {
+			// Avoid running the same query twice at the same time. If previous query is still running,
+			// we avoid re-running it.
+			if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
+				return
+			}
+			defer atomic.StoreInt64(&probe.QueryInProgress, 0)
+			throttleMetric := prometheus.ReadThrottleMetric(probe, clusterName)
+
+			store.inventoryMutex.Lock()
+			defer store.inventoryMutex.Unlock()
+			store.inventory.ClustersMetrics[clusterName] = throttleMetric
+		}()
+	}
+	return nil
+}
+
+// Refresh will re-structure the inventory based on reading config settings
+func (store *prometheusStore) Refresh() error {
+	log.Debugf("refreshing Prometheus inventory")
+
+	for clusterName, clusterSettings := range config.Settings().Stores.Prometheus.Clusters {
+		store.clusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
+		if clusterSettings.Address == "" {
+			log.Errorf("No Address found for Prometheus cluster %s", clusterName)
+			continue
+		}
+		if clusterSettings.Query == "" {
+			log.Errorf("No Query found for Prometheus cluster %s", clusterName)
+			continue
+		}
+		store.updateClusterProbe(&prometheus.ClusterProbe{
+			ClusterName:          clusterName,
+			IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
+			IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
+			AggregationMode:      clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+			Probe: &prometheus.Probe{
+				Address:       clusterSettings.Address,
+				Query:         clusterSettings.Query,
+				HostLabel:     clusterSettings.HostLabel,
+				TimeoutMillis: clusterSettings.TimeoutMillis,
+				CacheMillis:   clusterSettings.CacheMillis,
+			},
+		})
+	}
+	return nil
+}
+
+// synchronous update of inventory
+func (store *prometheusStore) updateClusterProbe(clusterProbe *prometheus.ClusterProbe) {
+	store.inventoryMutex.Lock()
+	defer store.inventoryMutex.Unlock()
+
+	log.Debugf("onPrometheusClusterProbe: %s", clusterProbe.ClusterName)
+	store.inventory.ClustersProbes[clusterProbe.ClusterName] = clusterProbe.Probe
+	store.inventory.IgnoreHostsCount[clusterProbe.ClusterName] = clusterProbe.IgnoreHostsCount
+	store.inventory.IgnoreHostsThreshold[clusterProbe.ClusterName] = clusterProbe.IgnoreHostsThreshold
+	store.inventory.AggregationModes[clusterProbe.ClusterName] = clusterProbe.AggregationMode
+}
+
+// Aggregate aggregates collected data per cluster
+func (store *prometheusStore) Aggregate() map[string]base.MetricResult {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	aggregatedMetrics := make(map[string]base.MetricResult)
+	for clusterName := range store.inventory.ClustersProbes {
+		ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+		ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+		aggregationMode := store.inventory.AggregationModes[clusterName]
+		aggregatedMetrics[clusterName] = aggregatePrometheusMetric(store.inventory.ClustersMetrics[clusterName], ignoreHostsCount, config.Settings().Stores.Prometheus.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode)
+	}
+	return aggregatedMetrics
+}
+
+// HostMetrics breaks down a cluster's metric by hosts
+func (store *prometheusStore) HostMetrics(clusterName string) (hostMetrics []*HostMetric, found bool) {
+	store.inventoryMutex.RLock()
+	defer store.inventoryMutex.RUnlock()
+
+	if _, found := store.inventory.ClustersProbes[clusterName]; !found {
+		return hostMetrics, false
+	}
+	ignoreHostsCount := store.inventory.IgnoreHostsCount[clusterName]
+	ignoreHostsThreshold := store.inventory.IgnoreHostsThreshold[clusterName]
+	aggregationMode := store.inventory.AggregationModes[clusterName]
+	return prometheusHostMetrics(store.inventory.ClustersMetrics[clusterName], ignoreHostsCount, config.Settings().Stores.Prometheus.IgnoreDialTcpErrors, ignoreHostsThreshold, aggregationMode), true
+}
+
+func (store *prometheusStore) Threshold(clusterName string) (float64, bool) {
+	if thresholdVal, found := store.clusterThresholds.Get(clusterName); found {
+		threshold, _ := thresholdVal.(float64)
+		return threshold, true
+	}
+	return 0, false
+}
@@ -0,0 +1,51 @@
+package throttle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/prometheus"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestAggregatePrometheusMetric(t *testing.T) {
+	{
+		worstMetric := aggregatePrometheusMetric(nil, 0, false, 0, base.AggregationMode{})
+		test.S(t).ExpectEquals(worstMetric, base.NoMetricResultYet)
+	}
+	{
+		prometheusMetric := &prometheus.PrometheusThrottleMetric{Err: errors.New("prometheus query failed")}
+		worstMetric := aggregatePrometheusMetric(prometheusMetric, 1, false, 0, base.AggregationMode{})
+		_, err := worstMetric.Get()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		prometheusMetric := prometheus.NewPrometheusThrottleMetric()
+		worstMetric := aggregatePrometheusMetric(prometheusMetric, 0, false, 0, base.AggregationMode{})
+		test.S(t).ExpectEquals(worstMetric, base.NoHostsMetricResult)
+	}
+	prometheusMetric := prometheus.NewPrometheusThrottleMetric()
+	prometheusMetric.HostMetrics["db1"] = base.NewSimpleMetricResult(0.4)
+	prometheusMetric.HostMetrics["db2"] = base.NewSimpleMetricResult(1.3)
+	prometheusMetric.HostMetrics["db3"] = base.NewSimpleMetricResult(0.9)
+	{
+		worstMetric := aggregatePrometheusMetric(prometheusMetric, 0, false, 0, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 1.3)
+	}
+	{
+		worstMetric := aggregatePrometheusMetric(prometheusMetric, 1, false, 0, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 0.9)
+	}
+	{
+		worstMetric := aggregatePrometheusMetric(prometheusMetric, 1, false, 1.5, base.AggregationMode{})
+		value, err := worstMetric.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 1.3)
+	}
+}
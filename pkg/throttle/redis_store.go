@@ -0,0 +1,112 @@
+package throttle
+
+import (
+	"strings"
+	"time"
+
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/hostprobe"
+	"github.com/github/freno/pkg/redis"
+
+	"github.com/outbrain/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const redisStoreType = "redis"
+
+func init() {
+	RegisterStore(redisStoreType, newRedisStore)
+}
+
+// redisStore collects replication lag from Redis clusters, as configured in Stores.Redis
+type redisStore struct {
+	*hostProbeStore
+}
+
+func newRedisStore() Store {
+	return &redisStore{
+		hostProbeStore: newHostProbeStore(redisStoreType, func() bool { return config.Settings().Stores.Redis.IgnoreDialTcpErrors }),
+	}
+}
+
+// Refresh will re-structure the inventory based on reading config settings, and potentially
+// re-discovering replicas via the primary's `INFO replication`
+func (store *redisStore) Refresh() error {
+	log.Debugf("refreshing Redis inventory")
+
+	addInstanceKey := func(key *hostprobe.InstanceKey, primaryKey *hostprobe.InstanceKey, clusterName string, clusterSettings *config.RedisClusterConfigurationSettings, probes *hostprobe.Probes) {
+		for _, ignore := range clusterSettings.IgnoreHosts {
+			if strings.Contains(key.StringCode(), ignore) {
+				log.Debugf("instance key ignored: %+v", key)
+				return
+			}
+		}
+		if !key.IsValid() {
+			log.Debugf("read invalid instance key: [%+v] for cluster %+v", key, clusterName)
+			return
+		}
+		log.Debugf("read instance key: %+v", key)
+
+		probe := &redis.Probe{
+			Probe:         hostprobe.Probe{Key: *key, CacheMillis: clusterSettings.CacheMillis},
+			PrimaryKey:    primaryKey,
+			User:          clusterSettings.User,
+			Password:      clusterSettings.Password,
+			LagMetric:     clusterSettings.LagMetric,
+			TimeoutMillis: clusterSettings.TimeoutMillis,
+		}
+		(*probes)[*key] = probe
+	}
+
+	for clusterName, clusterSettings := range config.Settings().Stores.Redis.Clusters {
+		clusterName := clusterName
+		clusterSettings := clusterSettings
+		// config may dynamically change, but internal structure (config.Settings().Stores.Redis.Clusters in our case)
+		// is immutable and can only be _replaced_. Hence, it's safe to read in a goroutine:
+		go func() error {
+			store.clusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
+			clusterProbes := &hostprobe.ClusterProbes{
+				ClusterName:          clusterName,
+				IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
+				IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
+				AggregationMode:      clusterAggregationMode(clusterName, clusterSettings.AggregationMode),
+				InstanceProbes:       hostprobe.NewProbes(),
+			}
+			var primaryKey *hostprobe.InstanceKey
+			if clusterSettings.Primary != "" {
+				key, err := hostprobe.ParseInstanceKey(clusterSettings.Primary, clusterSettings.Port)
+				if err != nil {
+					return log.Errore(err)
+				}
+				primaryKey = key
+			}
+
+			if !clusterSettings.StaticHostsSettings.IsEmpty() {
+				for _, host := range clusterSettings.StaticHostsSettings.Hosts {
+					key, err := hostprobe.ParseInstanceKey(host, clusterSettings.Port)
+					if err != nil {
+						return log.Errore(err)
+					}
+					addInstanceKey(key, primaryKey, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+
+			if primaryKey != nil {
+				primaryInfo, err := redis.ReadReplicationInfo(primaryKey, clusterSettings.User, clusterSettings.Password, time.Duration(clusterSettings.TimeoutMillis)*time.Millisecond)
+				if err != nil {
+					return log.Errorf("Unable to discover replicas of Redis cluster %s via %s: %+v", clusterName, primaryKey.StringCode(), err)
+				}
+				for _, key := range primaryInfo.Replicas() {
+					key := key
+					addInstanceKey(&key, primaryKey, clusterName, clusterSettings, clusterProbes.InstanceProbes)
+				}
+				store.updateClusterProbes(clusterProbes)
+				return nil
+			}
+			return log.Errorf("Could not find any hosts definition for Redis cluster %s", clusterName)
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package throttle
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/github/freno/pkg/base"
+	"github.com/github/freno/pkg/config"
+	"github.com/github/freno/pkg/haproxy"
+
+	"github.com/outbrain/golib/log"
+)
+
+// Store is a backend freno collects metrics from, such as MySQL. A store manages any number of
+// named clusters (store names). Each is aggregated into a single metric, which apps check via
+// `/check/<app>/<store-type>/<store-name>`.
+//
+// The throttler drives all stores alike: sparsely refreshing their inventory, frequently collecting
+// and aggregating their metrics. All of which only take place on the leader.
+type Store interface {
+	// Refresh re-reads the store's configuration and re-discovers its hosts.
+	Refresh() error
+	// Collect probes the store's hosts. It is expected to return immediately and to
+	// collect asynchronously.
+	Collect() error
+	// Aggregate returns an aggregated metric per store name, based on latest collected data.
+	Aggregate() map[string]base.MetricResult
+	// Threshold returns the throttle threshold for a given store name
+	Threshold(storeName string) (threshold float64, found bool)
+}
+
+// StoreFactory creates a new Store instance
+type StoreFactory func() Store
+
+var storeFactories = map[string]StoreFactory{}
+var storeFactoriesMutex sync.Mutex
+
+// RegisterStore makes a store type available to the throttler. It is expected to be called
+// upon init(), and panics on duplicate registration.
+func RegisterStore(storeType string, factory StoreFactory) {
+	storeFactoriesMutex.Lock()
+	defer storeFactoriesMutex.Unlock()
+
+	if _, found := storeFactories[storeType]; found {
+		panic("store type already registered: " + storeType)
+	}
+	storeFactories[storeType] = factory
+}
+
+// RegisteredStoreTypes returns the sorted list of known store types
+func RegisteredStoreTypes() (storeTypes []string) {
+	storeFactoriesMutex.Lock()
+	defer storeFactoriesMutex.Unlock()
+
+	for storeType := range storeFactories {
+		storeTypes = append(storeTypes, storeType)
+	}
+	sort.Strings(storeTypes)
+	return storeTypes
+}
+
+// newStores creates an instance of each registered store type
+func newStores() map[string]Store {
+	storeFactoriesMutex.Lock()
+	defer storeFactoriesMutex.Unlock()
+
+	stores := make(map[string]Store)
+	for storeType, factory := range storeFactories {
+		stores[storeType] = factory()
+	}
+	return stores
+}
+
+// readHAProxyHosts returns the throttler hosts found in the configured HAProxy pool, across all
+// given HAProxy addresses
+func readHAProxyHosts(settings *config.HAProxyConfigurationSettings) (totalHosts []string, err error) {
+	poolName := settings.PoolName
+	addresses, _ := settings.GetProxyAddresses()
+	for _, u := range addresses {
+		log.Debugf("getting haproxy data from %s", u.String())
+		csv, err := haproxy.Read(u)
+		if err != nil {
+			return totalHosts, log.Errorf("Unable to get HAproxy data from %s: %+v", u.String(), err)
+		}
+		if backendHosts, err := haproxy.ParseCsvHosts(csv, poolName); err == nil {
+			hosts := haproxy.FilterThrotllerHosts(backendHosts)
+			totalHosts = append(totalHosts, hosts...)
+			log.Debugf("Read %+v hosts from haproxy %s/#%s", len(hosts), u.String(), poolName)
+		} else {
+			log.Errorf("Unable to get HAproxy hosts from %s/#%s: %+v", u.String(), poolName, err)
+		}
+	}
+	if len(totalHosts) == 0 {
+		return totalHosts, log.Errorf("Unable to get any HAproxy hosts for pool: %+v", poolName)
+	}
+	return totalHosts, nil
+}
@@ -0,0 +1,72 @@
+package throttle
+
+import (
+	"testing"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+type fakeStore struct {
+	thresholds map[string]float64
+	metrics    map[string]base.MetricResult
+}
+
+func (store *fakeStore) Refresh() error                          { return nil }
+func (store *fakeStore) Collect() error                          { return nil }
+func (store *fakeStore) Aggregate() map[string]base.MetricResult { return store.metrics }
+func (store *fakeStore) Threshold(storeName string) (float64, bool) {
+	threshold, found := store.thresholds[storeName]
+	return threshold, found
+}
+
+func TestRegisteredStoreTypes(t *testing.T) {
+	storeTypes := RegisteredStoreTypes()
+	test.S(t).ExpectTrue(len(storeTypes) >= 2)
+
+	registered := map[string]bool{}
+	for _, storeType := range storeTypes {
+		registered[storeType] = true
+	}
+	test.S(t).ExpectTrue(registered["mysql"])
+	test.S(t).ExpectTrue(registered["postgresql"])
+}
+
+func TestRegisterStoreDuplicate(t *testing.T) {
+	defer func() {
+		test.S(t).ExpectNotNil(recover())
+	}()
+	RegisterStore("mysql", newMySQLStore)
+}
+
+func TestGetStoreMetrics(t *testing.T) {
+	throttler := NewThrottler()
+	throttler.stores = map[string]Store{
+		"fake": &fakeStore{
+			thresholds: map[string]float64{"c0": 2.5},
+			metrics:    map[string]base.MetricResult{"c0": base.NewSimpleMetricResult(1.5)},
+		},
+	}
+	test.S(t).ExpectTrue(throttler.hasStoreType("fake"))
+	test.S(t).ExpectFalse(throttler.hasStoreType("mysql"))
+
+	for storeName, metricResult := range throttler.stores["fake"].Aggregate() {
+		throttler.aggregatedMetrics.SetDefault("fake/"+storeName, metricResult)
+	}
+	{
+		metricResult, threshold := throttler.getStoreMetrics("fake", "c0")
+		value, err := metricResult.Get()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(value, 1.5)
+		test.S(t).ExpectEquals(threshold, 2.5)
+	}
+	{
+		metricResult, _ := throttler.getStoreMetrics("fake", "c1")
+		test.S(t).ExpectEquals(metricResult, base.NoSuchMetric)
+	}
+	{
+		metricResult, _ := throttler.getStoreMetrics("mysql", "c0")
+		test.S(t).ExpectEquals(metricResult, base.NoSuchMetric)
+	}
+}
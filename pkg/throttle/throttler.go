@@ -8,14 +8,10 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/github/freno/pkg/base"
 	"github.com/github/freno/pkg/config"
-	"github.com/github/freno/pkg/haproxy"
-	"github.com/github/freno/pkg/mysql"
-	"github.com/github/freno/pkg/vitess"
 
 	"github.com/outbrain/golib/log"
 	"github.com/patrickmn/go-cache"
@@ -25,10 +21,9 @@ import (
 )
 
 const leaderCheckInterval = 1 * time.Second
-const mysqlCollectInterval = 50 * time.Millisecond
-const mysqlRefreshInterval = 10 * time.Second
-const mysqlAggreateInterval = 25 * time.Millisecond
-const mysqlHttpCheckInterval = 5 * time.Second
+const storesCollectInterval = 50 * time.Millisecond
+const storesRefreshInterval = 10 * time.Second
+const storesAggregateInterval = 25 * time.Millisecond
 const sharedDomainCollectInterval = 1 * time.Second
 
 const aggregatedMetricsExpiration = 5 * time.Second
@@ -51,14 +46,9 @@ type Throttler struct {
 	isLeaderFunc             func() bool
 	sharedDomainServicesFunc func() (map[string]string, error)
 
-	mysqlThrottleMetricChan chan *mysql.MySQLThrottleMetric
-	mysqlHttpCheckChan      chan *mysql.MySQLHttpCheck
-	mysqlInventoryChan      chan *mysql.MySQLInventory
-	mysqlClusterProbesChan  chan *mysql.ClusterProbes
+	stores     map[string]Store // store type -> store
+	aggregated *tickNotifier    // notified upon each aggregation tick
 
-	mysqlInventory *mysql.MySQLInventory
-
-	mysqlClusterThresholds  *cache.Cache
 	aggregatedMetrics       *cache.Cache
 	throttledApps           *cache.Cache
 	recentApps              *cache.Cache
@@ -69,6 +59,13 @@ type Throttler struct {
 	memcachePath   string
 
 	throttledAppsMutex sync.Mutex
+	appRules           base.AppRules // the rules among throttled apps, by precedence
+	appRulesMutex      sync.RWMutex
+
+	appThresholds           map[string]*base.AppThreshold // app thresholds set at runtime, by key; see base.AppThrottleKey
+	configuredAppThresholds map[string]*base.AppThreshold // app thresholds of the AppThresholds configuration, by key
+	appThresholdRules       base.AppRules                 // the rules among app thresholds of either kind, by precedence
+	appThresholdsMutex      sync.RWMutex
 
 	nonLowPriorityAppRequestsThrottled *cache.Cache
 	httpClient                         *http.Client
@@ -78,15 +75,10 @@ func NewThrottler() *Throttler {
 	throttler := &Throttler{
 		isLeader: false,
 
-		mysqlThrottleMetricChan: make(chan *mysql.MySQLThrottleMetric),
-		mysqlHttpCheckChan:      make(chan *mysql.MySQLHttpCheck),
-
-		mysqlInventoryChan:     make(chan *mysql.MySQLInventory, 1),
-		mysqlClusterProbesChan: make(chan *mysql.ClusterProbes),
-		mysqlInventory:         mysql.NewMySQLInventory(),
+		stores:     newStores(),
+		aggregated: newTickNotifier(),
 
 		throttledApps:           cache.New(cache.NoExpiration, 10*time.Second),
-		mysqlClusterThresholds:  cache.New(cache.NoExpiration, 0),
 		aggregatedMetrics:       cache.New(aggregatedMetricsExpiration, aggregatedMetricsCleanup),
 		recentApps:              cache.New(recentAppsExpiration, time.Minute),
 		metricsHealth:           cache.New(cache.NoExpiration, 0),
@@ -95,7 +87,11 @@ func NewThrottler() *Throttler {
 		nonLowPriorityAppRequestsThrottled: cache.New(nonDeprioritizedAppMapExpiration, nonDeprioritizedAppMapInterval),
 
 		httpClient: base.SetupHttpClient(0),
+
+		appThresholds:           make(map[string]*base.AppThreshold),
+		configuredAppThresholds: make(map[string]*base.AppThreshold),
 	}
+	throttler.refreshConfiguredAppThresholds()
 	throttler.ThrottleApp("abusing-app", time.Now().Add(time.Hour*24*365*10), DefaultThrottleRatio)
 	if memcacheServers := config.Settings().MemcacheServers; len(memcacheServers) > 0 {
 		throttler.memcacheClient = memcache.New(memcacheServers...)
@@ -119,15 +115,14 @@ func (throttler *Throttler) ThrottledAppsSnapshot() map[string]cache.Item {
 
 func (throttler *Throttler) Operate() {
 	leaderCheckTick := time.Tick(leaderCheckInterval)
-	mysqlCollectTick := time.Tick(mysqlCollectInterval)
-	mysqlRefreshTick := time.Tick(mysqlRefreshInterval)
-	mysqlAggregateTick := time.Tick(mysqlAggreateInterval)
-	mysqlHttpCheckTick := time.Tick(mysqlHttpCheckInterval)
+	storesCollectTick := time.Tick(storesCollectInterval)
+	storesRefreshTick := time.Tick(storesRefreshInterval)
+	storesAggregateTick := time.Tick(storesAggregateInterval)
 	throttledAppsTick := time.Tick(throttledAppsSnapshotInterval)
 	sharedDomainTick := time.Tick(sharedDomainCollectInterval)
 
 	// initial read of inventory:
-	go throttler.refreshMySQLInventory()
+	go throttler.refreshStores()
 
 	for {
 		select {
@@ -136,46 +131,29 @@ func (throttler *Throttler) Operate() {
 				// sparse
 				throttler.isLeader = throttler.isLeaderFunc()
 			}
-		case <-mysqlCollectTick:
+		case <-storesCollectTick:
 			{
 				// frequent
-				throttler.collectMySQLMetrics()
+				throttler.collectStoresMetrics()
 			}
-		case <-mysqlHttpCheckTick:
+		case <-storesRefreshTick:
 			{
-				throttler.collectMySQLHttpChecks()
-			}
-		case metric := <-throttler.mysqlThrottleMetricChan:
-			{
-				// incoming MySQL metric, frequent, as result of collectMySQLMetrics()
-				throttler.mysqlInventory.InstanceKeyMetrics[metric.GetClusterInstanceKey()] = metric
+				// sparse
+				go throttler.refreshStores()
 			}
-		case httpCheckResult := <-throttler.mysqlHttpCheckChan:
+		case <-storesAggregateTick:
 			{
-				// incoming MySQL metric, frequent, as result of collectMySQLMetrics()
-				throttler.mysqlInventory.ClusterInstanceHttpChecks[httpCheckResult.HashKey()] = httpCheckResult.CheckResult
-			}
-		case <-mysqlRefreshTick:
-			{
-				// sparse
-				go throttler.refreshMySQLInventory()
+				throttler.aggregateStoresMetrics()
+				throttler.aggregated.notify()
 			}
 		case <-sharedDomainTick:
 			{
 				go throttler.collectShareDomainMetricHealth()
 			}
-		case probes := <-throttler.mysqlClusterProbesChan:
-			{
-				// incoming structural update, sparse, as result of refreshMySQLInventory()
-				throttler.updateMySQLClusterProbes(probes)
-			}
-		case <-mysqlAggregateTick:
-			{
-				throttler.aggregateMySQLMetrics()
-			}
 		case <-throttledAppsTick:
 			{
 				go throttler.expireThrottledApps()
+				go throttler.refreshConfiguredAppThresholds()
 				go throttler.pushStatusToExpVar()
 			}
 		}
@@ -185,220 +163,66 @@ func (throttler *Throttler) Operate() {
 	}
 }
 
-func (throttler *Throttler) collectMySQLMetrics() error {
+// collectStoresMetrics asks all stores to probe their hosts. Stores collect asynchronously.
+func (throttler *Throttler) collectStoresMetrics() error {
 	if !throttler.isLeader {
 		return nil
 	}
-	// synchronously, get lists of probes
-	for clusterName, probes := range throttler.mysqlInventory.ClustersProbes {
-		clusterName := clusterName
-		probes := probes
-		go func() {
-			// probes is known not to change. It can be *replaced*, but not changed.
-			// so it's safe to iterate it
-			for _, probe := range *probes {
-				probe := probe
-				go func() {
-					// Avoid querying the same server twice at the same time. If previous read is still there,
-					// we avoid re-reading it.
-					if !atomic.CompareAndSwapInt64(&probe.QueryInProgress, 0, 1) {
-						return
-					}
-					defer atomic.StoreInt64(&probe.QueryInProgress, 0)
-					throttleMetrics := mysql.ReadThrottleMetric(probe, clusterName)
-					throttler.mysqlThrottleMetricChan <- throttleMetrics
-				}()
-			}
-		}()
+	for storeType, store := range throttler.stores {
+		if err := store.Collect(); err != nil {
+			log.Errorf("error collecting %s metrics: %+v", storeType, err)
+		}
 	}
 	return nil
 }
 
-func (throttler *Throttler) collectMySQLHttpChecks() error {
+// refreshStores will have all stores re-read their config settings and re-discover their hosts
+func (throttler *Throttler) refreshStores() error {
 	if !throttler.isLeader {
 		return nil
 	}
-	// synchronously, get lists of probes
-	for clusterName, probes := range throttler.mysqlInventory.ClustersProbes {
-		clusterName := clusterName
-		probes := probes
+	for storeType, store := range throttler.stores {
+		storeType := storeType
+		store := store
 		go func() {
-			// probes is known not to change. It can be *replaced*, but not changed.
-			// so it's safe to iterate it
-			for _, probe := range *probes {
-				probe := probe
-				go func() {
-					// Avoid querying the same server twice at the same time. If previous read is still there,
-					// we avoid re-reading it.
-					if !atomic.CompareAndSwapInt64(&probe.HttpCheckInProgress, 0, 1) {
-						return
-					}
-					defer atomic.StoreInt64(&probe.HttpCheckInProgress, 0)
-					httpCheckResult := mysql.CheckHttp(clusterName, probe)
-					throttler.mysqlHttpCheckChan <- httpCheckResult
-				}()
+			if err := store.Refresh(); err != nil {
+				log.Errorf("error refreshing %s inventory: %+v", storeType, err)
 			}
 		}()
 	}
 	return nil
 }
 
-// refreshMySQLInventory will re-structure the inventory based on reading config settings, and potentially
-// re-querying dynamic data such as HAProxy list of hosts
-func (throttler *Throttler) refreshMySQLInventory() error {
+// synchronous aggregation of collected data
+func (throttler *Throttler) aggregateStoresMetrics() error {
 	if !throttler.isLeader {
 		return nil
 	}
-	log.Debugf("refreshing MySQL inventory")
-
-	addInstanceKey := func(key *mysql.InstanceKey, clusterName string, clusterSettings *config.MySQLClusterConfigurationSettings, probes *mysql.Probes) {
-		for _, ignore := range clusterSettings.IgnoreHosts {
-			if strings.Contains(key.StringCode(), ignore) {
-				log.Debugf("instance key ignored: %+v", key)
-				return
-			}
+	for storeType, store := range throttler.stores {
+		for storeName, aggregatedMetric := range store.Aggregate() {
+			metricName := fmt.Sprintf("%s/%s", storeType, storeName)
+			throttler.setAggregatedMetric(metricName, aggregatedMetric)
 		}
-		if !key.IsValid() {
-			log.Debugf("read invalid instance key: [%+v] for cluster %+v", key, clusterName)
-			return
-		}
-		log.Debugf("read instance key: %+v", key)
-
-		probe := &mysql.Probe{
-			Key:           *key,
-			User:          clusterSettings.User,
-			Password:      clusterSettings.Password,
-			MetricQuery:   clusterSettings.MetricQuery,
-			CacheMillis:   clusterSettings.CacheMillis,
-			HttpCheckPath: clusterSettings.HttpCheckPath,
-			HttpCheckPort: clusterSettings.HttpCheckPort,
-		}
-		(*probes)[*key] = probe
-	}
-
-	for clusterName, clusterSettings := range config.Settings().Stores.MySQL.Clusters {
-		clusterName := clusterName
-		clusterSettings := clusterSettings
-		// config may dynamically change, but internal structure (config.Settings().Stores.MySQL.Clusters in our case)
-		// is immutable and can only be _replaced_. Hence, it's safe to read in a goroutine:
-		go func() error {
-			throttler.mysqlClusterThresholds.Set(clusterName, clusterSettings.ThrottleThreshold, cache.DefaultExpiration)
-			if !clusterSettings.HAProxySettings.IsEmpty() {
-				poolName := clusterSettings.HAProxySettings.PoolName
-				totalHosts := []string{}
-				addresses, _ := clusterSettings.HAProxySettings.GetProxyAddresses()
-				for _, u := range addresses {
-					log.Debugf("getting haproxy data from %s", u.String())
-					csv, err := haproxy.Read(u)
-					if err != nil {
-						return log.Errorf("Unable to get HAproxy data from %s: %+v", u.String(), err)
-					}
-					if backendHosts, err := haproxy.ParseCsvHosts(csv, poolName); err == nil {
-						hosts := haproxy.FilterThrotllerHosts(backendHosts)
-						totalHosts = append(totalHosts, hosts...)
-						log.Debugf("Read %+v hosts from haproxy %s/#%s", len(hosts), u.String(), poolName)
-					} else {
-						log.Errorf("Unable to get HAproxy hosts from %s/#%s: %+v", u.String(), poolName, err)
-					}
-				}
-				if len(totalHosts) == 0 {
-					return log.Errorf("Unable to get any HAproxy hosts for pool: %+v", poolName)
-				}
-
-				clusterProbes := &mysql.ClusterProbes{
-					ClusterName:          clusterName,
-					IgnoreHostsCount:     clusterSettings.IgnoreHostsCount,
-					IgnoreHostsThreshold: clusterSettings.IgnoreHostsThreshold,
-					InstanceProbes:       mysql.NewProbes(),
-				}
-				for _, host := range totalHosts {
-					key := mysql.InstanceKey{Hostname: host, Port: clusterSettings.Port}
-					addInstanceKey(&key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
-				}
-				throttler.mysqlClusterProbesChan <- clusterProbes
-				return nil
-			}
-
-			if !clusterSettings.VitessSettings.IsEmpty() {
-				log.Debugf("getting vitess data from %s", clusterSettings.VitessSettings.API)
-				keyspace := clusterSettings.VitessSettings.Keyspace
-				shard := clusterSettings.VitessSettings.Shard
-				tablets, err := vitess.ParseTablets(clusterSettings.VitessSettings)
-				if err != nil {
-					return log.Errorf("Unable to get vitess hosts from %s, %s/%s: %+v", clusterSettings.VitessSettings.API, keyspace, shard, err)
-				}
-				log.Debugf("Read %+v hosts from vitess %s, %s/%s, cells=%s", len(tablets), clusterSettings.VitessSettings.API,
-					keyspace, shard, strings.Join(vitess.ParseCells(clusterSettings.VitessSettings), ","),
-				)
-				clusterProbes := &mysql.ClusterProbes{
-					ClusterName:      clusterName,
-					IgnoreHostsCount: clusterSettings.IgnoreHostsCount,
-					InstanceProbes:   mysql.NewProbes(),
-				}
-				for _, tablet := range tablets {
-					key := mysql.InstanceKey{Hostname: tablet.MysqlHostname, Port: int(tablet.MysqlPort)}
-					addInstanceKey(&key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
-				}
-				throttler.mysqlClusterProbesChan <- clusterProbes
-				return nil
-			}
-
-			if !clusterSettings.StaticHostsSettings.IsEmpty() {
-				clusterProbes := &mysql.ClusterProbes{
-					ClusterName:    clusterName,
-					InstanceProbes: mysql.NewProbes(),
-				}
-				for _, host := range clusterSettings.StaticHostsSettings.Hosts {
-					key, err := mysql.ParseInstanceKey(host, clusterSettings.Port)
-					if err != nil {
-						return log.Errore(err)
-					}
-					addInstanceKey(key, clusterName, clusterSettings, clusterProbes.InstanceProbes)
-				}
-				throttler.mysqlClusterProbesChan <- clusterProbes
-				return nil
-			}
-			return log.Errorf("Could not find any hosts definition for cluster %s", clusterName)
-		}()
 	}
 	return nil
 }
 
-// synchronous update of inventory
-func (throttler *Throttler) updateMySQLClusterProbes(clusterProbes *mysql.ClusterProbes) error {
-	log.Debugf("onMySQLClusterProbes: %s", clusterProbes.ClusterName)
-	throttler.mysqlInventory.ClustersProbes[clusterProbes.ClusterName] = clusterProbes.InstanceProbes
-	throttler.mysqlInventory.IgnoreHostsCount[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsCount
-	throttler.mysqlInventory.IgnoreHostsThreshold[clusterProbes.ClusterName] = clusterProbes.IgnoreHostsThreshold
-	return nil
-}
-
-// synchronous aggregation of collected data
-func (throttler *Throttler) aggregateMySQLMetrics() error {
-	if !throttler.isLeader {
-		return nil
-	}
-	for clusterName, probes := range throttler.mysqlInventory.ClustersProbes {
-		metricName := fmt.Sprintf("mysql/%s", clusterName)
-		ignoreHostsCount := throttler.mysqlInventory.IgnoreHostsCount[clusterName]
-		ignoreHostsThreshold := throttler.mysqlInventory.IgnoreHostsThreshold[clusterName]
-		aggregatedMetric := aggregateMySQLProbes(probes, clusterName, throttler.mysqlInventory.InstanceKeyMetrics, throttler.mysqlInventory.ClusterInstanceHttpChecks, ignoreHostsCount, config.Settings().Stores.MySQL.IgnoreDialTcpErrors, ignoreHostsThreshold)
-		go throttler.aggregatedMetrics.Set(metricName, aggregatedMetric, cache.DefaultExpiration)
-		if throttler.memcacheClient != nil {
-			go func() {
-				memcacheKey := fmt.Sprintf("%s/%s", throttler.memcachePath, metricName)
-				value, err := aggregatedMetric.Get()
-				if err != nil {
-					throttler.memcacheClient.Delete(memcacheKey)
-				} else {
-					epochMillis := time.Now().UnixNano() / 1000000
-					entryVal := fmt.Sprintf("%d:%.6f", epochMillis, value)
-					throttler.memcacheClient.Set(&memcache.Item{Key: memcacheKey, Value: []byte(entryVal), Expiration: 1})
-				}
-			}()
-		}
+// setAggregatedMetric stores a freshly aggregated metric, and publishes it to memcache if so configured
+func (throttler *Throttler) setAggregatedMetric(metricName string, aggregatedMetric base.MetricResult) {
+	go throttler.aggregatedMetrics.Set(metricName, aggregatedMetric, cache.DefaultExpiration)
+	if throttler.memcacheClient != nil {
+		go func() {
+			memcacheKey := fmt.Sprintf("%s/%s", throttler.memcachePath, metricName)
+			value, err := aggregatedMetric.Get()
+			if err != nil {
+				throttler.memcacheClient.Delete(memcacheKey)
+			} else {
+				epochMillis := time.Now().UnixNano() / 1000000
+				entryVal := fmt.Sprintf("%d:%.6f", epochMillis, value)
+				throttler.memcacheClient.Set(&memcache.Item{Key: memcacheKey, Value: []byte(entryVal), Expiration: 1})
+			}
+		}()
 	}
-	return nil
 }
 
 func (throttler *Throttler) pushStatusToExpVar() {
@@ -420,16 +244,42 @@ func (throttler *Throttler) getNamedMetric(metricName string) base.MetricResult
 	return base.NoSuchMetric
 }
 
-func (throttler *Throttler) getMySQLClusterMetrics(clusterName string) (base.MetricResult, float64) {
-	if thresholdVal, found := throttler.mysqlClusterThresholds.Get(clusterName); found {
-		threshold, _ := thresholdVal.(float64)
-		metricName := fmt.Sprintf("mysql/%s", clusterName)
+// hasStoreType returns true when given store type is known to this throttler
+func (throttler *Throttler) hasStoreType(storeType string) bool {
+	_, found := throttler.stores[storeType]
+	return found
+}
+
+// getStoreMetrics returns the aggregated metric and threshold for given store
+func (throttler *Throttler) getStoreMetrics(storeType string, storeName string) (base.MetricResult, float64) {
+	store, found := throttler.stores[storeType]
+	if !found {
+		return base.NoSuchMetric, 0
+	}
+	if threshold, found := store.Threshold(storeName); found {
+		metricName := fmt.Sprintf("%s/%s", storeType, storeName)
 		return throttler.getNamedMetric(metricName), threshold
 	}
 
 	return base.NoSuchMetric, 0
 }
 
+// getStoreHostMetrics returns the per-host breakdown of a store's metric
+func (throttler *Throttler) getStoreHostMetrics(storeType string, storeName string) (hostMetrics []*HostMetric, err error) {
+	store, found := throttler.stores[storeType]
+	if !found {
+		return hostMetrics, base.NoSuchMetricError
+	}
+	hostsReporter, ok := store.(HostsReporter)
+	if !ok {
+		return hostMetrics, fmt.Errorf("Store type %s does not report hosts", storeType)
+	}
+	if hostMetrics, found = hostsReporter.HostMetrics(storeName); !found {
+		return hostMetrics, base.NoSuchMetricError
+	}
+	return hostMetrics, nil
+}
+
 func (throttler *Throttler) aggregatedMetricsSnapshot() map[string]base.MetricResult {
 	snapshot := make(map[string]base.MetricResult)
 	for key, value := range throttler.aggregatedMetrics.Items() {
@@ -450,13 +300,28 @@ func (throttler *Throttler) expireThrottledApps() {
 }
 
 func (throttler *Throttler) ThrottleApp(appName string, expireAt time.Time, ratio float64) {
+	throttler.ThrottleAppWithMetadata(appName, expireAt, ratio, nil)
+}
+
+// ThrottleAppWithMetadata throttles an app just as ThrottleApp does, and sets the throttle's metadata.
+// A nil metadata keeps an existing throttle's metadata as is.
+func (throttler *Throttler) ThrottleAppWithMetadata(appName string, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata) {
+	throttler.ThrottleAppOnStore(appName, base.AppThrottleStore{}, expireAt, ratio, metadata)
+}
+
+// ThrottleAppOnStore throttles an app just as ThrottleAppWithMetadata does, on the stores of given scope only.
+// The throttle is kept apart from the app's throttles of other scopes.
+func (throttler *Throttler) ThrottleAppOnStore(appName string, store base.AppThrottleStore, expireAt time.Time, ratio float64, metadata *base.AppThrottleMetadata) {
 	throttler.throttledAppsMutex.Lock()
 	defer throttler.throttledAppsMutex.Unlock()
 
+	key := base.AppThrottleKey(appName, store)
 	var appThrottle *base.AppThrottle
 	now := time.Now()
-	if object, found := throttler.throttledApps.Get(appName); found {
-		appThrottle = object.(*base.AppThrottle)
+	if object, found := throttler.throttledApps.Get(key); found {
+		// copy on write: readers may hold the cached throttle, as listed by ThrottledAppsMap, without the lock
+		updated := *object.(*base.AppThrottle)
+		appThrottle = &updated
 		if !expireAt.IsZero() {
 			appThrottle.ExpireAt = expireAt
 		}
@@ -471,31 +336,111 @@ func (throttler *Throttler) ThrottleApp(appName string, expireAt time.Time, rati
 			ratio = DefaultThrottleRatio
 		}
 		appThrottle = base.NewAppThrottle(expireAt, ratio)
+		appThrottle.AppThrottleStore = store
+	}
+	if metadata != nil {
+		appThrottle.AppThrottleMetadata = *metadata
 	}
 	if now.Before(appThrottle.ExpireAt) {
-		throttler.throttledApps.Set(appName, appThrottle, cache.DefaultExpiration)
+		throttler.throttledApps.Set(key, appThrottle, cache.DefaultExpiration)
+		if base.IsAppRule(appName) {
+			throttler.refreshAppRules()
+		}
 	} else {
-		throttler.UnthrottleApp(appName)
+		throttler.unthrottleAppOnStore(appName, store)
+	}
+}
+
+// ReplaceThrottledApps replaces all app throttles with given ones, keyed as listed by ThrottledAppsMap, as when
+// restoring state. Expired throttles are skipped.
+func (throttler *Throttler) ReplaceThrottledApps(throttledApps map[string]base.AppThrottle) {
+	throttler.throttledAppsMutex.Lock()
+	defer throttler.throttledAppsMutex.Unlock()
+
+	now := time.Now()
+	throttler.throttledApps.Flush()
+	for key, appThrottle := range throttledApps {
+		appThrottle := appThrottle
+		if now.Before(appThrottle.ExpireAt) {
+			throttler.throttledApps.Set(key, &appThrottle, cache.DefaultExpiration)
+		}
 	}
+	throttler.refreshAppRules()
 }
 
+// UnthrottleApp removes the app's unscoped throttle. Given a throttle's key, as listed by ThrottledAppsMap,
+// it removes that throttle.
 func (throttler *Throttler) UnthrottleApp(appName string) {
-	throttler.throttledApps.Delete(appName)
+	throttler.UnthrottleAppOnStore(appName, base.AppThrottleStore{})
 }
 
-func (throttler *Throttler) IsAppThrottled(appName string) bool {
-	if object, found := throttler.throttledApps.Get(appName); found {
+// UnthrottleAppOnStore removes the app's throttle of given scope, keeping its throttles of other scopes
+func (throttler *Throttler) UnthrottleAppOnStore(appName string, store base.AppThrottleStore) {
+	throttler.throttledAppsMutex.Lock()
+	defer throttler.throttledAppsMutex.Unlock()
+
+	throttler.unthrottleAppOnStore(appName, store)
+}
+
+// unthrottleAppOnStore removes the app's throttle of given scope. The caller holds throttledAppsMutex.
+func (throttler *Throttler) unthrottleAppOnStore(appName string, store base.AppThrottleStore) {
+	throttler.throttledApps.Delete(base.AppThrottleKey(appName, store))
+	if base.IsAppRule(appName) {
+		throttler.refreshAppRules()
+	}
+}
+
+// refreshAppRules parses the rules among throttled apps
+func (throttler *Throttler) refreshAppRules() {
+	appNames := []string{}
+	for key, item := range throttler.throttledApps.Items() {
+		appThrottle := item.Object.(*base.AppThrottle)
+		appNames = append(appNames, base.AppThrottleKeyApp(key, appThrottle.AppThrottleStore))
+	}
+	appRules := base.NewAppRules(appNames)
+
+	throttler.appRulesMutex.Lock()
+	defer throttler.appRulesMutex.Unlock()
+	throttler.appRules = appRules
+}
+
+// activeAppThrottle returns the unexpired throttle of given key, if any
+func (throttler *Throttler) activeAppThrottle(key string) *base.AppThrottle {
+	if object, found := throttler.throttledApps.Get(key); found {
 		appThrottle := object.(*base.AppThrottle)
-		if appThrottle.ExpireAt.Before(time.Now()) {
-			// throttling cleanup hasn't purged yet, but it is expired
-			return false
+		// throttling cleanup may not have purged it yet
+		if appThrottle.ExpireAt.After(time.Now()) {
+			return appThrottle
 		}
+	}
+	return nil
+}
+
+// ResolveAppThrottle returns the throttle in effect for given app on given store: its own, or else that of the
+// most specific rule matching it, in which case ruleName names the rule
+func (throttler *Throttler) ResolveAppThrottle(appName string, store base.AppThrottleStore) (appThrottle *base.AppThrottle, ruleName string) {
+	throttler.appRulesMutex.RLock()
+	appRules := throttler.appRules
+	throttler.appRulesMutex.RUnlock()
+
+	return base.ResolveAppThrottle(appName, store, appRules, throttler.activeAppThrottle)
+}
+
+// IsAppThrottled checks the app's unscoped throttle; store scoped throttles only apply to checks on their stores
+func (throttler *Throttler) IsAppThrottled(appName string) bool {
+	_, _, _, throttled := throttler.rollAppThrottle(appName, base.AppThrottleStore{})
+	return throttled
+}
+
+// rollAppThrottle returns the throttle in effect for the app on given store, if any, the rule it comes from,
+// and the ratio roll deciding whether the current request is throttled
+func (throttler *Throttler) rollAppThrottle(appName string, store base.AppThrottleStore) (appThrottle *base.AppThrottle, ruleName string, roll float64, throttled bool) {
+	if appThrottle, ruleName = throttler.ResolveAppThrottle(appName, store); appThrottle != nil {
 		// handle ratio
-		if rand.Float64() < appThrottle.Ratio {
-			return true
-		}
+		roll = rand.Float64()
+		return appThrottle, ruleName, roll, roll < appThrottle.Ratio
 	}
-	return false
+	return nil, "", 0, false
 }
 
 func (throttler *Throttler) ThrottledAppsMap() (result map[string](*base.AppThrottle)) {
@@ -508,6 +453,100 @@ func (throttler *Throttler) ThrottledAppsMap() (result map[string](*base.AppThro
 	return result
 }
 
+// SetAppThreshold overrides the threshold of given store for an app, or for the apps an app rule matches.
+// It beats a configured threshold of the same app and store.
+func (throttler *Throttler) SetAppThreshold(appName string, store base.AppThrottleStore, threshold float64) {
+	throttler.appThresholdsMutex.Lock()
+	defer throttler.appThresholdsMutex.Unlock()
+
+	appThreshold := &base.AppThreshold{App: appName, Threshold: threshold, AppThrottleStore: store}
+	throttler.appThresholds[appThreshold.Key()] = appThreshold
+	throttler.refreshAppThresholdRules()
+}
+
+// RemoveAppThreshold removes an app threshold set at runtime. A configured threshold of the same app and store applies again.
+func (throttler *Throttler) RemoveAppThreshold(appName string, store base.AppThrottleStore) {
+	throttler.appThresholdsMutex.Lock()
+	defer throttler.appThresholdsMutex.Unlock()
+
+	delete(throttler.appThresholds, base.AppThrottleKey(appName, store))
+	throttler.refreshAppThresholdRules()
+}
+
+// ReplaceAppThresholds replaces all app thresholds set at runtime, as when restoring state
+func (throttler *Throttler) ReplaceAppThresholds(appThresholds []base.AppThreshold) {
+	throttler.appThresholdsMutex.Lock()
+	defer throttler.appThresholdsMutex.Unlock()
+
+	throttler.appThresholds = make(map[string]*base.AppThreshold)
+	for _, appThreshold := range appThresholds {
+		appThreshold := appThreshold
+		appThreshold.Configured = false
+		throttler.appThresholds[appThreshold.Key()] = &appThreshold
+	}
+	throttler.refreshAppThresholdRules()
+}
+
+// refreshConfiguredAppThresholds re-reads the AppThresholds configuration
+func (throttler *Throttler) refreshConfiguredAppThresholds() {
+	appThresholds, err := config.Settings().ConfiguredAppThresholds()
+	if err != nil {
+		log.Errorf("error reading app thresholds: %+v", err)
+		return
+	}
+	configuredAppThresholds := make(map[string]*base.AppThreshold)
+	for _, appThreshold := range appThresholds {
+		appThreshold := appThreshold
+		configuredAppThresholds[appThreshold.Key()] = &appThreshold
+	}
+
+	throttler.appThresholdsMutex.Lock()
+	defer throttler.appThresholdsMutex.Unlock()
+	throttler.configuredAppThresholds = configuredAppThresholds
+	throttler.refreshAppThresholdRules()
+}
+
+// refreshAppThresholdRules parses the rules among app thresholds. Expects appThresholdsMutex to be locked.
+func (throttler *Throttler) refreshAppThresholdRules() {
+	appNames := []string{}
+	for _, appThresholds := range []map[string]*base.AppThreshold{throttler.appThresholds, throttler.configuredAppThresholds} {
+		for _, appThreshold := range appThresholds {
+			appNames = append(appNames, appThreshold.App)
+		}
+	}
+	throttler.appThresholdRules = base.NewAppRules(appNames)
+}
+
+// AppThresholds lists the app thresholds set at runtime along with the configured ones, sorted by store then app.
+// A configured threshold is listed even when a runtime threshold of the same app and store overrides it.
+func (throttler *Throttler) AppThresholds() (result []base.AppThreshold) {
+	throttler.appThresholdsMutex.RLock()
+	defer throttler.appThresholdsMutex.RUnlock()
+
+	result = []base.AppThreshold{}
+	for _, appThresholds := range []map[string]*base.AppThreshold{throttler.appThresholds, throttler.configuredAppThresholds} {
+		for _, appThreshold := range appThresholds {
+			result = append(result, *appThreshold)
+		}
+	}
+	base.SortAppThresholds(result)
+	return result
+}
+
+// ResolveAppThreshold returns the threshold override in effect for given app on given store, if any: its own, or
+// else that of the most specific rule matching it. A runtime threshold beats a configured one.
+func (throttler *Throttler) ResolveAppThreshold(appName string, store base.AppThrottleStore) *base.AppThreshold {
+	throttler.appThresholdsMutex.RLock()
+	defer throttler.appThresholdsMutex.RUnlock()
+
+	return base.ResolveAppThreshold(appName, store, throttler.appThresholdRules, func(key string) *base.AppThreshold {
+		if appThreshold, found := throttler.appThresholds[key]; found {
+			return appThreshold
+		}
+		return throttler.configuredAppThresholds[key]
+	})
+}
+
 func (throttler *Throttler) markRecentApp(appName string, remoteAddr string) {
 	recentAppKey := fmt.Sprintf("%s/%s", appName, remoteAddr)
 	throttler.recentApps.Set(recentAppKey, time.Now(), cache.DefaultExpiration)
@@ -545,14 +584,29 @@ func (throttler *Throttler) metricsHealthSnapshot() base.MetricHealthMap {
 	return snapshot
 }
 
-func (throttler *Throttler) AppRequestMetricResult(appName string, metricResultFunc base.MetricResultFunc, denyApp bool) (metricResult base.MetricResult, threshold float64) {
+func (throttler *Throttler) AppRequestMetricResult(appName string, storeType string, storeName string, metricResultFunc base.MetricResultFunc, denyApp bool) (metricResult base.MetricResult, threshold float64) {
+	return throttler.appRequestMetricResult(appName, storeType, storeName, metricResultFunc, denyApp, nil)
+}
+
+func (throttler *Throttler) appRequestMetricResult(appName string, storeType string, storeName string, metricResultFunc base.MetricResultFunc, denyApp bool, explanation *CheckExplanation) (metricResult base.MetricResult, threshold float64) {
 	if denyApp {
 		return base.AppDeniedMetric, 0
 	}
-	if throttler.IsAppThrottled(appName) {
+	appThrottle, ruleName, roll, throttled := throttler.rollAppThrottle(appName, base.AppThrottleStore{StoreType: storeType, StoreName: storeName})
+	explanation.explainAppThrottle(appThrottle, ruleName, roll, throttled)
+	if throttled {
 		return base.AppDeniedMetric, 0
 	}
-	return metricResultFunc()
+	metricResult, threshold = metricResultFunc()
+	if appName == frenoAppName || appName == frenoShareDmainAppName {
+		// freno's own checks determine metric health, which is per store rather than per app
+		return metricResult, threshold
+	}
+	if appThreshold := throttler.ResolveAppThreshold(appName, base.AppThrottleStore{StoreType: storeType, StoreName: storeName}); appThreshold != nil {
+		explanation.explainAppThreshold(appThreshold, threshold)
+		threshold = appThreshold.Threshold
+	}
+	return metricResult, threshold
 }
 
 func (throttler *Throttler) collectShareDomainMetricHealth() error {
@@ -604,3 +658,19 @@ func (throttler *Throttler) getShareDomainSecondsSinceHealth(metricName string)
 	}
 	return 0
 }
+
+// explainShareDomain reports the shared domain services, and the shared domain health of a given metric
+func (throttler *Throttler) explainShareDomain(metricName string) *SharedDomainExplanation {
+	explanation := &SharedDomainExplanation{}
+	if throttler.sharedDomainServicesFunc != nil {
+		services, err := throttler.sharedDomainServicesFunc()
+		explanation.Services = services
+		if err != nil {
+			explanation.ServicesErr = err.Error()
+		}
+	}
+	if object, found := throttler.shareDomainMetricHealth.Get(metricName); found {
+		explanation.MetricHealth = object.(*base.MetricHealth)
+	}
+	return explanation
+}
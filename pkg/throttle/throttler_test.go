@@ -0,0 +1,79 @@
+package throttle
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestThrottleAppCopyOnWrite(t *testing.T) {
+	throttler := NewThrottler()
+	expireAt := time.Now().Add(time.Hour)
+	throttler.ThrottleAppOnStore("archiver", base.AppThrottleStore{}, expireAt, 0.5, &base.AppThrottleMetadata{Reason: "backfill"})
+
+	listed := throttler.ThrottledAppsMap()["archiver"]
+	throttler.ThrottleAppOnStore("archiver", base.AppThrottleStore{}, expireAt.Add(time.Hour), 0.9, &base.AppThrottleMetadata{Reason: "migration"})
+
+	// a throttle listed before the update is left as it was
+	test.S(t).ExpectTrue(listed.ExpireAt.Equal(expireAt))
+	test.S(t).ExpectEquals(listed.Ratio, 0.5)
+	test.S(t).ExpectEquals(listed.Reason, "backfill")
+
+	updated := throttler.ThrottledAppsMap()["archiver"]
+	test.S(t).ExpectTrue(updated.ExpireAt.Equal(expireAt.Add(time.Hour)))
+	test.S(t).ExpectEquals(updated.Ratio, 0.9)
+	test.S(t).ExpectEquals(updated.Reason, "migration")
+}
+
+func TestThrottleUnthrottleAppRulesConcurrently(t *testing.T) {
+	throttler := NewThrottler()
+	ruleNames := []string{"prefix:archiver", "glob:gh-ost:*", "regex:^migration-[0-9]+$"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, ruleName := range ruleNames {
+			wg.Add(2)
+			go func(ruleName string) {
+				defer wg.Done()
+				throttler.ThrottleApp(ruleName, time.Now().Add(time.Hour), 1)
+			}(ruleName)
+			go func(ruleName string) {
+				defer wg.Done()
+				throttler.UnthrottleApp(ruleName)
+			}(ruleName)
+		}
+	}
+	wg.Wait()
+
+	// rules in effect are those of the remaining throttles
+	throttled := []string{}
+	for appName := range throttler.ThrottledAppsMap() {
+		if base.IsAppRule(appName) {
+			throttled = append(throttled, appName)
+		}
+	}
+	ruled := []string{}
+	for _, rule := range throttler.appRules {
+		ruled = append(ruled, rule.Name)
+	}
+	sort.Strings(throttled)
+	sort.Strings(ruled)
+	test.S(t).ExpectEquals(len(ruled), len(throttled))
+	for i := range throttled {
+		test.S(t).ExpectEquals(ruled[i], throttled[i])
+	}
+
+	// an expired throttle unthrottles the rule
+	throttler.ThrottleApp("prefix:archiver", time.Now().Add(time.Hour), 1)
+	throttler.ThrottleApp("prefix:archiver", time.Now().Add(-time.Minute), 1)
+	_, found := throttler.ThrottledAppsMap()["prefix:archiver"]
+	test.S(t).ExpectFalse(found)
+	for _, rule := range throttler.appRules {
+		test.S(t).ExpectNotEquals(rule.Name, "prefix:archiver")
+	}
+}
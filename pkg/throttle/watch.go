@@ -0,0 +1,57 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+)
+
+// tickNotifier lets any number of waiters await the next occurrence of a recurring event
+type tickNotifier struct {
+	mutex sync.Mutex
+	tick  chan struct{}
+}
+
+func newTickNotifier() *tickNotifier {
+	return &tickNotifier{tick: make(chan struct{})}
+}
+
+// next returns a channel which is closed upon the next tick
+func (notifier *tickNotifier) next() <-chan struct{} {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	return notifier.tick
+}
+
+// notify wakes up all current waiters
+func (notifier *tickNotifier) notify() {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	close(notifier.tick)
+	notifier.tick = make(chan struct{})
+}
+
+// WatchCheck checks once, and then re-checks upon each aggregation of metrics. It calls `onCheck` with the
+// first result, and then whenever the status code changes. It returns once `ctx` is done, or as soon as `onCheck`
+// returns false. Only the first check counts as a check made by the app, in terms of metrics and recent apps.
+func (check *ThrottlerCheck) WatchCheck(ctx context.Context, appName string, storeType string, storeName string, remoteAddr string, flags *CheckFlags, onCheck func(checkResult *CheckResult) bool) {
+	checkResult := check.Check(appName, storeType, storeName, remoteAddr, flags)
+	if !onCheck(checkResult) {
+		return
+	}
+	lastStatusCode := checkResult.StatusCode
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-check.throttler.aggregated.next():
+		}
+		checkResult = check.recheck(appName, storeType, storeName, flags)
+		if checkResult.StatusCode == lastStatusCode {
+			continue
+		}
+		lastStatusCode = checkResult.StatusCode
+		if !onCheck(checkResult) {
+			return
+		}
+	}
+}
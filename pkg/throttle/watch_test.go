@@ -0,0 +1,56 @@
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github/freno/pkg/base"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+func TestWatchCheck(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statusCodes := []int{}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+				check.throttler.aggregated.notify()
+			}
+		}
+	}()
+	check.WatchCheck(ctx, "app", "fake", "c1", "local", &CheckFlags{}, func(checkResult *CheckResult) bool {
+		statusCodes = append(statusCodes, checkResult.StatusCode)
+		if checkResult.StatusCode == http.StatusTooManyRequests {
+			// lag goes away
+			check.throttler.aggregatedMetrics.SetDefault("fake/c1", base.NewSimpleMetricResult(0.5))
+		}
+		return checkResult.StatusCode != http.StatusOK
+	})
+	test.S(t).ExpectNil(ctx.Err())
+	test.S(t).ExpectEquals(len(statusCodes), 2)
+	test.S(t).ExpectEquals(statusCodes[0], http.StatusTooManyRequests)
+	test.S(t).ExpectEquals(statusCodes[1], http.StatusOK)
+}
+
+func TestWatchCheckContextDone(t *testing.T) {
+	check := newExplainTestThrottlerCheck()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	numChecks := 0
+	check.WatchCheck(ctx, "app", "fake", "c1", "local", &CheckFlags{}, func(checkResult *CheckResult) bool {
+		numChecks++
+		return true
+	})
+	test.S(t).ExpectNotNil(ctx.Err())
+	test.S(t).ExpectEquals(numChecks, 1)
+}
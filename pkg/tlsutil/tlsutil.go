@@ -0,0 +1,286 @@
+// Package tlsutil provides TLS configuration based on PEM files, which are reloaded as they change,
+// and verification of peers against a CA and an allowed list of identities.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/outbrain/golib/log"
+)
+
+// reloadCheckInterval is the minimal interval between checks for changed files
+var reloadCheckInterval = 5 * time.Second
+
+// Files locates PEM encoded TLS material
+type Files struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// Reloader serves a certificate and a CA pool read from files, re-reading them when they change.
+// Should re-reading fail (e.g. while files are being replaced), the last good material is kept.
+type Reloader struct {
+	files Files
+
+	mutex     sync.RWMutex
+	cert      *tls.Certificate
+	caPool    *x509.CertPool
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+// NewReloader reads given files, and returns an error if they cannot be loaded
+func NewReloader(files Files) (*Reloader, error) {
+	reloader := &Reloader{files: files}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (reloader *Reloader) paths() []string {
+	return []string{reloader.files.CAFile, reloader.files.CertFile, reloader.files.KeyFile}
+}
+
+func modTimes(paths []string) (map[string]time.Time, error) {
+	result := make(map[string]time.Time)
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return result, err
+		}
+		result[path] = info.ModTime()
+	}
+	return result, nil
+}
+
+// load reads the certificate, key and CA files
+func (reloader *Reloader) load() error {
+	times, err := modTimes(reloader.paths())
+	if err != nil {
+		return err
+	}
+	var cert *tls.Certificate
+	if reloader.files.CertFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(reloader.files.CertFile, reloader.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("cannot load certificate %s: %+v", reloader.files.CertFile, err)
+		}
+		cert = &keyPair
+	}
+	var caPool *x509.CertPool
+	if reloader.files.CAFile != "" {
+		pem, err := ioutil.ReadFile(reloader.files.CAFile)
+		if err != nil {
+			return err
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", reloader.files.CAFile)
+		}
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.cert = cert
+	reloader.caPool = caPool
+	reloader.modTimes = times
+	reloader.lastCheck = time.Now()
+	return nil
+}
+
+// maybeReload re-reads the files if any of them changed since last loaded
+func (reloader *Reloader) maybeReload() {
+	reloader.mutex.Lock()
+	if time.Since(reloader.lastCheck) < reloadCheckInterval {
+		reloader.mutex.Unlock()
+		return
+	}
+	reloader.lastCheck = time.Now()
+	loadedTimes := reloader.modTimes
+	reloader.mutex.Unlock()
+
+	times, err := modTimes(reloader.paths())
+	if err != nil {
+		return
+	}
+	changed := false
+	for path, modTime := range times {
+		if !modTime.Equal(loadedTimes[path]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := reloader.load(); err != nil {
+		log.Errorf("tls: keeping previous certificates; failed reloading: %+v", err)
+		return
+	}
+	log.Infof("tls: reloaded %s", reloader.files.CertFile)
+}
+
+// Certificate returns the current certificate
+func (reloader *Reloader) Certificate() *tls.Certificate {
+	reloader.maybeReload()
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return reloader.cert
+}
+
+// CAPool returns the current CA pool
+func (reloader *Reloader) CAPool() *x509.CertPool {
+	reloader.maybeReload()
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return reloader.caPool
+}
+
+// GetCertificate serves as tls.Config.GetCertificate
+func (reloader *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := reloader.Certificate(); cert != nil {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("no certificate configured")
+}
+
+// GetClientCertificate serves as tls.Config.GetClientCertificate
+func (reloader *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := reloader.Certificate(); cert != nil {
+		return cert, nil
+	}
+	// no certificate; the server will decide whether that's acceptable
+	return &tls.Certificate{}, nil
+}
+
+// Identities returns the identities a certificate presents: its common name and its DNS, IP and URI
+// subject alternative names
+func Identities(cert *x509.Certificate) (identities []string) {
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		identities = append(identities, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+// HasIdentity tells whether a certificate presents any of the given identities. An empty list allows any identity.
+func HasIdentity(cert *x509.Certificate, allowedIdentities []string) bool {
+	if len(allowedIdentities) == 0 {
+		return true
+	}
+	for _, identity := range Identities(cert) {
+		for _, allowed := range allowedIdentities {
+			if identity == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VerifyPeer verifies a peer's certificate chain against the current CA pool, and the peer's identity
+// against the allowed identities. It serves as tls.Config.VerifyPeerCertificate.
+func (reloader *Reloader) VerifyPeer(allowedIdentities []string) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("tls: peer presented no certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return fmt.Errorf("tls: cannot parse peer certificate: %+v", err)
+			}
+			certs = append(certs, cert)
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		// Peers act as both servers and clients, hence any key usage
+		opts := x509.VerifyOptions{
+			Roots:         reloader.CAPool(),
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return fmt.Errorf("tls: cannot verify peer certificate: %+v", err)
+		}
+		if !HasIdentity(certs[0], allowedIdentities) {
+			return fmt.Errorf("tls: peer identities %+v not allowed", Identities(certs[0]))
+		}
+		return nil
+	}
+}
+
+// MutualConfig returns a TLS configuration for both accepting and dialing connections, where both sides
+// authenticate each other: the peer's certificate must be signed by the CA and present an allowed identity.
+func MutualConfig(reloader *Reloader, allowedIdentities []string) *tls.Config {
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetCertificate:       reloader.GetCertificate,
+		GetClientCertificate: reloader.GetClientCertificate,
+		ClientAuth:           tls.RequireAnyClientCert,
+		// Verification is done by VerifyPeerCertificate, against the reloadable CA and the allowed identities,
+		// rather than by host name: raft peers are typically addressed by IP.
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: reloader.VerifyPeer(allowedIdentities),
+	}
+}
+
+// ServerConfig returns a TLS configuration for serving. When the reloader has a CA, clients may present a certificate
+// signed by this CA, and must do so if `requireClientCert` is set. Verified client certificates are then found in
+// the connection state's VerifiedChains.
+func ServerConfig(reloader *Reloader, requireClientCert bool) *tls.Config {
+	serverConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if reloader.CAPool() == nil {
+		return serverConfig
+	}
+	clientAuth := tls.VerifyClientCertIfGiven
+	if requireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	serverConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		// a per-connection config, so as to pick up a reloaded CA
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+			ClientCAs:      reloader.CAPool(),
+			ClientAuth:     clientAuth,
+		}, nil
+	}
+	return serverConfig
+}
+
+// ClientConfig returns a TLS configuration for dialing, presenting the reloader's certificate, if any. When the reloader
+// has a CA, the server's certificate is verified against this CA rather than by host name.
+func ClientConfig(reloader *Reloader) *tls.Config {
+	clientConfig := &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: reloader.GetClientCertificate,
+	}
+	if reloader.CAPool() != nil {
+		clientConfig.InsecureSkipVerify = true
+		clientConfig.VerifyPeerCertificate = reloader.VerifyPeer(nil)
+	}
+	return clientConfig
+}
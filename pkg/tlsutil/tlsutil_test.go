@@ -0,0 +1,186 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	test "github.com/outbrain/golib/tests"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.S(t).ExpectNil(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	test.S(t).ExpectNil(err)
+	cert, err := x509.ParseCertificate(der)
+	test.S(t).ExpectNil(err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key for given common name, signed by the CA
+func (ca *testCA) issue(t *testing.T, commonName string, serial int64) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.S(t).ExpectNil(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	test.S(t).ExpectNil(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	test.S(t).ExpectNil(err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFiles writes CA, certificate and key into dir
+func writeFiles(t *testing.T, dir string, ca *testCA, commonName string, serial int64) Files {
+	files := Files{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, commonName+".pem"),
+		KeyFile:  filepath.Join(dir, commonName+"-key.pem"),
+	}
+	certPEM, keyPEM := ca.issue(t, commonName, serial)
+	test.S(t).ExpectNil(ioutil.WriteFile(files.CAFile, ca.pem, 0600))
+	test.S(t).ExpectNil(ioutil.WriteFile(files.CertFile, certPEM, 0600))
+	test.S(t).ExpectNil(ioutil.WriteFile(files.KeyFile, keyPEM, 0600))
+	return files
+}
+
+func handshake(t *testing.T, serverConfig *tls.Config, clientConfig *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	test.S(t).ExpectNil(err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	if err == nil {
+		conn.Close()
+	}
+	if sErr := <-serverErr; sErr != nil && err == nil {
+		err = sErr
+	}
+	return err
+}
+
+func TestMutualConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-tlsutil")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "freno-ca")
+	node1, err := NewReloader(writeFiles(t, dir, ca, "node1", 2))
+	test.S(t).ExpectNil(err)
+	node2, err := NewReloader(writeFiles(t, dir, ca, "node2", 3))
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectNil(handshake(t, MutualConfig(node1, nil), MutualConfig(node2, nil)))
+	test.S(t).ExpectNil(handshake(t, MutualConfig(node1, []string{"node2"}), MutualConfig(node2, []string{"127.0.0.1"})))
+	// node1 does not allow node2's identity
+	test.S(t).ExpectNotNil(handshake(t, MutualConfig(node1, []string{"node3"}), MutualConfig(node2, nil)))
+
+	// a certificate signed by another CA is rejected
+	otherDir, err := ioutil.TempDir("", "freno-tlsutil")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(otherDir)
+	rogue, err := NewReloader(writeFiles(t, otherDir, newTestCA(t, "rogue-ca"), "node2", 4))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNotNil(handshake(t, MutualConfig(node1, nil), MutualConfig(rogue, nil)))
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-tlsutil")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	defer func(interval time.Duration) { reloadCheckInterval = interval }(reloadCheckInterval)
+	reloadCheckInterval = 0
+
+	ca := newTestCA(t, "freno-ca")
+	files := writeFiles(t, dir, ca, "node1", 2)
+	reloader, err := NewReloader(files)
+	test.S(t).ExpectNil(err)
+	leaf, err := x509.ParseCertificate(reloader.Certificate().Certificate[0])
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(leaf.SerialNumber.Int64(), int64(2))
+
+	// unchanged files: same certificate
+	test.S(t).ExpectTrue(reloader.Certificate() == reloader.Certificate())
+
+	// rotate certificate
+	writeFiles(t, dir, ca, "node1", 5)
+	future := time.Now().Add(time.Minute)
+	for _, path := range []string{files.CertFile, files.KeyFile} {
+		test.S(t).ExpectNil(os.Chtimes(path, future, future))
+	}
+	leaf, err = x509.ParseCertificate(reloader.Certificate().Certificate[0])
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(leaf.SerialNumber.Int64(), int64(5))
+
+	// a broken file keeps the last good certificate
+	test.S(t).ExpectNil(ioutil.WriteFile(files.CertFile, []byte("garbage"), 0600))
+	future = future.Add(time.Minute)
+	test.S(t).ExpectNil(os.Chtimes(files.CertFile, future, future))
+	leaf, err = x509.ParseCertificate(reloader.Certificate().Certificate[0])
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(leaf.SerialNumber.Int64(), int64(5))
+}
+
+func TestServerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freno-tlsutil")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "freno-ca")
+	server, err := NewReloader(writeFiles(t, dir, ca, "server", 2))
+	test.S(t).ExpectNil(err)
+	client, err := NewReloader(writeFiles(t, dir, ca, "client", 3))
+	test.S(t).ExpectNil(err)
+	anonymous, err := NewReloader(Files{CAFile: filepath.Join(dir, "ca.pem")})
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectNil(handshake(t, ServerConfig(server, false), ClientConfig(client)))
+	test.S(t).ExpectNil(handshake(t, ServerConfig(server, false), ClientConfig(anonymous)))
+	test.S(t).ExpectNil(handshake(t, ServerConfig(server, true), ClientConfig(client)))
+	test.S(t).ExpectNotNil(handshake(t, ServerConfig(server, true), ClientConfig(anonymous)))
+}